@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GitHubActions holds the GitHub Actions workflow integration options.
+// StepSummary and Output are the files referenced by the
+// GITHUB_STEP_SUMMARY and GITHUB_OUTPUT environment variables, and
+// Annotations is where workflow commands are written, apart from the
+// report.
+type GitHubActions struct {
+	StepSummary string
+	Output      string
+	Thresholds  []Threshold
+	Annotations io.Writer
+}
+
+// Threshold is the maximum value of a duration metric, above which a
+// warning is emitted for a pull request.
+type Threshold struct {
+	Metric DurationMetric
+	Max    time.Duration
+}
+
+// newGitHubActions returns the GitHub Actions workflow integration options
+// from the environment of the running job.
+func newGitHubActions(thresholds []Threshold, annotations io.Writer) (*GitHubActions, error) {
+	stepSummary := os.Getenv("GITHUB_STEP_SUMMARY")
+	if stepSummary == "" {
+		return nil, errors.New("GITHUB_STEP_SUMMARY is not set, --github-step-summary is only supported in GitHub Actions")
+	}
+
+	return &GitHubActions{
+		StepSummary: stepSummary,
+		Output:      os.Getenv("GITHUB_OUTPUT"),
+		Thresholds:  thresholds,
+		Annotations: annotations,
+	}, nil
+}
+
+// newThresholds returns the thresholds described by values in
+// 'METRIC=DURATION' format, e.g. 'feature-lead-time=72h'.
+func newThresholds(values []string) ([]Threshold, error) {
+	var thresholds []Threshold
+	for _, value := range values {
+		key, duration, found := strings.Cut(value, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid threshold %q, must be in 'METRIC=DURATION' format", value)
+		}
+
+		metric, err := durationMetric(key)
+		if err != nil {
+			return nil, err
+		}
+
+		max, err := time.ParseDuration(duration)
+		if err != nil {
+			return nil, fmt.Errorf("invalid threshold for %s: %w", key, err)
+		}
+		thresholds = append(thresholds, Threshold{Metric: metric, Max: max})
+	}
+
+	return thresholds, nil
+}
+
+// escapeWorkflowCommand escapes a value for use in a workflow command, such
+// as an annotation. Properties additionally need colons and commas escaped.
+func escapeWorkflowCommand(s string, property bool) string {
+	s = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+	if property {
+		s = strings.NewReplacer(":", "%3A", ",", "%2C").Replace(s)
+	}
+
+	return s
+}
+
+// outputSeconds formats a duration as a whole number of seconds for a step
+// output, or an empty string if it is not valid.
+func outputSeconds(d NullDuration) string {
+	if !d.Valid {
+		return ""
+	}
+
+	return strconv.FormatInt(int64(d.Duration.Round(time.Second)/time.Second), 10)
+}
+
+// appendFile appends content to a file, creating it if necessary.
+func appendFile(path, content string) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// stepSummary returns the Markdown report appended to the job summary. It
+// is formatted as Markdown output would be, whatever the output format, so
+// that PR numbers link to the PRs and durations don't use the CSV default
+// of clock durations.
+func (ui *UI) stepSummary(pullRequests []PullRequest, metrics []PullRequestMetrics) (string, error) {
+	t, err := ui.reportTable(pullRequests, metrics, FormatMarkdown)
+	if err != nil {
+		return "", err
+	}
+
+	return ui.markdownHeader(metrics) + t.RenderMarkdown() + "\n\n", nil
+}
+
+// reportToGitHubActions appends a Markdown version of the report to the job
+// summary, writes the summary statistics as step outputs and emits a
+// warning for every pull request that breaches a threshold.
+func (ui *UI) reportToGitHubActions(pullRequests []PullRequest, metrics []PullRequestMetrics) error {
+	actions := ui.Actions
+
+	summary, err := ui.stepSummary(pullRequests, metrics)
+	if err != nil {
+		return err
+	}
+	if err := appendFile(actions.StepSummary, summary); err != nil {
+		return err
+	}
+
+	// Annotations are read by people, so they don't use the CSV default of
+	// clock durations.
+	format := ui.DurationFormat
+	if format == "" {
+		format = DurationFormatGo
+	}
+
+	breaches := 0
+	for _, m := range metrics {
+		for _, threshold := range actions.Thresholds {
+			d := threshold.Metric.Value(m)
+			if !d.Valid || d.Duration <= threshold.Max {
+				continue
+			}
+
+			breaches++
+			title := fmt.Sprintf("%s threshold exceeded", threshold.Metric.Name)
+			message := fmt.Sprintf(
+				"Pull request #%d took %s, exceeding the threshold of %s",
+				m.PullRequest.Number,
+				formatDuration(d.Duration, format, ui.workdayLength()),
+				formatDuration(threshold.Max, format, ui.workdayLength()),
+			)
+			if m.PullRequest.URL != "" {
+				message += fmt.Sprintf(" (%s)", m.PullRequest.URL)
+			}
+			fmt.Fprintf(actions.Annotations, "::warning title=%s::%s\n", escapeWorkflowCommand(title, true), escapeWorkflowCommand(message, false))
+		}
+	}
+
+	if actions.Output == "" {
+		return nil
+	}
+
+	var outputs strings.Builder
+	fmt.Fprintf(&outputs, "pull-requests=%d\n", len(metrics))
+	for _, summary := range summarize(metrics, ui.durationMetrics()) {
+		fmt.Fprintf(&outputs, "%s-median=%s\n", summary.Key, outputSeconds(summary.Median))
+		fmt.Fprintf(&outputs, "%s-mean=%s\n", summary.Key, outputSeconds(summary.Mean))
+		fmt.Fprintf(&outputs, "%s-p90=%s\n", summary.Key, outputSeconds(summary.P90))
+	}
+	fmt.Fprintf(&outputs, "threshold-breaches=%d\n", breaches)
+
+	return appendFile(actions.Output, outputs.String())
+}
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nbio/st"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func Test_newThresholds(t *testing.T) {
+	thresholds, err := newThresholds([]string{"feature-lead-time=72h", "time-to-first-review=30m"})
+	st.Assert(t, err, nil)
+	st.Assert(t, len(thresholds), 2)
+	st.Assert(t, thresholds[0].Metric.Name, "Feature Lead Time")
+	st.Assert(t, thresholds[0].Max, 72*time.Hour)
+	st.Assert(t, thresholds[1].Metric.Name, "Time to First Review")
+	st.Assert(t, thresholds[1].Max, 30*time.Minute)
+
+	_, err = newThresholds([]string{"feature-lead-time"})
+	st.Assert(t, strings.Contains(err.Error(), "must be in 'METRIC=DURATION' format"), true)
+
+	_, err = newThresholds([]string{"cycle-time=72h"})
+	st.Assert(t, strings.Contains(err.Error(), `invalid metric "cycle-time"`), true)
+
+	_, err = newThresholds([]string{"feature-lead-time=3d"})
+	st.Assert(t, strings.Contains(err.Error(), "invalid threshold for feature-lead-time"), true)
+}
+
+func Test_escapeWorkflowCommand(t *testing.T) {
+	st.Assert(t, escapeWorkflowCommand("100% done\nnext: a, b", false), "100%25 done%0Anext: a, b")
+	st.Assert(t, escapeWorkflowCommand("100% done\nnext: a, b", true), "100%25 done%0Anext%3A a%2C b")
+}
+
+func Test_outputSeconds(t *testing.T) {
+	st.Assert(t, outputSeconds(NullDuration{}), "")
+	st.Assert(t, outputSeconds(NullDuration{Duration: 90*time.Minute + 400*time.Millisecond, Valid: true}), "5400")
+}
+
+func Test_RootCmd_GitHubStepSummary(t *testing.T) {
+	defer gock.Off()
+
+	dir := t.TempDir()
+	stepSummary := filepath.Join(dir, "step_summary.md")
+	output := filepath.Join(dir, "output")
+	st.Assert(t, os.WriteFile(stepSummary, []byte("# Previous step\n\n"), 0o644), nil)
+	t.Setenv("GITHUB_STEP_SUMMARY", stepSummary)
+	t.Setenv("GITHUB_OUTPUT", output)
+
+	gock.New("https://api.github.com/graphql").
+		Post("/").
+		MatchType("json").
+		AddMatcher(gqlSearchQueryMatcher(Owner, Repository, StartDate, EndDate)).
+		Reply(200).
+		BodyString(ResponseJSON)
+
+	stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+	RootCmd.SetOut(stdout)
+	RootCmd.SetErr(stderr)
+	ResetSubCommandFlagValues(t, RootCmd)
+	RootCmd.SetArgs(strings.Split(fmt.Sprintf("--repo=%s/%s --start=%s --end=%s --csv --github-step-summary --threshold=time-to-first-review=24h --threshold=feature-lead-time=72h", Owner, Repository, StartDate, EndDate), " "))
+	RootCmd.Execute()
+
+	st.Assert(t, strings.Contains(stdout.String(), "5339,1,6,3,1"), true)
+	st.Assert(t, strings.Contains(stdout.String(), "::warning "), false)
+	st.Assert(t, strings.Contains(stderr.String(), "::warning title=Time to First Review threshold exceeded::Pull request #5339 took 155h26m, exceeding the threshold of 24h0m (https://github.com/testOwner/testRepo/pull/5339)\n"), true)
+	st.Assert(t, strings.Count(stderr.String(), "::warning "), 2)
+
+	summary, err := os.ReadFile(stepSummary)
+	st.Assert(t, err, nil)
+	st.Assert(t, strings.HasPrefix(string(summary), "# Previous step\n\n## Pull request metrics for testOwner/testRepo\n"), true)
+	st.Assert(t, strings.Contains(string(summary), "| PR | Commits |"), true)
+	st.Assert(t, strings.Contains(string(summary), "| [5339](https://github.com/testOwner/testRepo/pull/5339) | 1 |"), true)
+	st.Assert(t, strings.Contains(string(summary), "| 155h26m |"), true)
+
+	outputs, err := os.ReadFile(output)
+	st.Assert(t, err, nil)
+	st.Assert(t, strings.HasPrefix(string(outputs), "pull-requests=2\ntime-to-first-review-median=559586\n"), true)
+	st.Assert(t, strings.Contains(string(outputs), "first-to-last-review-p90=86399\n"), true)
+	st.Assert(t, strings.HasSuffix(string(outputs), "threshold-breaches=2\n"), true)
+}
+
+func Test_RootCmd_GitHubStepSummaryOutsideActions(t *testing.T) {
+	t.Setenv("GITHUB_STEP_SUMMARY", "")
+
+	actual := execute(t, "--repo=cli/cli --github-step-summary")
+	expected := "GITHUB_STEP_SUMMARY is not set"
+
+	st.Assert(t, strings.Contains(actual, expected), true)
+}
+
+func Test_RootCmd_ThresholdWithoutStepSummary(t *testing.T) {
+	actual := execute(t, "--repo=cli/cli --threshold=feature-lead-time=72h")
+	expected := "--threshold requires --github-step-summary"
+
+	st.Assert(t, strings.Contains(actual, expected), true)
+}
@@ -0,0 +1,180 @@
+// Charts and table sorting shared by the HTML report and the dashboard.
+var ghMetrics = (function () {
+  "use strict";
+
+  var SVG = "http://www.w3.org/2000/svg";
+  var WIDTH = 360;
+  var HEIGHT = 200;
+  var PADDING = { top: 10, right: 10, bottom: 30, left: 45 };
+
+  function el(name, attrs, parent) {
+    var node = document.createElementNS(SVG, name);
+    Object.keys(attrs || {}).forEach(function (key) {
+      node.setAttribute(key, attrs[key]);
+    });
+    if (parent) {
+      parent.appendChild(node);
+    }
+    return node;
+  }
+
+  function text(parent, x, y, value, anchor) {
+    var node = el("text", { x: x, y: y, "text-anchor": anchor || "start" }, parent);
+    node.textContent = value;
+    return node;
+  }
+
+  function chart(container, title) {
+    var figure = document.createElement("div");
+    figure.className = "chart";
+    var heading = document.createElement("h3");
+    heading.textContent = title;
+    figure.appendChild(heading);
+    container.appendChild(figure);
+
+    return el("svg", { width: WIDTH, height: HEIGHT, viewBox: "0 0 " + WIDTH + " " + HEIGHT }, figure);
+  }
+
+  function scale(domainMax, rangeMin, rangeMax) {
+    var max = domainMax > 0 ? domainMax : 1;
+    return function (value) {
+      return rangeMin + (value / max) * (rangeMax - rangeMin);
+    };
+  }
+
+  function axes(svg, yMax, yLabel) {
+    var bottom = HEIGHT - PADDING.bottom;
+    el("line", { class: "axis", x1: PADDING.left, y1: PADDING.top, x2: PADDING.left, y2: bottom }, svg);
+    el("line", { class: "axis", x1: PADDING.left, y1: bottom, x2: WIDTH - PADDING.right, y2: bottom }, svg);
+    text(svg, PADDING.left - 4, PADDING.top + 8, yMax.toFixed(1) + yLabel, "end");
+    text(svg, PADDING.left - 4, bottom, "0", "end");
+  }
+
+  function lineChart(container, title, labels, values) {
+    var svg = chart(container, title);
+    var present = values.filter(function (v) { return v !== null; });
+    if (present.length === 0) {
+      text(svg, WIDTH / 2, HEIGHT / 2, "No data", "middle");
+      return;
+    }
+
+    var yMax = Math.max.apply(null, present);
+    var y = scale(yMax, HEIGHT - PADDING.bottom, PADDING.top);
+    var x = scale(Math.max(labels.length - 1, 1), PADDING.left, WIDTH - PADDING.right);
+    axes(svg, yMax, "h");
+
+    var path = "";
+    var move = true;
+    values.forEach(function (value, i) {
+      if (value === null) {
+        move = true;
+        return;
+      }
+      path += (move ? "M" : "L") + x(i) + "," + y(value);
+      move = false;
+      var point = el("circle", { class: "point", cx: x(i), cy: y(value), r: 3 }, svg);
+      el("title", {}, point).textContent = labels[i] + ": " + value.toFixed(1) + "h";
+    });
+    el("path", { class: "line", d: path }, svg);
+
+    text(svg, PADDING.left, HEIGHT - 10, labels[0]);
+    if (labels.length > 1) {
+      text(svg, WIDTH - PADDING.right, HEIGHT - 10, labels[labels.length - 1], "end");
+    }
+  }
+
+  function scatterChart(container, title, points) {
+    var svg = chart(container, title);
+    if (points.length === 0) {
+      text(svg, WIDTH / 2, HEIGHT / 2, "No data", "middle");
+      return;
+    }
+
+    var xMax = Math.max.apply(null, points.map(function (p) { return p.size; }));
+    var yMax = Math.max.apply(null, points.map(function (p) { return p.hours; }));
+    var x = scale(xMax, PADDING.left, WIDTH - PADDING.right);
+    var y = scale(yMax, HEIGHT - PADDING.bottom, PADDING.top);
+    axes(svg, yMax, "h");
+    text(svg, WIDTH - PADDING.right, HEIGHT - 10, xMax + " lines changed", "end");
+
+    points.forEach(function (p) {
+      var parent = svg;
+      if (p.url) {
+        parent = el("a", { href: p.url }, svg);
+      }
+      var point = el("circle", { class: "point", cx: x(p.size), cy: y(p.hours), r: 4 }, parent);
+      el("title", {}, point).textContent = "#" + p.number + ": " + p.size + " lines, " + p.hours.toFixed(1) + "h";
+    });
+  }
+
+  // Sorting of tables. Durations such as "1h2m3s" are compared by
+  // their length in seconds, numbers numerically, and empty cells sort last.
+  var UNITS = { h: 3600, m: 60, s: 1, ms: 0.001 };
+
+  function sortKey(value) {
+    value = value.trim();
+    if (value === "" || value === "--") {
+      return null;
+    }
+    if (/^-?\d+(\.\d+)?$/.test(value)) {
+      return parseFloat(value);
+    }
+    var match = value.match(/^(\d+(\.\d+)?(h|ms|m|s))+$/);
+    if (match) {
+      var seconds = 0;
+      value.replace(/(\d+(?:\.\d+)?)(h|ms|m|s)/g, function (_, amount, unit) {
+        seconds += parseFloat(amount) * UNITS[unit];
+      });
+      return seconds;
+    }
+    return value.toLowerCase();
+  }
+
+  function compare(a, b) {
+    if (a === b) {
+      return 0;
+    }
+    if (a === null) {
+      return 1;
+    }
+    if (b === null) {
+      return -1;
+    }
+    if (typeof a !== typeof b) {
+      return typeof a === "number" ? -1 : 1;
+    }
+    return a < b ? -1 : 1;
+  }
+
+  function sortable(table) {
+    var body = table.tBodies[0];
+    if (!body) {
+      return;
+    }
+    var headers = table.querySelectorAll("thead th");
+    headers.forEach(function (header, column) {
+      header.addEventListener("click", function () {
+        var direction = header.getAttribute("data-sort") === "asc" ? "desc" : "asc";
+        headers.forEach(function (h) { h.removeAttribute("data-sort"); });
+        header.setAttribute("data-sort", direction);
+
+        var rows = Array.prototype.slice.call(body.rows);
+        rows.sort(function (a, b) {
+          var ka = sortKey(a.cells[column].textContent);
+          var kb = sortKey(b.cells[column].textContent);
+          if (ka === null || kb === null) {
+            return compare(ka, kb);
+          }
+          return direction === "asc" ? compare(ka, kb) : compare(kb, ka);
+        });
+        rows.forEach(function (row) { body.appendChild(row); });
+      });
+    });
+  }
+
+  return {
+    lineChart: lineChart,
+    scatterChart: scatterChart,
+    sortable: sortable
+  };
+})();
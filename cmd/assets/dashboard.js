@@ -0,0 +1,146 @@
+(function () {
+  "use strict";
+
+  var form = document.getElementById("options");
+  var error = document.getElementById("error");
+  var table = document.getElementById("pull-request-table");
+  var metrics = Array.prototype.map.call(table.querySelectorAll("th[data-metric]"), function (th) {
+    return { key: th.getAttribute("data-metric"), name: th.textContent };
+  });
+
+  // formatDuration formats a number of seconds like the command line
+  // output, in hours and minutes rounded to the nearest minute.
+  function formatDuration(seconds) {
+    if (seconds === null || seconds === undefined) {
+      return "--";
+    }
+    var minutes = Math.round(seconds / 60);
+    var hours = Math.floor(minutes / 60);
+    return hours > 0 ? hours + "h" + (minutes % 60) + "m" : minutes + "m";
+  }
+
+  function cell(row, value) {
+    var td = document.createElement("td");
+    if (value instanceof Node) {
+      td.appendChild(value);
+    } else {
+      td.textContent = value;
+    }
+    row.appendChild(td);
+    return td;
+  }
+
+  function params() {
+    var search = new URLSearchParams();
+    Array.prototype.forEach.call(form.elements, function (input) {
+      if (!input.name || input.disabled) {
+        return;
+      }
+      if (input.type === "checkbox") {
+        search.set(input.name, input.checked ? "true" : "false");
+      } else if (input.value) {
+        search.set(input.name, input.value);
+      }
+    });
+    return search.toString();
+  }
+
+  function get(path, query) {
+    return fetch(path + "?" + query).then(function (response) {
+      return response.json().then(function (body) {
+        if (!response.ok) {
+          throw new Error(body.error || response.statusText);
+        }
+        return body;
+      });
+    });
+  }
+
+  function renderSummary(summary) {
+    document.getElementById("pull-requests").textContent = summary.pullRequests + " pull requests";
+    var body = document.getElementById("summary");
+    body.innerHTML = "";
+    summary.metrics.forEach(function (metric) {
+      var row = document.createElement("tr");
+      cell(row, metric.name);
+      cell(row, metric.count);
+      cell(row, formatDuration(metric.median));
+      cell(row, formatDuration(metric.mean));
+      cell(row, formatDuration(metric.p90));
+      body.appendChild(row);
+    });
+  }
+
+  function renderTrend(trend) {
+    var container = document.getElementById("trend-charts");
+    container.innerHTML = "";
+    var weeks = trend.map(function (point) { return point.week; });
+    metrics.forEach(function (metric) {
+      ghMetrics.lineChart(container, metric.name, weeks, trend.map(function (point) {
+        var seconds = point.medians[metric.key];
+        return seconds === null ? null : seconds / 3600;
+      }));
+    });
+  }
+
+  function renderPullRequests(pullRequests) {
+    var body = table.tBodies[0];
+    body.innerHTML = "";
+    table.querySelectorAll("th").forEach(function (th) { th.removeAttribute("data-sort"); });
+    pullRequests.forEach(function (pr) {
+      var row = document.createElement("tr");
+      var link = document.createElement("a");
+      link.href = pr.url;
+      link.textContent = pr.number;
+      cell(row, link);
+      cell(row, pr.author);
+      [pr.commits, pr.additions, pr.deletions, pr.changedFiles, pr.comments, pr.participants].forEach(function (value) {
+        cell(row, value).align = "right";
+      });
+      metrics.forEach(function (metric) {
+        cell(row, formatDuration(pr.durations[metric.key]));
+      });
+      cell(row, pr.labels.length > 0 ? pr.labels.join(", ") : "--");
+      body.appendChild(row);
+    });
+  }
+
+  function refresh() {
+    var query = params();
+    error.textContent = "";
+    history.replaceState(null, "", "?" + query);
+
+    Promise.all([
+      get("api/summary", query),
+      get("api/trend", query),
+      get("api/prs", query)
+    ]).then(function (results) {
+      renderSummary(results[0]);
+      renderTrend(results[1]);
+      renderPullRequests(results[2]);
+    }).catch(function (err) {
+      error.textContent = err.message;
+    });
+  }
+
+  // Restore the options of a previously shared URL.
+  new URLSearchParams(location.search).forEach(function (value, name) {
+    var input = form.elements[name];
+    if (!input || input.disabled) {
+      return;
+    }
+    if (input.type === "checkbox") {
+      input.checked = value === "true";
+    } else {
+      input.value = value;
+    }
+  });
+
+  form.addEventListener("submit", function (event) {
+    event.preventDefault();
+    refresh();
+  });
+
+  ghMetrics.sortable(table);
+  refresh();
+})();
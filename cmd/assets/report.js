@@ -0,0 +1,13 @@
+(function () {
+  "use strict";
+
+  var data = JSON.parse(document.getElementById("report-data").textContent);
+
+  var trends = document.getElementById("trend-charts");
+  data.metrics.forEach(function (metric, i) {
+    ghMetrics.lineChart(trends, metric, data.weeks, data.trend.map(function (medians) { return medians[i]; }));
+  });
+  ghMetrics.scatterChart(document.getElementById("scatter-chart"), "Lines changed vs. feature lead time", data.scatter);
+
+  document.querySelectorAll("table.go-pretty-table").forEach(ghMetrics.sortable);
+})();
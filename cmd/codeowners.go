@@ -0,0 +1,378 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	graphql "github.com/cli/shurcooL-graphql"
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+const (
+	OwnerApprovedYes = "yes"
+	OwnerApprovedNo  = "no"
+)
+
+// CodeOwnerColumns lists the columns that require CODEOWNERS rules.
+var CodeOwnerColumns = []string{"time-to-first-owner-review", "owner-approved"}
+
+// CodeOwnersRule is a line of a CODEOWNERS file: a pattern of paths and
+// the users (@login), teams (@org/team-slug) or email addresses owning
+// them.
+type CodeOwnersRule struct {
+	Pattern string
+	Owners  []string
+	re      *regexp.Regexp
+}
+
+// CodeOwners holds the rules of a CODEOWNERS file, in order.
+type CodeOwners struct {
+	Rules []CodeOwnersRule
+}
+
+// codeOwnersPattern returns a regular expression matching the paths a
+// CODEOWNERS pattern applies to. Patterns follow the rules of .gitignore
+// files: a pattern containing a slash, other than a trailing one, is
+// relative to the root of the repository, otherwise it matches at any
+// depth, and a pattern matching a directory matches everything under it,
+// except for patterns ending in '/*'.
+func codeOwnersPattern(pattern string) (*regexp.Regexp, error) {
+	anchored := strings.Contains(strings.TrimSuffix(pattern, "/"), "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+	directory := strings.HasSuffix(pattern, "/")
+	pattern = strings.TrimSuffix(pattern, "/")
+	if pattern == "" {
+		return nil, errors.New("empty pattern")
+	}
+
+	var b strings.Builder
+	b.WriteString("^")
+	if !anchored {
+		b.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(pattern); i++ {
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case pattern[i] == '*':
+			b.WriteString("[^/]*")
+		case pattern[i] == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+	switch {
+	case directory:
+		b.WriteString("/.*")
+	case !strings.HasSuffix(pattern, "/*"):
+		b.WriteString("(?:/.*)?")
+	}
+	b.WriteString("$")
+
+	return regexp.Compile(b.String())
+}
+
+// parseCodeOwners parses the content of a CODEOWNERS file. Blank lines and
+// comments are ignored.
+func parseCodeOwners(text string) (*CodeOwners, error) {
+	codeOwners := &CodeOwners{}
+	for i, line := range strings.Split(text, "\n") {
+		if comment := strings.Index(line, "#"); comment >= 0 {
+			line = line[:comment]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		re, err := codeOwnersPattern(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid CODEOWNERS pattern %q on line %d: %w", fields[0], i+1, err)
+		}
+		codeOwners.Rules = append(codeOwners.Rules, CodeOwnersRule{Pattern: fields[0], Owners: fields[1:], re: re})
+	}
+
+	return codeOwners, nil
+}
+
+// Owners returns the owners of a path. As on GitHub, the last matching
+// rule takes precedence.
+func (c *CodeOwners) Owners(path string) []string {
+	for i := len(c.Rules) - 1; i >= 0; i-- {
+		if c.Rules[i].re.MatchString(path) {
+			return c.Rules[i].Owners
+		}
+	}
+
+	return nil
+}
+
+// filesTruncated returns true if a PR changed more files than fetched, so
+// that the owners of some of them are unknown.
+func filesTruncated(pr PullRequest) bool {
+	return pr.ChangedFiles > len(pr.Files.Nodes)
+}
+
+// ownersOf returns the owners of the files changed by a PR, in order of
+// first appearance, or none if not every changed file was fetched.
+func (c *CodeOwners) ownersOf(pr PullRequest) []string {
+	if filesTruncated(pr) {
+		return nil
+	}
+
+	var owners []string
+	seen := make(map[string]bool)
+	for _, file := range pr.Files.Nodes {
+		for _, owner := range c.Owners(file.Path) {
+			if !seen[owner] {
+				seen[owner] = true
+				owners = append(owners, owner)
+			}
+		}
+	}
+
+	return owners
+}
+
+// loadCodeOwners returns the CODEOWNERS rules read from a local file, or
+// fetched from the default branch of the repository if path is empty.
+func (ui *UI) loadCodeOwners(path string) (*CodeOwners, error) {
+	text, err := ui.readCodeOwners(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseCodeOwners(text)
+}
+
+// readCodeOwners returns the content of a local CODEOWNERS file, or of the
+// one of the repository if path is empty.
+func (ui *UI) readCodeOwners(path string) (string, error) {
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	}
+
+	return ui.fetchCodeOwners()
+}
+
+// fetchCodeOwners returns the content of the CODEOWNERS file of the
+// repository, looked up in the same locations as GitHub does.
+func (ui *UI) fetchCodeOwners() (string, error) {
+	client, err := ui.gqlClient()
+	if err != nil {
+		return "", err
+	}
+
+	var gqlQuery CodeOwnersGQLQuery
+	gqlQueryVariables := map[string]interface{}{
+		"owner": graphql.String(ui.Owner),
+		"name":  graphql.String(ui.Repository),
+	}
+
+	ui.rateLimit().wait()
+	if err := client.Query("CodeOwners", &gqlQuery, gqlQueryVariables); err != nil {
+		return "", err
+	}
+	ui.rateLimit().observe(gqlQuery.RateLimit)
+
+	repository := gqlQuery.Repository
+	for _, blob := range []Blob{repository.GitHub, repository.Root, repository.Docs} {
+		if blob.Blob.Text != "" {
+			return blob.Blob.Text, nil
+		}
+	}
+
+	return "", fmt.Errorf("no CODEOWNERS file found in %s/%s, use --codeowners to read a local one", ui.Owner, ui.Repository)
+}
+
+// ownerTeam returns the team a given owner refers to, if it is a team.
+func ownerTeam(owner string) (GHTeam, bool) {
+	name, ok := strings.CutPrefix(owner, "@")
+	if !ok {
+		return GHTeam{}, false
+	}
+	org, slug, ok := strings.Cut(name, "/")
+
+	return GHTeam{Org: org, Slug: slug}, ok
+}
+
+// isOwner returns true if a user is a given owner, or a member of it if it
+// is a team. Owners given by email address can't be matched to users.
+func (ui *UI) isOwner(login, owner string) bool {
+	if team, ok := ownerTeam(owner); ok {
+		return ui.isTeamMember(team, login)
+	}
+
+	name, ok := strings.CutPrefix(owner, "@")
+	return ok && strings.EqualFold(name, login)
+}
+
+// ownerReviewSpan returns the events the time to first review of a PR by
+// one of the given owners is measured between.
+func (ui *UI) ownerReviewSpan(pr PullRequest, owners []string) (Span, error) {
+	if len(owners) == 0 {
+		return Span{}, errors.New("no changed files with code owners")
+	}
+
+	var reviews ReviewNodes
+	for _, review := range pr.Reviews.Nodes {
+		for _, owner := range owners {
+			if ui.isOwner(review.Author.Login, owner) {
+				reviews = append(reviews, review)
+				break
+			}
+		}
+	}
+
+	return timeToFirstReviewSpan(pr.Author.Login, pr.CreatedAt, pr.IsDraft, pr.TimelineItems, Reviews{Nodes: reviews})
+}
+
+// timeToFirstOwnerReview returns the time to the first review of a PR by
+// an owner of any of its changed files.
+func (ui *UI) timeToFirstOwnerReview(pr PullRequest) NullDuration {
+	return ui.spanDuration(ui.ownerReviewSpan(pr, ui.CodeOwners.ownersOf(pr)))
+}
+
+// approvedByOwner returns true if a PR was approved by one of the given
+// owners, other than its author.
+func (ui *UI) approvedByOwner(pr PullRequest, owners []string) bool {
+	for _, review := range pr.Reviews.Nodes {
+		if review.State != ReviewApprovedState || review.Author.Login == pr.Author.Login {
+			continue
+		}
+		for _, owner := range owners {
+			if ui.isOwner(review.Author.Login, owner) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// ownerApproved returns OwnerApprovedYes if every changed file of a PR
+// that has code owners was approved by one of them, OwnerApprovedNo if
+// not, and an empty string if none of its changed files have owners, or
+// if that can't be told because not every changed file was fetched.
+func (ui *UI) ownerApproved(pr PullRequest) string {
+	owned := false
+	for _, file := range pr.Files.Nodes {
+		owners := ui.CodeOwners.Owners(file.Path)
+		if len(owners) == 0 {
+			continue
+		}
+		owned = true
+		if !ui.approvedByOwner(pr, owners) {
+			return OwnerApprovedNo
+		}
+	}
+
+	if !owned || filesTruncated(pr) {
+		return ""
+	}
+
+	return OwnerApprovedYes
+}
+
+// OwnerLatency holds how quickly an owner reviewed the PRs changing files
+// it owns.
+type OwnerLatency struct {
+	Owner        string
+	PullRequests int
+	Reviewed     int
+	Approved     int
+	Median       NullDuration
+	P90          NullDuration
+}
+
+// ownerLatencies returns the time to first review by each code owner of
+// the PRs changing files it owns. Owners whose reviews take the longest
+// are listed first.
+func (ui *UI) ownerLatencies(pullRequests []PullRequest) []OwnerLatency {
+	byOwner := make(map[string]*OwnerLatency)
+	durations := make(map[string][]time.Duration)
+	for _, pr := range pullRequests {
+		for _, owner := range ui.CodeOwners.ownersOf(pr) {
+			latency, ok := byOwner[owner]
+			if !ok {
+				latency = &OwnerLatency{Owner: owner}
+				byOwner[owner] = latency
+			}
+			latency.PullRequests++
+
+			if d := ui.spanDuration(ui.ownerReviewSpan(pr, []string{owner})); d.Valid {
+				latency.Reviewed++
+				durations[owner] = append(durations[owner], d.Duration)
+			}
+			if ui.approvedByOwner(pr, []string{owner}) {
+				latency.Approved++
+			}
+		}
+	}
+
+	latencies := make([]OwnerLatency, 0, len(byOwner))
+	for owner, latency := range byOwner {
+		var nullDurations []NullDuration
+		for _, d := range durations[owner] {
+			nullDurations = append(nullDurations, NullDuration{Duration: d, Valid: true})
+		}
+		latency.Median = medianDuration(nullDurations)
+		latency.P90 = percentileDuration(durations[owner], 90)
+		latencies = append(latencies, *latency)
+	}
+	sort.Slice(latencies, func(i, j int) bool {
+		a, b := latencies[i], latencies[j]
+		if a.Median.Valid != b.Median.Valid {
+			return a.Median.Valid
+		}
+		if a.Median.Duration != b.Median.Duration {
+			return a.Median.Duration > b.Median.Duration
+		}
+		return a.Owner < b.Owner
+	})
+
+	return latencies
+}
+
+// ownerLatencyTable returns a table containing a row per code owner,
+// formatted for a given output format.
+func (ui *UI) ownerLatencyTable(latencies []OwnerLatency, format string) table.Writer {
+	t := table.NewWriter()
+	t.SetStyle(table.StyleLight)
+
+	t.AppendHeader(table.Row{
+		"Owner",
+		"PRs",
+		"Reviewed",
+		"Approved",
+		"Median Time to First Review",
+		"90th Percentile",
+	})
+
+	for _, l := range latencies {
+		t.AppendRow(table.Row{
+			l.Owner,
+			l.PullRequests,
+			l.Reviewed,
+			l.Approved,
+			ui.formatNullDurationIn(l.Median, format),
+			ui.formatNullDurationIn(l.P90, format),
+		})
+	}
+
+	return t
+}
@@ -0,0 +1,211 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nbio/st"
+	"github.com/rickar/cal/v2"
+	"gopkg.in/h2non/gock.v1"
+)
+
+const CodeOwnersFile = `# Default owners
+*                 @gotham/villains
+
+/src/gadgets/     @Alfred
+docs/             @gotham/heroes @Joker # documentation
+`
+
+func Test_codeOwnersPattern(t *testing.T) {
+	cases := []struct {
+		pattern string
+		path    string
+		match   bool
+	}{
+		{"*", "src/main.go", true},
+		{"*.js", "web/app/index.js", true},
+		{"*.js", "web/app/index.go", false},
+		{"/build/logs/", "build/logs/today.log", true},
+		{"/build/logs/", "src/build/logs/today.log", false},
+		{"docs/", "src/docs/index.md", true},
+		{"docs/*", "docs/index.md", true},
+		{"docs/*", "docs/guides/index.md", false},
+		{"apps/github", "apps/github/index.ts", true},
+		{"apps/github", "src/apps/github/index.ts", false},
+		{"**/logs", "deeply/nested/logs/today.log", true},
+		{"/scripts/**/*.sh", "scripts/ci/release/build.sh", true},
+		{"README.md", "docs/README.md", true},
+		{"README.md", "README.markdown", false},
+	}
+
+	for _, c := range cases {
+		re, err := codeOwnersPattern(c.pattern)
+		st.Assert(t, err, nil)
+		st.Assert(t, fmt.Sprintf("%s %s %t", c.pattern, c.path, re.MatchString(c.path)), fmt.Sprintf("%s %s %t", c.pattern, c.path, c.match))
+	}
+}
+
+func Test_parseCodeOwners(t *testing.T) {
+	codeOwners, err := parseCodeOwners(CodeOwnersFile)
+	st.Assert(t, err, nil)
+
+	st.Assert(t, len(codeOwners.Rules), 3)
+	st.Assert(t, codeOwners.Owners("src/batmobile/tyres.go"), []string{"@gotham/villains"})
+	st.Assert(t, codeOwners.Owners("src/gadgets/grapple.go"), []string{"@Alfred"})
+	st.Assert(t, codeOwners.Owners("docs/gadgets.md"), []string{"@gotham/heroes", "@Joker"})
+
+	pr := PullRequest{Files: Files{Nodes: FileNodes{{Path: "src/gadgets/grapple.go"}, {Path: "src/gadgets/rope.go"}, {Path: "docs/gadgets.md"}}}}
+	st.Assert(t, codeOwners.ownersOf(pr), []string{"@Alfred", "@gotham/heroes", "@Joker"})
+}
+
+func Test_isOwner(t *testing.T) {
+	ui := newTeamsUI()
+
+	st.Assert(t, ui.isOwner("Joker", "@gotham/villains"), true)
+	st.Assert(t, ui.isOwner("Batman", "@gotham/villains"), false)
+	st.Assert(t, ui.isOwner("alfred", "@Alfred"), true)
+	st.Assert(t, ui.isOwner("Alfred", "alfred@wayne.example"), false)
+}
+
+// newCodeOwnersUI returns a UI with the rules of CodeOwnersFile loaded.
+func newCodeOwnersUI(t *testing.T) *UI {
+	codeOwners, err := parseCodeOwners(CodeOwnersFile)
+	st.Assert(t, err, nil)
+
+	ui := newTeamsUI()
+	ui.Teams = nil
+	ui.Owner = Owner
+	ui.Repository = Repository
+	ui.StartDate = StartDate
+	ui.EndDate = EndDate
+	ui.CodeOwners = codeOwners
+	ui.Calendar = cal.NewBusinessCalendar()
+
+	return ui
+}
+
+func Test_ownerApproved_TruncatedFiles(t *testing.T) {
+	ui := newCodeOwnersUI(t)
+	pr := PullRequest{
+		Author:       Author{Login: "Batman"},
+		ChangedFiles: 2,
+		Files:        Files{Nodes: FileNodes{{Path: "src/batmobile/tyres.go"}}},
+		Reviews:      Reviews{Nodes: ReviewNodes{{Author: Author{Login: "Riddler"}, State: ReviewApprovedState}}},
+	}
+
+	st.Assert(t, ui.CodeOwners.ownersOf(pr), []string(nil))
+	st.Assert(t, ui.ownerApproved(pr), "")
+
+	pr.Files.Nodes = append(pr.Files.Nodes, FileNodes{{Path: "src/batmobile/wheels.go"}}...)
+	st.Assert(t, ui.CodeOwners.ownersOf(pr), []string{"@gotham/villains"})
+	st.Assert(t, ui.ownerApproved(pr), OwnerApprovedYes)
+
+	pr.ChangedFiles = 3
+	pr.Files.Nodes[1].Path = "docs/batmobile.md"
+	st.Assert(t, ui.ownerApproved(pr), OwnerApprovedNo)
+}
+
+func Test_SearchQuery_CodeOwnerColumns(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.github.com/graphql").
+		Post("/").
+		MatchType("json").
+		AddMatcher(gqlSearchQueryMatcher(Owner, Repository, StartDate, EndDate)).
+		Reply(200).
+		BodyString(ResponseJSON)
+
+	ui := newCodeOwnersUI(t)
+	ui.CSVFormat = true
+	ui.Columns = []string{"number", "time-to-first-owner-review", "owner-approved"}
+
+	have := ui.PrintMetrics()
+
+	st.Assert(t, have, "PR,Time to First Owner Review,Owner Approved\n5339,38:13,yes\n5340,38:13,no")
+}
+
+func Test_SearchQuery_OwnerLatency(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.github.com/graphql").
+		Post("/").
+		MatchType("json").
+		AddMatcher(gqlSearchQueryMatcher(Owner, Repository, StartDate, EndDate)).
+		Reply(200).
+		BodyString(ResponseJSON)
+
+	ui := newCodeOwnersUI(t)
+	ui.CSVFormat = true
+	ui.OwnerLatency = true
+
+	have := ui.PrintMetrics()
+
+	st.Assert(t, have, "Owner,PRs,Reviewed,Approved,Median Time to First Review,90th Percentile\n"+
+		"@Joker,1,1,1,38:13,38:13\n"+
+		"@gotham/villains,1,1,1,38:13,38:13\n"+
+		"@Alfred,1,0,0,--,--\n"+
+		"@gotham/heroes,1,0,0,--,--")
+}
+
+// gqlCodeOwnersMatcher matches queries for the CODEOWNERS file.
+func gqlCodeOwnersMatcher(req *http.Request, ereq *gock.Request) (bool, error) {
+	body, err := io.ReadAll(req.Body)
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	return strings.Contains(string(body), "CODEOWNERS"), err
+}
+
+func Test_fetchCodeOwners(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.github.com/graphql").
+		Post("/").
+		MatchType("json").
+		AddMatcher(gqlCodeOwnersMatcher).
+		Reply(200).
+		JSON(map[string]interface{}{
+			"data": map[string]interface{}{
+				"repository": map[string]interface{}{
+					"github": nil,
+					"root":   map[string]interface{}{"text": CodeOwnersFile},
+					"docs":   nil,
+				},
+			},
+		})
+
+	ui := &UI{Owner: Owner, Repository: "codeOwnersRepo"}
+	codeOwners, err := ui.loadCodeOwners("")
+
+	st.Assert(t, err, nil)
+	st.Assert(t, len(codeOwners.Rules), 3)
+}
+
+func Test_RootCmd_CodeOwnersFile(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.github.com/graphql").
+		Post("/").
+		MatchType("json").
+		AddMatcher(gqlSearchQueryMatcher(Owner, Repository, StartDate, EndDate)).
+		Reply(200).
+		BodyString(ResponseJSON)
+
+	path := filepath.Join(t.TempDir(), "CODEOWNERS")
+	st.Assert(t, os.WriteFile(path, []byte("* @Joker\n"), 0o644), nil)
+
+	actual := execute(t, fmt.Sprintf("--repo=%s/%s --start=%s --end=%s --codeowners=%s --columns=number,owner-approved --format=csv", Owner, Repository, StartDate, EndDate, path))
+
+	st.Assert(t, actual, "PR,Owner Approved\n5339,yes\n5340,yes\n")
+}
+
+func Test_RootCmd_OwnerLatencyWithGroupBy(t *testing.T) {
+	actual := execute(t, "--repo=cli/cli --owner-latency --group-by=label")
+
+	st.Assert(t, strings.Contains(actual, "--owner-latency can't be combined with --group-by, --cross-team-reviews or --flag-rubber-stamps"), true)
+}
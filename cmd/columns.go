@@ -0,0 +1,330 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+// Column describes a column of the per pull request output. Value returns
+// the raw value of the column for a PR: an int, a string, a NullDuration, a
+// NullFloat or a list of strings. Fields are the optional connections of
+// pull requests it is computed from.
+type Column struct {
+	Key    string
+	Header string
+	Value  func(PullRequestMetrics) interface{}
+	Fields PullRequestFields
+}
+
+// Columns lists the columns available to `--columns` and `--sort`.
+var Columns = []Column{
+	{Key: "number", Header: "PR", Value: func(m PullRequestMetrics) interface{} { return m.PullRequest.Number }},
+	{Key: "title", Header: "Title", Value: func(m PullRequestMetrics) interface{} { return m.PullRequest.Title }},
+	{Key: "author", Header: "Author", Value: func(m PullRequestMetrics) interface{} { return m.PullRequest.Author.Login }},
+	{Key: "url", Header: "URL", Value: func(m PullRequestMetrics) interface{} { return m.PullRequest.URL }},
+	{Key: "created-at", Header: "Created At", Value: func(m PullRequestMetrics) interface{} { return m.PullRequest.CreatedAt }},
+	{Key: "merged-at", Header: "Merged At", Value: func(m PullRequestMetrics) interface{} { return m.PullRequest.MergedAt }},
+	{Key: "commits", Header: "Commits", Value: func(m PullRequestMetrics) interface{} { return m.PullRequest.Commits.TotalCount }},
+	{Key: "additions", Header: "Additions", Value: func(m PullRequestMetrics) interface{} { return m.PullRequest.Additions }},
+	{Key: "deletions", Header: "Deletions", Value: func(m PullRequestMetrics) interface{} { return m.PullRequest.Deletions }},
+	{Key: "changed-files", Header: "Changed Files", Value: func(m PullRequestMetrics) interface{} { return m.PullRequest.ChangedFiles }},
+	{Key: "time-to-first-review", Header: "Time to First Review", Value: func(m PullRequestMetrics) interface{} { return m.TimeToFirstReview }},
+	{Key: "time-to-first-response", Header: "Time to First Response", Value: func(m PullRequestMetrics) interface{} { return m.TimeToFirstResponse }, Fields: PullRequestFields{Responses: true}},
+	{Key: "comments", Header: "Comments", Value: func(m PullRequestMetrics) interface{} { return m.PullRequest.Comments.TotalCount }},
+	{Key: "time-to-first-owner-review", Header: "Time to First Owner Review", Value: func(m PullRequestMetrics) interface{} { return m.TimeToFirstOwnerReview }},
+	{Key: "owner-approved", Header: "Owner Approved", Value: func(m PullRequestMetrics) interface{} { return m.OwnerApproved }},
+	{Key: "time-to-requested-review", Header: "Time to Requested Review", Value: func(m PullRequestMetrics) interface{} { return m.TimeToRequestedReview }, Fields: PullRequestFields{ReviewRequests: true}},
+	{Key: "unanswered-review-requests", Header: "Unanswered Review Requests", Value: func(m PullRequestMetrics) interface{} { return unansweredReviewers(m.ReviewRequests) }, Fields: PullRequestFields{ReviewRequests: true}},
+	{Key: "linked-issues", Header: "Linked Issues", Value: func(m PullRequestMetrics) interface{} { return linkedIssues(m.PullRequest) }, Fields: PullRequestFields{LinkedIssues: true}},
+	{Key: "issue-created-to-merge", Header: "Issue Created to Merge", Value: func(m PullRequestMetrics) interface{} { return m.IssueCreatedToMerge }, Fields: PullRequestFields{LinkedIssues: true}},
+	{Key: "issue-started-to-merge", Header: "Issue Started to Merge", Value: func(m PullRequestMetrics) interface{} { return m.IssueStartedToMerge }, Fields: PullRequestFields{LinkedIssues: true}},
+	{Key: "review-comments", Header: "Review Comments", Value: func(m PullRequestMetrics) interface{} { return reviewComments(m.PullRequest) }},
+	{Key: "review-depth", Header: "Review Comments per 100 Lines", Value: func(m PullRequestMetrics) interface{} { return reviewDepth(m.PullRequest) }},
+	{Key: "participants", Header: "Participants", Value: func(m PullRequestMetrics) interface{} { return m.PullRequest.Participants.TotalCount }},
+	{Key: "feature-lead-time", Header: "Feature Lead Time", Value: func(m PullRequestMetrics) interface{} { return m.FeatureLeadTime }},
+	{Key: "first-to-last-review", Header: "First to Last Review", Value: func(m PullRequestMetrics) interface{} { return m.FirstReviewToLastReview }},
+	{Key: "first-approval-to-merge", Header: "First Approval to Merge", Value: func(m PullRequestMetrics) interface{} { return m.FirstApprovalToMerge }},
+	{Key: "approval-to-enqueue", Header: "Approval to Enqueue", Value: func(m PullRequestMetrics) interface{} { return m.ApprovalToEnqueue }, Fields: PullRequestFields{MergeQueue: true}},
+	{Key: "enqueue-to-merge", Header: "Enqueue to Merge", Value: func(m PullRequestMetrics) interface{} { return m.EnqueueToMerge }, Fields: PullRequestFields{MergeQueue: true}},
+	{Key: "time-in-merge-queue", Header: "Time in Merge Queue", Value: func(m PullRequestMetrics) interface{} { return m.TimeInMergeQueue }, Fields: PullRequestFields{MergeQueue: true}},
+	{Key: "merge-queue-ejections", Header: "Merge Queue Ejections", Value: func(m PullRequestMetrics) interface{} { return mergeQueueEjections(m.PullRequest) }, Fields: PullRequestFields{MergeQueue: true}},
+	{Key: "labels", Header: "Labels", Value: func(m PullRequestMetrics) interface{} { return labelNames(m.PullRequest.Labels) }},
+}
+
+// DefaultColumns lists the columns displayed when `--columns` isn't given.
+var DefaultColumns = []string{
+	"number",
+	"commits",
+	"additions",
+	"deletions",
+	"changed-files",
+	"time-to-first-review",
+	"comments",
+	"participants",
+	"feature-lead-time",
+	"first-to-last-review",
+	"first-approval-to-merge",
+	"labels",
+}
+
+// columnKeys returns the keys of every available column.
+func columnKeys() []string {
+	keys := make([]string, 0, len(Columns))
+	for _, c := range Columns {
+		keys = append(keys, c.Key)
+	}
+
+	return keys
+}
+
+// column returns the column identified by a given key.
+func column(key string) (Column, error) {
+	for _, c := range Columns {
+		if c.Key == key {
+			return c, nil
+		}
+	}
+
+	return Column{}, fmt.Errorf("invalid column %q, must be one of %v", key, columnKeys())
+}
+
+// usesAnyColumn returns true if any of the given columns, or the column
+// sorted by, is one of keys.
+func usesAnyColumn(columns []string, sortBy string, keys []string) bool {
+	for _, column := range append([]string{strings.TrimPrefix(sortBy, "-")}, columns...) {
+		for _, key := range keys {
+			if column == key {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// validateColumns returns an error if any of the given column keys is not
+// supported.
+func validateColumns(keys []string) error {
+	for _, key := range keys {
+		if _, err := column(key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// parseSort returns the column key and direction of a `--sort` value, which
+// is prefixed with '-' to sort in descending order.
+func parseSort(value string) (string, bool, error) {
+	key := strings.TrimPrefix(value, "-")
+	if _, err := column(key); err != nil {
+		return "", false, err
+	}
+
+	return key, strings.HasPrefix(value, "-"), nil
+}
+
+// columns returns the selected columns.
+func (ui *UI) columns() []Column {
+	keys := ui.Columns
+	if len(keys) == 0 {
+		keys = DefaultColumns
+	}
+
+	columns := make([]Column, 0, len(keys))
+	for _, key := range keys {
+		c, err := column(key)
+		if err != nil {
+			log.Fatal(err)
+		}
+		columns = append(columns, c)
+	}
+
+	return columns
+}
+
+// isEmpty returns true if a column value is missing, such as the duration
+// of a metric that couldn't be determined.
+func isEmpty(value interface{}) bool {
+	switch v := value.(type) {
+	case NullDuration:
+		return !v.Valid
+	case NullFloat:
+		return !v.Valid
+	case string:
+		return v == ""
+	case []string:
+		return len(v) == 0
+	}
+
+	return false
+}
+
+// lessValue returns true if column value a sorts before b. Both values must
+// come from the same column and not be empty.
+func lessValue(a, b interface{}) bool {
+	switch a := a.(type) {
+	case int:
+		return a < b.(int)
+	case NullDuration:
+		return a.Duration < b.(NullDuration).Duration
+	case NullFloat:
+		return a.Float64 < b.(NullFloat).Float64
+	case string:
+		return strings.ToLower(a) < strings.ToLower(b.(string))
+	case []string:
+		return strings.ToLower(strings.Join(a, ", ")) < strings.ToLower(strings.Join(b.([]string), ", "))
+	}
+
+	return false
+}
+
+// sortMetrics sorts PRs according to the `--sort` option. Empty values are
+// always sorted last, and ties keep their original order.
+func (ui *UI) sortMetrics(metrics []PullRequestMetrics) {
+	if ui.Sort == "" {
+		return
+	}
+
+	key, descending, err := parseSort(ui.Sort)
+	if err != nil {
+		log.Fatal(err)
+	}
+	c, _ := column(key)
+
+	sort.SliceStable(metrics, func(i, j int) bool {
+		a, b := c.Value(metrics[i]), c.Value(metrics[j])
+		if isEmpty(a) || isEmpty(b) {
+			return !isEmpty(a) && isEmpty(b)
+		}
+		if descending {
+			return lessValue(b, a)
+		}
+		return lessValue(a, b)
+	})
+}
+
+// formatCell formats a column value as a table cell in a given output
+// format.
+func (ui *UI) formatCell(c Column, m PullRequestMetrics, format string) interface{} {
+	if c.Key == "number" {
+		return ui.formatNumber(m.PullRequest, format)
+	}
+
+	value := c.Value(m)
+	switch v := value.(type) {
+	case NullDuration:
+		return ui.formatNullDurationIn(v, format)
+	case NullFloat:
+		return formatNullFloat(v)
+	case []string:
+		if len(v) == 0 {
+			return DefaultEmptyCell
+		}
+		return strings.Join(v, ", ")
+	case string:
+		if v == "" {
+			return DefaultEmptyCell
+		}
+	}
+
+	return value
+}
+
+// metricsTable returns a table containing a row of metrics per PR,
+// formatted for a given output format. When issue columns are shown, PRs
+// without linked issues are counted in the caption.
+func (ui *UI) metricsTable(metrics []PullRequestMetrics, format string) table.Writer {
+	t := table.NewWriter()
+	t.SetStyle(table.StyleLight)
+
+	columns := ui.columns()
+
+	header := make(table.Row, 0, len(columns))
+	for _, c := range columns {
+		header = append(header, c.Header)
+	}
+	t.AppendHeader(header)
+
+	for _, m := range metrics {
+		row := make(table.Row, 0, len(columns))
+		for _, c := range columns {
+			row = append(row, highlightOutlier(c, m, ui.formatCell(c, m, format), format))
+		}
+		t.AppendRow(row)
+	}
+
+	if usesAnyColumn(ui.Columns, ui.Sort, IssueColumns) {
+		t.SetCaption(unlinkedCaption(metrics))
+	}
+
+	return t
+}
+
+// renderJSON returns the selected columns of every PR as a JSON array of
+// objects keyed by column, in the order of the columns. Durations are in
+// seconds, and values that can't be determined are null. With outliers
+// marked, each object ends with an "outlier" flag, and with issue columns
+// shown, with an "unlinked" flag for PRs that don't close any issue.
+func (ui *UI) renderJSON(metrics []PullRequestMetrics) string {
+	columns := ui.columns()
+	issues := usesAnyColumn(ui.Columns, ui.Sort, IssueColumns)
+
+	var b bytes.Buffer
+	b.WriteString("[")
+	for i, m := range metrics {
+		if i > 0 {
+			b.WriteString(",")
+		}
+
+		b.WriteString("{")
+		for j, c := range columns {
+			value := c.Value(m)
+			switch v := value.(type) {
+			case NullDuration:
+				value = apiSeconds(v)
+			case NullFloat:
+				if v.Valid {
+					value = v.Float64
+				} else {
+					value = nil
+				}
+			}
+
+			key, _ := json.Marshal(c.Key)
+			encoded, err := json.Marshal(value)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			if j > 0 {
+				b.WriteString(",")
+			}
+			b.Write(key)
+			b.WriteString(":")
+			b.Write(encoded)
+		}
+		if ui.Outliers != nil {
+			fmt.Fprintf(&b, `,"outlier":%t`, m.IsOutlier())
+		}
+		if issues {
+			fmt.Fprintf(&b, `,"unlinked":%t`, len(m.PullRequest.ClosingIssuesReferences.Nodes) == 0)
+		}
+		b.WriteString("}")
+	}
+	b.WriteString("]")
+
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, b.Bytes(), "", "  "); err != nil {
+		log.Fatal(err)
+	}
+
+	return indented.String()
+}
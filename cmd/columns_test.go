@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nbio/st"
+	"github.com/rickar/cal/v2"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func Test_column(t *testing.T) {
+	c, err := column("feature-lead-time")
+	st.Assert(t, err, nil)
+	st.Assert(t, c.Header, "Feature Lead Time")
+
+	_, err = column("color")
+	st.Assert(t, strings.Contains(err.Error(), `invalid column "color"`), true)
+
+	st.Assert(t, validateColumns(DefaultColumns), nil)
+	st.Assert(t, validateColumns([]string{"number", "color"}) != nil, true)
+}
+
+func Test_usesAnyColumn(t *testing.T) {
+	st.Assert(t, usesAnyColumn(nil, "", CodeOwnerColumns), false)
+	st.Assert(t, usesAnyColumn([]string{"number", "owner-approved"}, "", CodeOwnerColumns), true)
+	st.Assert(t, usesAnyColumn(nil, "-time-to-first-owner-review", CodeOwnerColumns), true)
+}
+
+func Test_parseSort(t *testing.T) {
+	key, descending, err := parseSort("-feature-lead-time")
+	st.Assert(t, err, nil)
+	st.Assert(t, key, "feature-lead-time")
+	st.Assert(t, descending, true)
+
+	key, descending, err = parseSort("title")
+	st.Assert(t, err, nil)
+	st.Assert(t, key, "title")
+	st.Assert(t, descending, false)
+
+	_, _, err = parseSort("-color")
+	st.Assert(t, err != nil, true)
+}
+
+func Test_sortMetrics(t *testing.T) {
+	hours := func(h int) NullDuration {
+		return NullDuration{Duration: time.Duration(h) * time.Hour, Valid: true}
+	}
+	metrics := []PullRequestMetrics{
+		{PullRequest: PullRequest{Number: 1, Title: "b"}, FeatureLeadTime: hours(3)},
+		{PullRequest: PullRequest{Number: 2}},
+		{PullRequest: PullRequest{Number: 3, Title: "A"}, FeatureLeadTime: hours(1)},
+		{PullRequest: PullRequest{Number: 4, Title: "c"}, FeatureLeadTime: hours(3)},
+	}
+	numbers := func() []int {
+		var numbers []int
+		for _, m := range metrics {
+			numbers = append(numbers, m.PullRequest.Number)
+		}
+		return numbers
+	}
+
+	(&UI{}).sortMetrics(metrics)
+	st.Assert(t, numbers(), []int{1, 2, 3, 4})
+
+	(&UI{Sort: "feature-lead-time"}).sortMetrics(metrics)
+	st.Assert(t, numbers(), []int{3, 1, 4, 2})
+
+	(&UI{Sort: "-feature-lead-time"}).sortMetrics(metrics)
+	st.Assert(t, numbers(), []int{1, 4, 3, 2})
+
+	(&UI{Sort: "-title"}).sortMetrics(metrics)
+	st.Assert(t, numbers(), []int{4, 1, 3, 2})
+
+	(&UI{Sort: "-number"}).sortMetrics(metrics)
+	st.Assert(t, numbers(), []int{4, 3, 2, 1})
+}
+
+func Test_SearchQuery_WithColumns(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.github.com/graphql").
+		Post("/").
+		MatchType("json").
+		AddMatcher(gqlSearchQueryMatcher(Owner, Repository, StartDate, EndDate)).
+		Reply(200).
+		BodyString(ResponseJSON)
+
+	ui := &UI{
+		Owner:      Owner,
+		Repository: Repository,
+		StartDate:  StartDate,
+		EndDate:    EndDate,
+		CSVFormat:  true,
+		Columns:    []string{"number", "title", "author", "merged-at", "feature-lead-time"},
+		Sort:       "-additions",
+		Calendar:   cal.NewBusinessCalendar(),
+	}
+
+	have := ui.PrintMetrics()
+	expected := `PR,Title,Author,Merged At,Feature Lead Time
+5340,Add a grappling hook,Batman,2022-03-22T16:22:05Z,01:12
+5339,Fix the Batmobile,Batman,2022-03-21T16:22:05Z,01:12`
+
+	st.Assert(t, have, expected)
+}
+
+func Test_SearchQuery_WithJSON(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.github.com/graphql").
+		Post("/").
+		MatchType("json").
+		AddMatcher(gqlSearchQueryMatcher(Owner, Repository, StartDate, EndDate)).
+		Reply(200).
+		BodyString(ResponseJSON)
+
+	ui := &UI{
+		Owner:      Owner,
+		Repository: Repository,
+		StartDate:  StartDate,
+		EndDate:    EndDate,
+		Format:     FormatJSON,
+		Calendar:   cal.NewBusinessCalendar(),
+	}
+
+	have := ui.PrintMetrics()
+
+	var rows []map[string]interface{}
+	st.Assert(t, json.Unmarshal([]byte(have), &rows), nil)
+	st.Assert(t, len(rows), 2)
+	st.Assert(t, len(rows[0]), len(DefaultColumns))
+	st.Assert(t, rows[0]["number"], 5339.0)
+	st.Assert(t, rows[0]["feature-lead-time"], 4333.0)
+	st.Assert(t, rows[0]["labels"], []interface{}{"bug"})
+	st.Assert(t, strings.Index(have, `"number"`) < strings.Index(have, `"commits"`), true)
+	st.Assert(t, strings.Index(have, `"first-approval-to-merge"`) < strings.Index(have, `"labels"`), true)
+}
+
+func Test_RootCmd_InvalidColumns(t *testing.T) {
+	actual := execute(t, "--repo=cli/cli --columns=number,color")
+	expected := `invalid column "color"`
+
+	st.Assert(t, strings.Contains(actual, expected), true)
+}
+
+func Test_RootCmd_SortWithGroupBy(t *testing.T) {
+	actual := execute(t, "--repo=cli/cli --group-by=label --sort=-additions")
+	expected := "--columns and --sort are not supported with --group-by, --cross-team-reviews, --flag-rubber-stamps, --owner-latency or --review-requests"
+
+	st.Assert(t, strings.Contains(actual, expected), true)
+}
+
+func Test_RootCmd_OpenMetricsWithGroupBy(t *testing.T) {
+	actual := execute(t, "--repo=cli/cli --group-by=label --format=openmetrics")
+	expected := "--format openmetrics is not supported with --group-by"
+
+	st.Assert(t, strings.Contains(actual, expected), true)
+}
@@ -0,0 +1,368 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+)
+
+const (
+	ViolationNoApproval        = "no-approval"
+	ViolationDismissedApproval = "dismissed-approval"
+	ViolationStaleApproval     = "stale-approval"
+	ViolationSelfMerged        = "self-merged"
+	// More reviews or dismissals than fetched, so whether the review
+	// policy was followed can't be determined.
+	ViolationUndetermined = "undetermined"
+)
+
+// ComplianceFormatOptions lists the output formats of the compliance
+// command.
+var ComplianceFormatOptions = []string{FormatTable, FormatCSV, FormatJSON}
+
+// ComplianceFinding is a merged PR that breaks the review policy.
+type ComplianceFinding struct {
+	Repository string   `json:"repository"`
+	Number     int      `json:"number"`
+	Title      string   `json:"title"`
+	URL        string   `json:"url"`
+	Author     string   `json:"author"`
+	MergedBy   string   `json:"mergedBy"`
+	MergedAt   string   `json:"mergedAt"`
+	Violations []string `json:"violations"`
+}
+
+// ComplianceCount holds the number of merged PRs of a repository, and the
+// number of them breaking each part of the review policy.
+type ComplianceCount struct {
+	Repository        string `json:"repository"`
+	PullRequests      int    `json:"pullRequests"`
+	Findings          int    `json:"findings"`
+	NoApproval        int    `json:"noApproval"`
+	DismissedApproval int    `json:"dismissedApproval"`
+	StaleApproval     int    `json:"staleApproval"`
+	SelfMerged        int    `json:"selfMerged"`
+	Undetermined      int    `json:"undetermined"`
+}
+
+// lastApproval returns the time of the last approval of a PR by anyone
+// other than the author, or the zero time if there is none.
+func lastApproval(pr PullRequest) time.Time {
+	var last time.Time
+	for _, review := range pr.Reviews.Nodes {
+		if review.Author.Login == pr.Author.Login || review.State != ReviewApprovedState {
+			continue
+		}
+		approvedAt, err := time.Parse(time.RFC3339, review.CreatedAt)
+		if err == nil && approvedAt.After(last) {
+			last = approvedAt
+		}
+	}
+
+	return last
+}
+
+// dismissedApproval returns true if an approval of a PR by anyone other
+// than the author was dismissed before it was merged.
+func dismissedApproval(pr PullRequest) bool {
+	mergedAt, mergedErr := time.Parse(time.RFC3339, pr.MergedAt)
+	for _, node := range pr.Dismissals.Nodes {
+		event := node.ReviewDismissedEvent
+		if event.PreviousReviewState != ReviewApprovedState || event.Review.Author.Login == pr.Author.Login {
+			continue
+		}
+		dismissedAt, err := time.Parse(time.RFC3339, event.CreatedAt)
+		if mergedErr != nil || err != nil || dismissedAt.Before(mergedAt) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// violations returns the parts of the review policy a merged PR breaks:
+// merged after an approval from anyone other than the author was
+// dismissed, merged without such an approval (because there was none, or
+// because it was dismissed), merged with an approval given before its last
+// commit, or merged by its author. Whether approvals are missing or stale
+// is undetermined for PRs with more reviews or dismissals than fetched.
+func violations(pr PullRequest) []string {
+	var found []string
+
+	dismissed := dismissedApproval(pr)
+	if dismissed {
+		found = append(found, ViolationDismissedApproval)
+	}
+
+	approvedAt := lastApproval(pr)
+	switch {
+	case pr.Reviews.TotalCount > len(pr.Reviews.Nodes):
+		// The last approval may be among the reviews not fetched.
+		found = append(found, ViolationUndetermined)
+	case !dismissed && pr.Dismissals.TotalCount > len(pr.Dismissals.Nodes):
+		// An approval may have been dismissed among the dismissals not
+		// fetched.
+		found = append(found, ViolationUndetermined)
+	case approvedAt.IsZero():
+		if !dismissed {
+			found = append(found, ViolationNoApproval)
+		}
+	case len(pr.LastCommit.Nodes) > 0:
+		committedAt, err := time.Parse(time.RFC3339, pr.LastCommit.Nodes[0].Commit.CommittedDate)
+		if err == nil && approvedAt.Before(committedAt) {
+			found = append(found, ViolationStaleApproval)
+		}
+	}
+
+	if pr.MergedBy.Login != "" && pr.MergedBy.Login == pr.Author.Login {
+		found = append(found, ViolationSelfMerged)
+	}
+
+	return found
+}
+
+// compliance returns the PRs of a repository breaking the review policy,
+// along with the number of them per violation.
+func compliance(repository string, pullRequests []PullRequest) ([]ComplianceFinding, ComplianceCount) {
+	var findings []ComplianceFinding
+	count := ComplianceCount{Repository: repository, PullRequests: len(pullRequests)}
+
+	for _, pr := range pullRequests {
+		found := violations(pr)
+		if len(found) == 0 {
+			continue
+		}
+
+		count.Findings++
+		for _, violation := range found {
+			switch violation {
+			case ViolationNoApproval:
+				count.NoApproval++
+			case ViolationDismissedApproval:
+				count.DismissedApproval++
+			case ViolationStaleApproval:
+				count.StaleApproval++
+			case ViolationSelfMerged:
+				count.SelfMerged++
+			case ViolationUndetermined:
+				count.Undetermined++
+			}
+		}
+
+		findings = append(findings, ComplianceFinding{
+			Repository: repository,
+			Number:     pr.Number,
+			Title:      pr.Title,
+			URL:        pr.URL,
+			Author:     pr.Author.Login,
+			MergedBy:   pr.MergedBy.Login,
+			MergedAt:   pr.MergedAt,
+			Violations: found,
+		})
+	}
+
+	return findings, count
+}
+
+// complianceFindingsTable returns a table containing a row per finding.
+func complianceFindingsTable(findings []ComplianceFinding) table.Writer {
+	t := table.NewWriter()
+	t.SetStyle(table.StyleLight)
+
+	t.AppendHeader(table.Row{
+		"Repository",
+		"PR",
+		"Title",
+		"Author",
+		"Merged By",
+		"Merged At",
+		"Violations",
+	})
+
+	for _, f := range findings {
+		mergedBy := f.MergedBy
+		if mergedBy == "" {
+			mergedBy = DefaultEmptyCell
+		}
+
+		t.AppendRow(table.Row{
+			f.Repository,
+			f.Number,
+			f.Title,
+			f.Author,
+			mergedBy,
+			f.MergedAt,
+			strings.Join(f.Violations, ", "),
+		})
+	}
+
+	return t
+}
+
+// complianceCountsTable returns a table containing a row per repository.
+func complianceCountsTable(counts []ComplianceCount) table.Writer {
+	t := table.NewWriter()
+	t.SetStyle(table.StyleLight)
+
+	t.AppendHeader(table.Row{
+		"Repository",
+		"PRs",
+		"Findings",
+		"No Approval",
+		"Dismissed Approval",
+		"Stale Approval",
+		"Self-Merged",
+		"Undetermined",
+	})
+
+	for _, c := range counts {
+		t.AppendRow(table.Row{
+			c.Repository,
+			c.PullRequests,
+			c.Findings,
+			c.NoApproval,
+			c.DismissedApproval,
+			c.StaleApproval,
+			c.SelfMerged,
+			c.Undetermined,
+		})
+	}
+
+	return t
+}
+
+// renderCompliance returns either the findings or the counts per
+// repository in a given format. Tables of findings are followed by the
+// counts.
+func renderCompliance(findings []ComplianceFinding, counts []ComplianceCount, format string, countsOnly bool) (string, error) {
+	switch format {
+	case FormatJSON:
+		var value interface{} = findings
+		if countsOnly {
+			value = counts
+		} else if findings == nil {
+			value = []ComplianceFinding{}
+		}
+		data, err := json.MarshalIndent(value, "", "  ")
+		return string(data), err
+	case FormatCSV:
+		if countsOnly {
+			return complianceCountsTable(counts).RenderCSV(), nil
+		}
+		return complianceFindingsTable(findings).RenderCSV(), nil
+	default:
+		if countsOnly {
+			return complianceCountsTable(counts).Render(), nil
+		}
+		return complianceFindingsTable(findings).Render() + "\n\n" + complianceCountsTable(counts).Render(), nil
+	}
+}
+
+// validateComplianceFormat returns an error if the given output format is
+// not supported by the compliance command.
+func validateComplianceFormat(format string) error {
+	for _, option := range ComplianceFormatOptions {
+		if format == option {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("invalid format %q, must be one of %v", format, ComplianceFormatOptions)
+}
+
+var ComplianceCmd = &cobra.Command{
+	Use:   "compliance [[HOST/]OWNER/REPO...]",
+	Short: "List merged pull requests that break the review policy",
+	Long: `List the pull requests merged in a date range that were merged without an
+approval from anyone other than the author (` + ViolationNoApproval + `), after such an
+approval was dismissed (` + ViolationDismissedApproval + `), with a last approval that
+predates the last commit (` + ViolationStaleApproval + `), or by their author
+(` + ViolationSelfMerged + `), followed by the number of them per repository. Pull requests
+with more reviews or dismissals than fetched are reported as ` + ViolationUndetermined + `.
+
+Repositories are given as arguments, and default to --repo. Use --counts to
+only report the number of pull requests per repository.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		repository, _ := cmd.Flags().GetString("repo")
+		startDate, _ := cmd.Flags().GetString("start")
+		endDate, _ := cmd.Flags().GetString("end")
+		windowDays, _ := cmd.Flags().GetInt("window-days")
+		concurrency, _ := cmd.Flags().GetInt("concurrency")
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+		query, _ := cmd.Flags().GetString("query")
+		format, _ := cmd.Flags().GetString("format")
+		output, _ := cmd.Flags().GetString("output")
+		countsOnly, _ := cmd.Flags().GetBool("counts")
+
+		if err := validateComplianceFormat(format); err != nil {
+			return err
+		}
+
+		repositories := args
+		if len(repositories) == 0 {
+			if repository == "" {
+				return errors.New("no repository given")
+			}
+			repositories = []string{repository}
+		}
+
+		var findings []ComplianceFinding
+		var counts []ComplianceCount
+		for _, name := range repositories {
+			repo, err := newGHRepo(name)
+			if err != nil {
+				return err
+			}
+
+			ui := &UI{
+				Owner:       repo.Owner,
+				Repository:  repo.Name,
+				Host:        repo.Host,
+				StartDate:   startDate,
+				EndDate:     endDate,
+				Query:       query,
+				Fields:      &PullRequestFields{Compliance: true},
+				WindowDays:  windowDays,
+				Concurrency: concurrency,
+				Timeout:     timeout,
+				Progress:    cmd.ErrOrStderr(),
+			}
+
+			pullRequests, err := ui.loadPullRequests(DefaultResultCount)
+			if err != nil {
+				return err
+			}
+
+			repoFindings, count := compliance(repo.Owner+"/"+repo.Name, pullRequests)
+			findings = append(findings, repoFindings...)
+			counts = append(counts, count)
+		}
+
+		content, err := renderCompliance(findings, counts, format, countsOnly)
+		if err != nil {
+			return err
+		}
+
+		if output != "" {
+			return os.WriteFile(output, []byte(content+"\n"), 0o644)
+		}
+		cmd.Println(content)
+
+		return nil
+	},
+}
+
+func init() {
+	ComplianceCmd.Flags().StringP("query", "q", "", "additional query filter for merged pull requests")
+	ComplianceCmd.Flags().StringP("format", "f", FormatTable, fmt.Sprintf("output format, one of %v", ComplianceFormatOptions))
+	ComplianceCmd.Flags().StringP("output", "o", "", "write output to a file instead of standard output")
+	ComplianceCmd.Flags().Bool("counts", false, "only report the number of pull requests breaking the review policy per repository")
+
+	RootCmd.AddCommand(ComplianceCmd)
+}
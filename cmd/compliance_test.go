@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/nbio/st"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func Test_violations(t *testing.T) {
+	approved := Reviews{Nodes: ReviewNodes{
+		{Author: Author{Login: "Batman"}, CreatedAt: "2022-03-21T12:00:00Z", State: "APPROVED"},
+		{Author: Author{Login: "Joker"}, CreatedAt: "2022-03-21T10:00:00Z", State: "APPROVED"},
+	}}
+	lastCommit := func(date string) LastCommit {
+		return LastCommit{Nodes: CommitNodes{{Commit{CommittedDate: date}}}}
+	}
+	dismissal := func(state, login, date string) Dismissals {
+		var event ReviewDismissedEvent
+		event.PreviousReviewState = state
+		event.Review.Author.Login = login
+		event.CreatedAt = date
+		return Dismissals{Nodes: DismissalNodes{{ReviewDismissedEvent: event}}}
+	}
+
+	batman := Author{Login: "Batman"}
+	mergedAt := "2022-03-21T15:00:00Z"
+
+	st.Assert(t, violations(PullRequest{Author: batman, MergedAt: mergedAt, Reviews: approved, LastCommit: lastCommit("2022-03-21T09:00:00Z")}), []string(nil))
+	st.Assert(t, violations(PullRequest{Author: batman, MergedAt: mergedAt, Reviews: approved, LastCommit: lastCommit("2022-03-21T11:00:00Z")}), []string{ViolationStaleApproval})
+	st.Assert(t, violations(PullRequest{Author: batman, MergedAt: mergedAt, MergedBy: batman}), []string{ViolationNoApproval, ViolationSelfMerged})
+	st.Assert(t, violations(PullRequest{Author: batman, MergedAt: mergedAt, Dismissals: dismissal("APPROVED", "Joker", "2022-03-21T11:00:00Z")}), []string{ViolationDismissedApproval})
+	st.Assert(t, violations(PullRequest{Author: batman, MergedAt: mergedAt, Dismissals: dismissal("CHANGES_REQUESTED", "Joker", "2022-03-21T11:00:00Z")}), []string{ViolationNoApproval})
+	st.Assert(t, violations(PullRequest{Author: batman, MergedAt: mergedAt, Dismissals: dismissal("APPROVED", "Joker", "2022-03-21T16:00:00Z")}), []string{ViolationNoApproval})
+	st.Assert(t, violations(PullRequest{Author: batman, MergedAt: mergedAt, Reviews: approved, LastCommit: lastCommit("2022-03-21T09:00:00Z"), Dismissals: dismissal("APPROVED", "Riddler", "2022-03-21T11:00:00Z")}), []string{ViolationDismissedApproval})
+
+	truncatedReviews := approved
+	truncatedReviews.TotalCount = 101
+	st.Assert(t, violations(PullRequest{Author: batman, MergedAt: mergedAt, Reviews: truncatedReviews, LastCommit: lastCommit("2022-03-21T09:00:00Z")}), []string{ViolationUndetermined})
+
+	truncatedDismissals := dismissal("CHANGES_REQUESTED", "Joker", "2022-03-21T11:00:00Z")
+	truncatedDismissals.TotalCount = 11
+	st.Assert(t, violations(PullRequest{Author: batman, MergedAt: mergedAt, Reviews: approved, LastCommit: lastCommit("2022-03-21T09:00:00Z"), Dismissals: truncatedDismissals}), []string{ViolationUndetermined})
+
+	truncatedDismissals = dismissal("APPROVED", "Joker", "2022-03-21T11:00:00Z")
+	truncatedDismissals.TotalCount = 11
+	st.Assert(t, violations(PullRequest{Author: batman, MergedAt: mergedAt, Reviews: approved, LastCommit: lastCommit("2022-03-21T09:00:00Z"), Dismissals: truncatedDismissals}), []string{ViolationDismissedApproval})
+}
+
+func Test_compliance(t *testing.T) {
+	pullRequests := []PullRequest{
+		{Number: 1, Author: Author{Login: "Batman"}, MergedBy: Author{Login: "Batman"}},
+		{Number: 2, Author: Author{Login: "Batman"}, Reviews: Reviews{Nodes: ReviewNodes{
+			{Author: Author{Login: "Joker"}, CreatedAt: "2022-03-21T10:00:00Z", State: "APPROVED"},
+		}}},
+		{Number: 3, Author: Author{Login: "Batman"}, Reviews: Reviews{TotalCount: 101}},
+	}
+
+	findings, count := compliance("o/r", pullRequests)
+
+	st.Assert(t, len(findings), 2)
+	st.Assert(t, findings[0].Number, 1)
+	st.Assert(t, findings[1].Violations, []string{ViolationUndetermined})
+	st.Assert(t, count, ComplianceCount{Repository: "o/r", PullRequests: 3, Findings: 2, NoApproval: 1, SelfMerged: 1, Undetermined: 1})
+}
+
+func Test_ComplianceCmd(t *testing.T) {
+	defer gock.Off()
+
+	selfMerged := strings.Replace(ResponseJSON,
+		`"mergedAt": "2022-03-21T16:22:05Z",`,
+		`"mergedAt": "2022-03-21T16:22:05Z", "mergedBy": {"login": "Batman"},`, 1)
+
+	gock.New("https://api.github.com/graphql").
+		Post("/").
+		MatchType("json").
+		AddMatcher(gqlSearchQueryMatcher(Owner, "selfMergedRepo", StartDate, EndDate)).
+		Reply(200).
+		BodyString(selfMerged)
+
+	gock.New("https://api.github.com/graphql").
+		Post("/").
+		MatchType("json").
+		AddMatcher(gqlSearchQueryMatcher(Owner, "otherRepo", StartDate, EndDate)).
+		Reply(200).
+		BodyString(ResponseJSON)
+
+	actual := execute(t, fmt.Sprintf("compliance --start=%s --end=%s --format=csv %s/selfMergedRepo %s/otherRepo", StartDate, EndDate, Owner, Owner))
+
+	st.Assert(t, actual, "Repository,PR,Title,Author,Merged By,Merged At,Violations\n"+
+		"testOwner/selfMergedRepo,5339,Fix the Batmobile,Batman,Batman,2022-03-21T16:22:05Z,self-merged\n")
+}
+
+func Test_ComplianceCmd_CountsJSON(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.github.com/graphql").
+		Post("/").
+		MatchType("json").
+		AddMatcher(gqlSearchQueryMatcher(Owner, Repository, StartDate, EndDate)).
+		Reply(200).
+		BodyString(ResponseJSON)
+
+	actual := execute(t, fmt.Sprintf("compliance --repo=%s/%s --start=%s --end=%s --format=json --counts", Owner, Repository, StartDate, EndDate))
+
+	var counts []ComplianceCount
+	st.Assert(t, json.Unmarshal([]byte(actual), &counts), nil)
+	st.Assert(t, counts, []ComplianceCount{{Repository: "testOwner/testRepo", PullRequests: 2}})
+}
+
+func Test_ComplianceCmd_InvalidFormat(t *testing.T) {
+	actual := execute(t, "compliance --repo=cli/cli --format=markdown")
+
+	st.Assert(t, strings.Contains(actual, `invalid format "markdown", must be one of [table csv json]`), true)
+}
@@ -0,0 +1,366 @@
+package cmd
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultDashboardHost is the host the dashboard is served on when --addr
+// doesn't include one.
+const DefaultDashboardHost = "127.0.0.1"
+
+var (
+	//go:embed assets/dashboard.html.tmpl
+	dashboardTemplate string
+	//go:embed assets/dashboard.js
+	dashboardJS string
+)
+
+// Dashboard serves an interactive view of pull request metrics, backed by
+// a JSON API. Pull requests are fetched from the API, read from the local
+// store when Offline, or replayed from Snapshot.
+type Dashboard struct {
+	Repository  string
+	Offline     bool
+	Snapshot    *Snapshot
+	WindowDays  int
+	Concurrency int
+	Timeout     time.Duration
+	Progress    io.Writer
+}
+
+// APIPullRequest is a pull request returned by the dashboard API. Durations
+// are in seconds, keyed by metric, and null if they can't be determined.
+type APIPullRequest struct {
+	Number       int                 `json:"number"`
+	Title        string              `json:"title"`
+	URL          string              `json:"url"`
+	Author       string              `json:"author"`
+	CreatedAt    string              `json:"createdAt"`
+	MergedAt     string              `json:"mergedAt"`
+	Commits      int                 `json:"commits"`
+	Additions    int                 `json:"additions"`
+	Deletions    int                 `json:"deletions"`
+	ChangedFiles int                 `json:"changedFiles"`
+	Comments     int                 `json:"comments"`
+	Participants int                 `json:"participants"`
+	Labels       []string            `json:"labels"`
+	Durations    map[string]*float64 `json:"durations"`
+}
+
+// APIMetricSummary holds summary statistics of a duration metric returned by
+// the dashboard API, in seconds.
+type APIMetricSummary struct {
+	Name   string   `json:"name"`
+	Key    string   `json:"key"`
+	Count  int      `json:"count"`
+	Median *float64 `json:"median"`
+	Mean   *float64 `json:"mean"`
+	P90    *float64 `json:"p90"`
+}
+
+// APITrendPoint holds the median of every duration metric, in seconds and
+// keyed by metric, across the pull requests merged in a week.
+type APITrendPoint struct {
+	Week         string              `json:"week"`
+	PullRequests int                 `json:"pullRequests"`
+	Medians      map[string]*float64 `json:"medians"`
+}
+
+// apiSeconds returns a duration in seconds, or nil if it is not valid.
+func apiSeconds(d NullDuration) *float64 {
+	if !d.Valid {
+		return nil
+	}
+
+	seconds := d.Duration.Seconds()
+	return &seconds
+}
+
+// apiPullRequests returns the dashboard API representation of a set of PRs.
+func apiPullRequests(metrics []PullRequestMetrics) []APIPullRequest {
+	pullRequests := []APIPullRequest{}
+	for _, m := range metrics {
+		pr := m.PullRequest
+		durations := make(map[string]*float64)
+		for _, metric := range DurationMetrics {
+			durations[metric.Key] = apiSeconds(metric.Value(m))
+		}
+
+		pullRequests = append(pullRequests, APIPullRequest{
+			Number:       pr.Number,
+			Title:        pr.Title,
+			URL:          pr.URL,
+			Author:       pr.Author.Login,
+			CreatedAt:    pr.CreatedAt,
+			MergedAt:     pr.MergedAt,
+			Commits:      pr.Commits.TotalCount,
+			Additions:    pr.Additions,
+			Deletions:    pr.Deletions,
+			ChangedFiles: pr.ChangedFiles,
+			Comments:     pr.Comments.TotalCount,
+			Participants: pr.Participants.TotalCount,
+			Labels:       labelNames(pr.Labels),
+			Durations:    durations,
+		})
+	}
+
+	return pullRequests
+}
+
+// apiSummary returns the dashboard API representation of the summary
+// statistics of a set of PRs.
+func apiSummary(metrics []PullRequestMetrics) map[string]interface{} {
+	return map[string]interface{}{
+		"pullRequests": len(metrics),
+		"metrics":      apiMetricSummaries(summarize(metrics, DurationMetrics)),
+	}
+}
+
+// apiMetricSummaries returns the API representation of summary statistics.
+func apiMetricSummaries(metricSummaries []MetricSummary) []APIMetricSummary {
+	summaries := []APIMetricSummary{}
+	for _, summary := range metricSummaries {
+		summaries = append(summaries, APIMetricSummary{
+			Name:   summary.Name,
+			Key:    summary.Key,
+			Count:  summary.Count,
+			Median: apiSeconds(summary.Median),
+			Mean:   apiSeconds(summary.Mean),
+			P90:    apiSeconds(summary.P90),
+		})
+	}
+
+	return summaries
+}
+
+// apiTrend returns the dashboard API representation of the weekly trend of
+// a set of PRs.
+func apiTrend(metrics []PullRequestMetrics) []APITrendPoint {
+	trend := []APITrendPoint{}
+	for _, point := range weeklyTrend(metrics, DurationMetrics) {
+		medians := make(map[string]*float64)
+		for i, metric := range DurationMetrics {
+			medians[metric.Key] = apiSeconds(point.Medians[i])
+		}
+
+		trend = append(trend, APITrendPoint{
+			Week:         point.Week,
+			PullRequests: point.PullRequests,
+			Medians:      medians,
+		})
+	}
+
+	return trend
+}
+
+// defaults returns the default repository, date range and query of the
+// dashboard. Snapshots can only be replayed with the parameters they were
+// taken with.
+func (d *Dashboard) defaults() SnapshotParameters {
+	if d.Snapshot != nil {
+		return d.Snapshot.Parameters
+	}
+
+	return SnapshotParameters{
+		StartDate: defaultStart,
+		EndDate:   defaultEnd,
+	}
+}
+
+// repository returns the default repository of the dashboard.
+func (d *Dashboard) repository() string {
+	if d.Snapshot != nil {
+		parameters := d.Snapshot.Parameters
+		return fmt.Sprintf("%s/%s/%s", parameters.Host, parameters.Owner, parameters.Repository)
+	}
+
+	return d.Repository
+}
+
+// ui returns the UI for the report described by the parameters of a
+// request: start, end, query and only_weekdays. Reports are always of the
+// repository the dashboard was started for, as they are fetched with the
+// token of the user running it, so a different repo is rejected.
+func (d *Dashboard) ui(r *http.Request) (*UI, error) {
+	params := r.URL.Query()
+	defaults := d.defaults()
+
+	repo, err := newGHRepo(d.repository())
+	if err != nil {
+		return nil, err
+	}
+	if requested := params.Get("repo"); d.Snapshot == nil && requested != "" {
+		other, err := newGHRepo(requested)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.EqualFold(other.Host, repo.Host) || !strings.EqualFold(other.Owner, repo.Owner) || !strings.EqualFold(other.Name, repo.Name) {
+			return nil, fmt.Errorf("repository %q is not served by this dashboard", requested)
+		}
+	}
+
+	startDate := params.Get("start")
+	endDate := params.Get("end")
+	query := params.Get("query")
+	if d.Snapshot != nil || startDate == "" {
+		startDate = defaults.StartDate
+	}
+	if d.Snapshot != nil || endDate == "" {
+		endDate = defaults.EndDate
+	}
+	windowDays := d.WindowDays
+	if d.Snapshot != nil {
+		query = defaults.Query
+		if defaults.WindowDays > 0 {
+			windowDays = defaults.WindowDays
+		}
+	}
+
+	onlyWeekdays := false
+	if value := params.Get("only_weekdays"); value != "" {
+		var err error
+		onlyWeekdays, err = strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid only_weekdays %q", value)
+		}
+	}
+
+	for _, date := range []string{startDate, endDate} {
+		if _, err := time.Parse(DefaultDateFormat, date); err != nil {
+			return nil, fmt.Errorf("invalid date %q, must be in YYYY-MM-DD format", date)
+		}
+	}
+
+	if d.Offline && query != "" {
+		return nil, errors.New("query is not supported offline")
+	}
+
+	// Every duration metric is shown.
+	var fields PullRequestFields
+	for _, metric := range DurationMetrics {
+		fields = fields.merge(metric.Fields)
+	}
+
+	return &UI{
+		Owner:          repo.Owner,
+		Repository:     repo.Name,
+		Host:           repo.Host,
+		StartDate:      startDate,
+		EndDate:        endDate,
+		Query:          query,
+		OnlyWeekdays:   onlyWeekdays,
+		Fields:         &fields,
+		Offline:        d.Offline,
+		Snapshot:       d.Snapshot,
+		ReplaySnapshot: d.Snapshot != nil,
+		WindowDays:     windowDays,
+		Concurrency:    d.Concurrency,
+		Timeout:        d.Timeout,
+		Progress:       d.Progress,
+		Calendar:       newCalendar(onlyWeekdays),
+	}, nil
+}
+
+// writeJSON writes a value as a JSON response.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// api returns a handler responding with the JSON representation of the
+// metrics of the PRs described by the parameters of a request.
+func (d *Dashboard) api(represent func([]PullRequestMetrics) interface{}) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ui, err := d.ui(r)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+
+		pullRequests, err := ui.loadPullRequests(DefaultResultCount)
+		if err != nil {
+			writeJSON(w, http.StatusBadGateway, map[string]string{"error": err.Error()})
+			return
+		}
+
+		metrics, err := ui.metricsOf(pullRequests)
+		if err != nil {
+			writeJSON(w, http.StatusBadGateway, map[string]string{"error": err.Error()})
+			return
+		}
+
+		writeJSON(w, http.StatusOK, represent(metrics))
+	}
+}
+
+// index serves the dashboard page.
+func (d *Dashboard) index(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+
+	tmpl := template.Must(template.New("dashboard").Parse(dashboardTemplate))
+	defaults := d.defaults()
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	tmpl.Execute(w, map[string]interface{}{
+		"Repository": d.repository(),
+		"StartDate":  defaults.StartDate,
+		"EndDate":    defaults.EndDate,
+		"Query":      defaults.Query,
+		"Snapshot":   d.Snapshot != nil,
+		"Offline":    d.Offline,
+		"Version":    Version,
+		"Metrics":    DurationMetrics,
+		"CSS":        template.CSS(reportCSS),
+		"ChartsJS":   template.JS(chartsJS),
+		"JS":         template.JS(dashboardJS),
+	})
+}
+
+// dashboardAddr returns the address to serve the dashboard on. Without a
+// host, it is only served on the loopback interface, and other hosts are
+// rejected unless allowRemote is set, as anyone reaching the dashboard can
+// query the API with the token of the user running it.
+func dashboardAddr(addr string, allowRemote bool) (string, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", fmt.Errorf("invalid --addr: %w", err)
+	}
+	if host == "" {
+		return net.JoinHostPort(DefaultDashboardHost, port), nil
+	}
+
+	ip := net.ParseIP(host)
+	if allowRemote || strings.EqualFold(host, "localhost") || (ip != nil && ip.IsLoopback()) {
+		return addr, nil
+	}
+
+	return "", fmt.Errorf("--addr %s is not a loopback address, pass --allow-remote to serve the dashboard on it", addr)
+}
+
+// register registers the dashboard page and API on a given mux.
+func (d *Dashboard) register(mux *http.ServeMux) {
+	mux.HandleFunc("/", d.index)
+	mux.HandleFunc("/api/prs", d.api(func(metrics []PullRequestMetrics) interface{} {
+		return apiPullRequests(metrics)
+	}))
+	mux.HandleFunc("/api/summary", d.api(func(metrics []PullRequestMetrics) interface{} {
+		return apiSummary(metrics)
+	}))
+	mux.HandleFunc("/api/trend", d.api(func(metrics []PullRequestMetrics) interface{} {
+		return apiTrend(metrics)
+	}))
+}
@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/nbio/st"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func serveDashboard(t *testing.T, dashboard *Dashboard, target string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	dashboard.register(mux)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", target, nil))
+
+	return rec
+}
+
+func Test_Dashboard_Index(t *testing.T) {
+	rec := serveDashboard(t, &Dashboard{Repository: "cli/cli"}, "/")
+
+	st.Assert(t, rec.Code, http.StatusOK)
+	st.Assert(t, strings.Contains(rec.Body.String(), `name="repo" value="cli/cli"`), true)
+	st.Assert(t, strings.Contains(rec.Body.String(), `<th data-metric="feature-lead-time">Feature Lead Time</th>`), true)
+	st.Assert(t, strings.Contains(rec.Body.String(), "<script src"), false)
+
+	rec = serveDashboard(t, &Dashboard{}, "/missing")
+	st.Assert(t, rec.Code, http.StatusNotFound)
+}
+
+func Test_Dashboard_API(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.github.com/graphql").
+		Post("/").
+		MatchType("json").
+		AddMatcher(gqlSearchQueryWithFilterMatcher).
+		Times(3).
+		Reply(200).
+		BodyString(ResponseJSON)
+
+	dashboard := &Dashboard{Repository: fmt.Sprintf("%s/%s", Owner, Repository)}
+	params := fmt.Sprintf("start=%s&end=%s&query=%s&only_weekdays=true", StartDate, EndDate, Query)
+
+	rec := serveDashboard(t, dashboard, "/api/summary?"+params)
+	st.Assert(t, rec.Code, http.StatusOK)
+	st.Assert(t, rec.Header().Get("Content-Type"), "application/json")
+
+	var summary struct {
+		PullRequests int                `json:"pullRequests"`
+		Metrics      []APIMetricSummary `json:"metrics"`
+	}
+	st.Assert(t, json.Unmarshal(rec.Body.Bytes(), &summary), nil)
+	st.Assert(t, summary.PullRequests, 2)
+	st.Assert(t, summary.Metrics[2].Key, "feature-lead-time")
+	st.Assert(t, summary.Metrics[2].Count, 2)
+	st.Assert(t, *summary.Metrics[2].Median, 4333.0)
+
+	rec = serveDashboard(t, dashboard, "/api/prs?"+params)
+	st.Assert(t, rec.Code, http.StatusOK)
+
+	var pullRequests []APIPullRequest
+	st.Assert(t, json.Unmarshal(rec.Body.Bytes(), &pullRequests), nil)
+	st.Assert(t, len(pullRequests), 2)
+	st.Assert(t, pullRequests[0].Number, 5339)
+	st.Assert(t, pullRequests[0].Labels, []string{"bug"})
+	st.Assert(t, *pullRequests[0].Durations["feature-lead-time"], 4333.0)
+
+	rec = serveDashboard(t, dashboard, "/api/trend?"+params)
+	st.Assert(t, rec.Code, http.StatusOK)
+
+	var trend []APITrendPoint
+	st.Assert(t, json.Unmarshal(rec.Body.Bytes(), &trend), nil)
+	st.Assert(t, len(trend), 1)
+	st.Assert(t, trend[0].Week, "2022-03-21")
+	st.Assert(t, trend[0].PullRequests, 2)
+}
+
+func Test_Dashboard_APIInvalidParameters(t *testing.T) {
+	dashboard := &Dashboard{Repository: "cli/cli"}
+
+	for target, expected := range map[string]string{
+		"/api/summary?repo=cli":              "invalid repository name",
+		"/api/prs?repo=cli/go-gh":            `repository \"cli/go-gh\" is not served by this dashboard`,
+		"/api/summary?start=yesterday":       `invalid date \"yesterday\"`,
+		"/api/trend?only_weekdays=sometimes": `invalid only_weekdays \"sometimes\"`,
+		"/api/prs?end=2022-13-01":            `invalid date \"2022-13-01\"`,
+	} {
+		rec := serveDashboard(t, dashboard, target)
+		st.Assert(t, rec.Code, http.StatusBadRequest)
+		st.Assert(t, strings.Contains(rec.Body.String(), expected), true)
+	}
+
+	rec := serveDashboard(t, &Dashboard{Repository: "cli/cli", Offline: true}, "/api/prs?query=author:Batman")
+	st.Assert(t, rec.Code, http.StatusBadRequest)
+	st.Assert(t, strings.Contains(rec.Body.String(), "query is not supported offline"), true)
+}
+
+func Test_Dashboard_Offline(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	dashboard := &Dashboard{Repository: fmt.Sprintf("%s/%s", Owner, Repository), Offline: true}
+
+	rec := serveDashboard(t, dashboard, "/api/prs")
+	st.Assert(t, rec.Code, http.StatusBadGateway)
+	st.Assert(t, strings.Contains(rec.Body.String(), "please run `gh metrics sync`"), true)
+
+	store, _ := loadStore("github.com", Owner, Repository)
+	store.Upsert([]PullRequest{{Number: 5339, MergedAt: "2022-03-21T16:22:05Z"}})
+	st.Assert(t, store.Save(), nil)
+
+	rec = serveDashboard(t, dashboard, fmt.Sprintf("/api/prs?start=%s&end=%s", StartDate, EndDate))
+	st.Assert(t, rec.Code, http.StatusOK)
+	st.Assert(t, strings.Contains(rec.Body.String(), `"number":5339`), true)
+}
+
+func Test_Dashboard_Snapshot(t *testing.T) {
+	gock.New("https://api.github.com/graphql").
+		Post("/").
+		MatchType("json").
+		AddMatcher(gqlSearchQueryMatcher(Owner, Repository, StartDate, EndDate)).
+		Reply(200).
+		BodyString(ResponseJSON)
+
+	snapshot := newSnapshot(SnapshotParameters{Host: "github.com", Owner: Owner, Repository: Repository, StartDate: StartDate, EndDate: EndDate})
+	ui := &UI{Owner: Owner, Repository: Repository, StartDate: StartDate, EndDate: EndDate, Snapshot: snapshot, Calendar: newCalendar(false)}
+	_, err := ui.loadPullRequests(DefaultResultCount)
+	st.Assert(t, err, nil)
+	gock.Off()
+
+	dashboard := &Dashboard{Snapshot: snapshot}
+
+	rec := serveDashboard(t, dashboard, "/")
+	st.Assert(t, strings.Contains(rec.Body.String(), `value="github.com/testOwner/testRepo"`), true)
+	st.Assert(t, strings.Contains(rec.Body.String(), "Replaying a snapshot"), true)
+
+	// Parameters other than the calendar are taken from the snapshot.
+	rec = serveDashboard(t, dashboard, "/api/summary?repo=cli/cli&start=2020-01-01&only_weekdays=true")
+	st.Assert(t, rec.Code, http.StatusOK)
+	st.Assert(t, strings.Contains(rec.Body.String(), `"pullRequests":2`), true)
+}
+
+func Test_Dashboard_SameRepository(t *testing.T) {
+	rec := serveDashboard(t, &Dashboard{Repository: "cli/cli"}, "/api/summary?repo=github.com/CLI/cli&start=yesterday")
+	st.Assert(t, rec.Code, http.StatusBadRequest)
+	st.Assert(t, strings.Contains(rec.Body.String(), `invalid date \"yesterday\"`), true)
+}
+
+func Test_dashboardAddr(t *testing.T) {
+	for addr, expected := range map[string]string{
+		":8080":          "127.0.0.1:8080",
+		"localhost:8080": "localhost:8080",
+		"127.0.0.1:8080": "127.0.0.1:8080",
+		"[::1]:8080":     "[::1]:8080",
+	} {
+		actual, err := dashboardAddr(addr, false)
+		st.Assert(t, err, nil)
+		st.Assert(t, actual, expected)
+	}
+
+	_, err := dashboardAddr("0.0.0.0:8080", false)
+	st.Assert(t, err.Error(), "--addr 0.0.0.0:8080 is not a loopback address, pass --allow-remote to serve the dashboard on it")
+
+	actual, err := dashboardAddr("0.0.0.0:8080", true)
+	st.Assert(t, err, nil)
+	st.Assert(t, actual, "0.0.0.0:8080")
+
+	_, err = dashboardAddr("8080", false)
+	st.Assert(t, strings.Contains(err.Error(), "invalid --addr"), true)
+}
@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// Format durations in hours and minutes, e.g. "185h5m".
+	DurationFormatGo = "go"
+	// Format durations in days, hours and minutes, e.g. "7d 17h".
+	DurationFormatHuman = "human"
+	// Format durations in workdays, e.g. "2.3 bd".
+	DurationFormatBusinessDays = "business-days"
+	// Format durations in decimal hours, e.g. "185.08".
+	DurationFormatHours = "hours"
+	// Format durations in seconds, e.g. "666300".
+	DurationFormatSeconds = "seconds"
+	// Format durations in ISO 8601, e.g. "PT185H5M".
+	DurationFormatISO8601 = "iso8601"
+	// Format durations as zero-padded hours and minutes, e.g. "185:05",
+	// which Excel can parse.
+	DurationFormatClock = "clock"
+	// Length of a workday if the calendar doesn't define one.
+	DefaultWorkdayLength = 24 * time.Hour
+)
+
+// DurationFormatOptions lists the supported values of the
+// `--duration-format` flag.
+var DurationFormatOptions = []string{
+	DurationFormatGo,
+	DurationFormatHuman,
+	DurationFormatBusinessDays,
+	DurationFormatHours,
+	DurationFormatSeconds,
+	DurationFormatISO8601,
+	DurationFormatClock,
+}
+
+// validateDurationFormat returns an error if the given duration format is
+// not supported.
+func validateDurationFormat(format string) error {
+	for _, option := range DurationFormatOptions {
+		if format == option {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("invalid duration format %q, must be one of %v", format, DurationFormatOptions)
+}
+
+// formatDuration formats a duration in the given duration format. Business
+// days are relative to the given workday length. All formats but seconds,
+// hours and business days are rounded to the nearest minute.
+func formatDuration(d time.Duration, format string, workday time.Duration) string {
+	rounded := d.Round(time.Minute)
+
+	switch format {
+	case DurationFormatHuman:
+		return humanDuration(rounded)
+	case DurationFormatBusinessDays:
+		return fmt.Sprintf("%.1f bd", float64(d)/float64(workday))
+	case DurationFormatHours:
+		return fmt.Sprintf("%.2f", d.Hours())
+	case DurationFormatSeconds:
+		return fmt.Sprintf("%d", d.Round(time.Second)/time.Second)
+	case DurationFormatISO8601:
+		return iso8601Duration(rounded)
+	case DurationFormatClock:
+		return excelCompatDuration(rounded)
+	}
+
+	if rounded == 0 {
+		return "0m"
+	}
+
+	return strings.TrimSuffix(rounded.String(), "0s")
+}
+
+// formatNullDuration formats a duration that may be absent, returning
+// DefaultEmptyCell if it is.
+func formatNullDuration(d NullDuration, format string, workday time.Duration) string {
+	if !d.Valid {
+		return DefaultEmptyCell
+	}
+
+	return formatDuration(d.Duration, format, workday)
+}
+
+// humanDuration formats a duration in its two most significant units of
+// days, hours and minutes, e.g. "7d 17h" or "1h 12m".
+func humanDuration(d time.Duration) string {
+	days := d / (24 * time.Hour)
+	d -= days * 24 * time.Hour
+	h := d / time.Hour
+	d -= h * time.Hour
+	m := d / time.Minute
+
+	switch {
+	case days > 0 && h > 0:
+		return fmt.Sprintf("%dd %dh", days, h)
+	case days > 0:
+		return fmt.Sprintf("%dd", days)
+	case h > 0 && m > 0:
+		return fmt.Sprintf("%dh %dm", h, m)
+	case h > 0:
+		return fmt.Sprintf("%dh", h)
+	}
+
+	return fmt.Sprintf("%dm", m)
+}
+
+// iso8601Duration formats a duration in hours and minutes as an ISO 8601
+// duration, e.g. "PT185H5M".
+func iso8601Duration(d time.Duration) string {
+	h := d / time.Hour
+	d -= h * time.Hour
+	m := d / time.Minute
+
+	var b strings.Builder
+	b.WriteString("PT")
+	if h > 0 {
+		fmt.Fprintf(&b, "%dH", h)
+	}
+	if m > 0 || h == 0 {
+		fmt.Fprintf(&b, "%dM", m)
+	}
+
+	return b.String()
+}
+
+// excelCompatDuration formats a duration in hours and minutes, for
+// Excel compatibility, rounded to the nearest minute.
+func excelCompatDuration(d time.Duration) string {
+	h := d / time.Hour
+	d -= h * time.Hour
+	m := d / time.Minute
+
+	return fmt.Sprintf("%02d:%02d", h, m)
+}
+
+// durationFormat returns the configured duration format, defaulting to
+// clock durations for CSV output and Go durations otherwise.
+func (ui *UI) durationFormat() string {
+	return ui.durationFormatIn(ui.format())
+}
+
+// durationFormatIn returns the configured duration format for a given
+// output format, defaulting to clock durations for CSV output and Go
+// durations otherwise.
+func (ui *UI) durationFormatIn(format string) string {
+	if ui.DurationFormat != "" {
+		return ui.DurationFormat
+	}
+	if format == FormatCSV {
+		return DurationFormatClock
+	}
+
+	return DurationFormatGo
+}
+
+// workdayLength returns the length of a workday in the configured
+// calendar, rounded to the nearest minute.
+func (ui *UI) workdayLength() time.Duration {
+	if ui.Calendar == nil {
+		return DefaultWorkdayLength
+	}
+
+	// Any Monday will do, as workdays are the same length.
+	monday := time.Date(2006, time.January, 2, 0, 0, 0, 0, time.UTC)
+	length := ui.Calendar.WorkdayEnd(monday).Sub(ui.Calendar.WorkdayStart(monday)).Round(time.Minute)
+	if length <= 0 {
+		return DefaultWorkdayLength
+	}
+
+	return length
+}
+
+// formatDuration formats a duration in the configured duration format.
+func (ui *UI) formatDuration(d time.Duration) string {
+	return formatDuration(d, ui.durationFormat(), ui.workdayLength())
+}
+
+// formatNullDuration formats a duration that may be absent in the
+// configured duration format.
+func (ui *UI) formatNullDuration(d NullDuration) string {
+	return ui.formatNullDurationIn(d, ui.format())
+}
+
+// formatNullDurationIn formats a duration that may be absent in the
+// duration format of a given output format.
+func (ui *UI) formatNullDurationIn(d NullDuration, format string) string {
+	return formatNullDuration(d, ui.durationFormatIn(format), ui.workdayLength())
+}
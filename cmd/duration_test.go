@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nbio/st"
+	"github.com/rickar/cal/v2"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func Test_validateDurationFormat(t *testing.T) {
+	for _, format := range DurationFormatOptions {
+		st.Assert(t, validateDurationFormat(format), nil)
+	}
+
+	st.Assert(t, validateDurationFormat("fortnights").Error(), `invalid duration format "fortnights", must be one of [go human business-days hours seconds iso8601 clock]`)
+}
+
+func Test_formatDuration_LessThanMinute(t *testing.T) {
+	st.Assert(t, formatDuration(time.Second*5, DurationFormatGo, DefaultWorkdayLength), "0m")
+}
+
+func Test_formatDuration_LessThanMinuteWithClock(t *testing.T) {
+	st.Assert(t, formatDuration(time.Second*5, DurationFormatClock, DefaultWorkdayLength), "00:00")
+}
+
+func Test_formatDuration_MoreThanMinute(t *testing.T) {
+	st.Assert(t, formatDuration(time.Minute*5, DurationFormatGo, DefaultWorkdayLength), "5m")
+}
+
+func Test_formatDuration_MoreThanMinuteWithClock(t *testing.T) {
+	st.Assert(t, formatDuration(time.Minute*5, DurationFormatClock, DefaultWorkdayLength), "00:05")
+}
+
+func Test_formatDuration_Formats(t *testing.T) {
+	d := 185*time.Hour + 5*time.Minute + 10*time.Second
+
+	for format, want := range map[string]string{
+		DurationFormatGo:           "185h5m",
+		DurationFormatHuman:        "7d 17h",
+		DurationFormatBusinessDays: "7.7 bd",
+		DurationFormatHours:        "185.09",
+		DurationFormatSeconds:      "666310",
+		DurationFormatISO8601:      "PT185H5M",
+		DurationFormatClock:        "185:05",
+	} {
+		st.Assert(t, formatDuration(d, format, DefaultWorkdayLength), want)
+	}
+}
+
+func Test_formatDuration_Zero(t *testing.T) {
+	for format, want := range map[string]string{
+		DurationFormatGo:           "0m",
+		DurationFormatHuman:        "0m",
+		DurationFormatBusinessDays: "0.0 bd",
+		DurationFormatHours:        "0.00",
+		DurationFormatSeconds:      "0",
+		DurationFormatISO8601:      "PT0M",
+		DurationFormatClock:        "00:00",
+	} {
+		st.Assert(t, formatDuration(0, format, DefaultWorkdayLength), want)
+	}
+}
+
+func Test_formatDuration_BusinessDays(t *testing.T) {
+	st.Assert(t, formatDuration(18*time.Hour+24*time.Minute, DurationFormatBusinessDays, 8*time.Hour), "2.3 bd")
+}
+
+func Test_humanDuration(t *testing.T) {
+	st.Assert(t, humanDuration(48*time.Hour), "2d")
+	st.Assert(t, humanDuration(72*time.Minute), "1h 12m")
+	st.Assert(t, humanDuration(3*time.Hour), "3h")
+	st.Assert(t, humanDuration(2*time.Minute), "2m")
+}
+
+func Test_iso8601Duration(t *testing.T) {
+	st.Assert(t, iso8601Duration(3*time.Hour), "PT3H")
+	st.Assert(t, iso8601Duration(7*time.Minute), "PT7M")
+}
+
+func Test_formatNullDuration(t *testing.T) {
+	for _, format := range DurationFormatOptions {
+		st.Assert(t, formatNullDuration(NullDuration{}, format, DefaultWorkdayLength), DefaultEmptyCell)
+	}
+}
+
+func Test_durationFormat(t *testing.T) {
+	st.Assert(t, (&UI{}).durationFormat(), DurationFormatGo)
+	st.Assert(t, (&UI{Format: FormatCSV}).durationFormat(), DurationFormatClock)
+	st.Assert(t, (&UI{Format: FormatCSV, DurationFormat: DurationFormatSeconds}).durationFormat(), DurationFormatSeconds)
+}
+
+func Test_workdayLength(t *testing.T) {
+	st.Assert(t, (&UI{}).workdayLength(), DefaultWorkdayLength)
+	st.Assert(t, (&UI{Calendar: newCalendar(false)}).workdayLength(), 24*time.Hour)
+	st.Assert(t, (&UI{Calendar: newCalendar(true)}).workdayLength(), 24*time.Hour)
+	st.Assert(t, (&UI{Calendar: cal.NewBusinessCalendar()}).workdayLength(), 8*time.Hour)
+}
+
+func Test_RootCmd_DurationFormat(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.github.com/graphql").
+		Post("/").
+		MatchType("json").
+		AddMatcher(gqlSearchQueryMatcher(Owner, Repository, StartDate, EndDate)).
+		Reply(200).
+		BodyString(ResponseJSON)
+
+	actual := execute(t, fmt.Sprintf("--repo=%s/%s --start=%s --end=%s --csv --duration-format=seconds --columns=number,time-to-first-review,feature-lead-time", Owner, Repository, StartDate, EndDate))
+
+	st.Assert(t, strings.Contains(actual, "5339,559586,4333\n"), true)
+}
+
+func Test_RootCmd_InvalidDurationFormat(t *testing.T) {
+	actual := execute(t, "--repo=cli/cli --duration-format=fortnights")
+
+	st.Assert(t, strings.Contains(actual, `invalid duration format "fortnights"`), true)
+}
@@ -0,0 +1,243 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	graphql "github.com/cli/shurcooL-graphql"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+)
+
+// TimelineEvent is an event in the history of a pull request.
+type TimelineEvent struct {
+	Time    time.Time
+	Event   string
+	Actor   string
+	Details string
+}
+
+// fetchPullRequest returns a single PR, along with the commits and draft
+// transitions that make up its timeline.
+func (ui *UI) fetchPullRequest(number int) (ExplainPullRequest, error) {
+	client, err := ui.gqlClient()
+	if err != nil {
+		return ExplainPullRequest{}, err
+	}
+
+	var gqlQuery PullRequestGQLQuery
+	gqlQueryVariables := map[string]interface{}{
+		"owner":  graphql.String(ui.Owner),
+		"name":   graphql.String(ui.Repository),
+		"number": graphql.Int(number),
+	}
+	for name, value := range AllPullRequestFields.variables() {
+		gqlQueryVariables[name] = value
+	}
+
+	ui.rateLimit().wait()
+	if err := client.Query("PullRequest", &gqlQuery, gqlQueryVariables); err != nil {
+		return ExplainPullRequest{}, err
+	}
+	ui.rateLimit().observe(gqlQuery.RateLimit)
+
+	return gqlQuery.Repository.PullRequest, nil
+}
+
+// timeline returns the events of a PR in chronological order. Events with
+// an invalid date are left out.
+func timeline(pr ExplainPullRequest) []TimelineEvent {
+	var events []TimelineEvent
+	add := func(at, event, actor, details string) {
+		t, err := time.Parse(time.RFC3339, at)
+		if err != nil {
+			return
+		}
+		events = append(events, TimelineEvent{Time: t, Event: event, Actor: actor, Details: details})
+	}
+
+	add(pr.CreatedAt, "opened", pr.Author.Login, pr.Title)
+	for _, node := range pr.CommitDetails.Nodes {
+		commit := node.Commit
+		add(commit.CommittedDate, "commit", "", strings.TrimSpace(commit.AbbreviatedOid+" "+commit.MessageHeadline))
+	}
+	for _, node := range pr.DraftTransitions.Nodes {
+		switch node.Typename {
+		case "ReadyForReviewEvent":
+			add(node.ReadyForReviewEvent.CreatedAt, "ready for review", node.ReadyForReviewEvent.Actor.Login, "")
+		case "ConvertToDraftEvent":
+			add(node.ConvertToDraftEvent.CreatedAt, "converted to draft", node.ConvertToDraftEvent.Actor.Login, "")
+		}
+	}
+	for _, node := range pr.ReviewRequestEvents.Nodes {
+		switch node.Typename {
+		case "ReviewRequestedEvent":
+			reviewer, _ := requestedReviewer(node.ReviewRequestedEvent)
+			add(node.ReviewRequestedEvent.CreatedAt, "review requested", "", reviewer)
+		case "ReviewRequestRemovedEvent":
+			reviewer, _ := requestedReviewer(node.ReviewRequestRemovedEvent)
+			add(node.ReviewRequestRemovedEvent.CreatedAt, "review request removed", "", reviewer)
+		}
+	}
+	for _, review := range pr.Reviews.Nodes {
+		add(review.CreatedAt, "review", review.Author.Login, review.State)
+	}
+	for _, review := range pr.DismissedReviews.Nodes {
+		add(review.CreatedAt, "review", review.Author.Login, review.State)
+	}
+	for _, comment := range pr.IssueComments.Nodes {
+		add(comment.CreatedAt, "comment", comment.Author.Login, "")
+	}
+	for _, thread := range pr.ReviewThreads.Nodes {
+		for _, comment := range thread.Comments.Nodes {
+			add(comment.CreatedAt, "review comment", comment.Author.Login, "")
+		}
+	}
+	for _, node := range pr.MergeQueueEvents.Nodes {
+		switch node.Typename {
+		case "AddedToMergeQueueEvent":
+			add(node.AddedToMergeQueueEvent.CreatedAt, "added to merge queue", "", "")
+		case "RemovedFromMergeQueueEvent":
+			add(node.RemovedFromMergeQueueEvent.CreatedAt, "removed from merge queue", "", node.RemovedFromMergeQueueEvent.Reason)
+		}
+	}
+	add(pr.MergedAt, "merged", "", "")
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Time.Before(events[j].Time)
+	})
+
+	return events
+}
+
+// formatEventTime formats the time of an event in UTC.
+func formatEventTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// timelineTable returns a table of the events of a PR.
+func timelineTable(events []TimelineEvent) table.Writer {
+	t := table.NewWriter()
+	t.SetStyle(table.StyleLight)
+	t.AppendHeader(table.Row{"Time", "Event", "Actor", "Details"})
+
+	for _, event := range events {
+		actor, details := event.Actor, event.Details
+		if actor == "" {
+			actor = DefaultEmptyCell
+		}
+		if details == "" {
+			details = DefaultEmptyCell
+		}
+		t.AppendRow(table.Row{formatEventTime(event.Time), event.Event, actor, details})
+	}
+
+	return t
+}
+
+// explainTable returns a table of the events every duration metric of a PR
+// is measured between, with the wall-clock time between them and the
+// value of the metric with respect to the configured calendar.
+func (ui *UI) explainTable(pr PullRequest) table.Writer {
+	t := table.NewWriter()
+	t.SetStyle(table.StyleLight)
+	t.AppendHeader(table.Row{"Metric", "From", "To", "Wall Clock", "Value"})
+
+	metrics := ui.computeMetrics(pr)
+	for _, metric := range DurationMetrics {
+		span, err := metric.Span(ui, pr)
+		if err != nil {
+			t.AppendRow(table.Row{metric.Name, err.Error(), DefaultEmptyCell, DefaultEmptyCell, DefaultEmptyCell})
+			continue
+		}
+
+		t.AppendRow(table.Row{
+			metric.Name,
+			fmt.Sprintf("%s\n%s", span.From.Description, formatEventTime(span.From.Time)),
+			fmt.Sprintf("%s\n%s", span.To.Description, formatEventTime(span.To.Time)),
+			ui.formatDuration(span.To.Time.Sub(span.From.Time)),
+			ui.formatNullDuration(metric.Value(metrics)),
+		})
+	}
+
+	return t
+}
+
+// explain returns the timeline of a PR and how each of its metrics is
+// calculated.
+func (ui *UI) explain(pr ExplainPullRequest) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "#%d %s\n", pr.Number, pr.Title)
+	if pr.URL != "" {
+		fmt.Fprintf(&b, "%s\n", pr.URL)
+	}
+	fmt.Fprintf(&b, "\nTimeline\n%s\n", timelineTable(timeline(pr)).Render())
+	fmt.Fprintf(&b, "\nMetrics (values counting %s)\n%s", ui.calendarDescription(), ui.explainTable(pr.PullRequest).Render())
+
+	return b.String()
+}
+
+var ExplainCmd = &cobra.Command{
+	Use:   "explain NUMBER",
+	Short: "Explain how the metrics of a pull request are calculated",
+	Long: `Print the timeline of a single pull request (commits, draft transitions,
+reviews, comments and merge), followed by the events each metric is measured between,
+the wall-clock time between them, and the value with respect to the
+calendar.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		repository, _ := cmd.Flags().GetString("repo")
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+		onlyWeekdays, _ := cmd.Flags().GetBool("only-weekdays")
+		durationFormat, _ := cmd.Flags().GetString("duration-format")
+		issueStartedLabel, _ := cmd.Flags().GetString("issue-started-label")
+
+		number, err := strconv.Atoi(strings.TrimPrefix(args[0], "#"))
+		if err != nil || number <= 0 {
+			return fmt.Errorf("invalid pull request number %q", args[0])
+		}
+
+		if durationFormat != "" {
+			if err := validateDurationFormat(durationFormat); err != nil {
+				return err
+			}
+		}
+
+		repo, err := newGHRepo(repository)
+		if err != nil {
+			return err
+		}
+
+		ui := &UI{
+			Owner:             repo.Owner,
+			Repository:        repo.Name,
+			Host:              repo.Host,
+			DurationFormat:    durationFormat,
+			OnlyWeekdays:      onlyWeekdays,
+			IssueStartedLabel: issueStartedLabel,
+			Timeout:           timeout,
+			Progress:          cmd.ErrOrStderr(),
+			Calendar:          newCalendar(onlyWeekdays),
+		}
+
+		pr, err := ui.fetchPullRequest(number)
+		if err != nil {
+			return err
+		}
+
+		cmd.Println(ui.explain(pr))
+
+		return nil
+	},
+}
+
+func init() {
+	ExplainCmd.Flags().BoolP("only-weekdays", "w", false, "only include weekdays (M-F) in date range calculations")
+	ExplainCmd.Flags().String("duration-format", "", fmt.Sprintf("format of durations, one of %v", DurationFormatOptions))
+	ExplainCmd.Flags().String("issue-started-label", "", "label marking linked issues as started, instead of their first assignment")
+
+	RootCmd.AddCommand(ExplainCmd)
+}
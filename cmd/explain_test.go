@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/nbio/st"
+	"github.com/rickar/cal/v2"
+	"gopkg.in/h2non/gock.v1"
+)
+
+const PullRequestResponseJSON = `{
+  "data": {
+    "repository": {
+      "pullRequest": {
+        "author": {"login": "Batman"},
+        "additions": 6,
+        "deletions": 3,
+        "number": 5339,
+        "title": "Fix the Batmobile",
+        "url": "https://github.com/testOwner/testRepo/pull/5339",
+        "createdAt": "2022-03-21T09:00:00Z",
+        "isDraft": false,
+        "mergedAt": "2022-03-21T15:00:00Z",
+        "reviews": {
+          "nodes": [
+            {"author": {"login": "Joker"}, "createdAt": "2022-03-21T12:00:00Z", "state": "COMMENTED"},
+            {"author": {"login": "Batman"}, "createdAt": "2022-03-21T13:00:00Z", "state": "COMMENTED"},
+            {"author": {"login": "Robin"}, "createdAt": "2022-03-21T14:00:00Z", "state": "APPROVED"}
+          ]
+        },
+        "comments": {"totalCount": 2},
+        "issueComments": {
+          "nodes": [
+            {"author": {"login": "github-actions", "__typename": "Bot"}, "createdAt": "2022-03-21T10:05:00Z"},
+            {"author": {"login": "Robin", "__typename": "User"}, "createdAt": "2022-03-21T11:00:00Z"}
+          ]
+        },
+        "commits": {
+          "totalCount": 1,
+          "nodes": [{"commit": {"committedDate": "2022-03-21T08:30:00Z"}}]
+        },
+        "timelineItems": {
+          "totalCount": 1,
+          "nodes": [{"createdAt": "2022-03-21T10:00:00Z"}]
+        },
+        "commitDetails": {
+          "nodes": [{"commit": {"abbreviatedOid": "abc1234", "messageHeadline": "Fix the tyres", "committedDate": "2022-03-21T08:30:00Z"}}]
+        },
+        "draftTransitions": {
+          "nodes": [
+            {"__typename": "ReadyForReviewEvent", "actor": {"login": "Batman"}, "createdAt": "2022-03-21T10:00:00Z"}
+          ]
+        }
+      }
+    }
+  }
+}`
+
+// gqlPullRequestMatcher matches queries for a single pull request.
+func gqlPullRequestMatcher(number int) func(req *http.Request, ereq *gock.Request) (bool, error) {
+	return func(req *http.Request, ereq *gock.Request) (bool, error) {
+		var gqlRequest struct {
+			Variables struct {
+				Number int
+			}
+		}
+
+		body, err := io.ReadAll(req.Body)
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		if err != nil {
+			return false, err
+		}
+		err = json.Unmarshal(body, &gqlRequest)
+
+		return gqlRequest.Variables.Number == number, err
+	}
+}
+
+func Test_timeline(t *testing.T) {
+	var pr ExplainPullRequest
+	pr.Author.Login = "Batman"
+	pr.Title = "Fix the Batmobile"
+	pr.CreatedAt = "2022-03-21T09:00:00Z"
+	pr.MergedAt = "not-a-date"
+	pr.DraftTransitions.Nodes = DraftTransitionNodes{
+		{Typename: "ConvertToDraftEvent", ConvertToDraftEvent: DraftTransitionEvent{Actor: Author{Login: "Batman"}, CreatedAt: "2022-03-21T09:30:00Z"}},
+		{Typename: "ReadyForReviewEvent", ReadyForReviewEvent: DraftTransitionEvent{Actor: Author{Login: "Robin"}, CreatedAt: "2022-03-21T09:15:00Z"}},
+	}
+	pr.ReviewThreads.Nodes = ReviewThreadNodes{
+		{Comments: struct{ Nodes CommentNodes }{Nodes: CommentNodes{{Author: Author{Login: "Robin"}, CreatedAt: "2022-03-21T09:20:00Z"}}}},
+	}
+
+	var events []string
+	for _, event := range timeline(pr) {
+		events = append(events, fmt.Sprintf("%s %s %s", formatEventTime(event.Time), event.Event, event.Actor))
+	}
+
+	st.Assert(t, events, []string{
+		"2022-03-21T09:00:00Z opened Batman",
+		"2022-03-21T09:15:00Z ready for review Robin",
+		"2022-03-21T09:20:00Z review comment Robin",
+		"2022-03-21T09:30:00Z converted to draft Batman",
+	})
+}
+
+func Test_DurationMetrics_Span(t *testing.T) {
+	pr := PullRequest{
+		Author:   Author{Login: "Batman"},
+		MergedAt: "2022-03-21T15:00:00Z",
+		Reviews: Reviews{Nodes: ReviewNodes{
+			{Author: Author{Login: "Batman"}, CreatedAt: "2022-03-21T13:00:00Z", State: "COMMENTED"},
+		}},
+	}
+
+	var reasons []string
+	for _, metric := range DurationMetrics {
+		_, err := metric.Span(&UI{}, pr)
+		reasons = append(reasons, err.Error())
+	}
+
+	st.Assert(t, reasons, []string{
+		"not reviewed by anyone other than the author",
+		"no response from anyone other than the author or bots",
+		"no commits",
+		"not reviewed by anyone other than the author",
+		"not approved by anyone other than the author",
+		"no linked issues",
+		"no linked issues",
+		"not approved by anyone other than the author",
+		"not approved by anyone other than the author",
+		"never added to the merge queue",
+	})
+}
+
+func Test_explainTable(t *testing.T) {
+	ui := &UI{Calendar: cal.NewBusinessCalendar()}
+	pr := PullRequest{
+		MergedAt: "2022-03-21T15:00:00Z",
+		Commits:  Commits{Nodes: CommitNodes{{Commit{CommittedDate: "2022-03-21T08:30:00Z"}}}},
+	}
+
+	actual := ui.explainTable(pr).Render()
+
+	st.Assert(t, strings.Contains(actual, "│ Time to First Review    │ not reviewed by anyone other than the author          │ --                   │ --         │ --    │"), true)
+	st.Assert(t, strings.Contains(actual, "│ Time to First Response  │ no response from anyone other than the author or bots │ --                   │ --         │ --    │"), true)
+	st.Assert(t, strings.Contains(actual, "│ Feature Lead Time       │ earliest commit                                       │ merged               │ 6h30m      │ 6h0m  │"), true)
+}
+
+func Test_explain_OnlyWeekdays(t *testing.T) {
+	ui := &UI{OnlyWeekdays: true, Calendar: newCalendar(true)}
+	pr := ExplainPullRequest{PullRequest: PullRequest{Number: 5339, Title: "Fix the Batmobile"}}
+
+	st.Assert(t, strings.Contains(ui.explain(pr), "Metrics (values counting weekdays only (Monday to Friday))\n"), true)
+}
+
+func Test_ExplainCmd(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.github.com/graphql").
+		Post("/").
+		MatchType("json").
+		AddMatcher(gqlPullRequestMatcher(5339)).
+		Reply(200).
+		BodyString(PullRequestResponseJSON)
+
+	actual := execute(t, fmt.Sprintf("explain --repo=%s/%s 5339", Owner, Repository))
+
+	st.Assert(t, strings.HasPrefix(actual, "#5339 Fix the Batmobile\nhttps://github.com/testOwner/testRepo/pull/5339\n\nTimeline\n"), true)
+	st.Assert(t, strings.Contains(actual, "│ 2022-03-21T08:30:00Z │ commit           │ --             │ abc1234 Fix the tyres │"), true)
+	st.Assert(t, strings.Contains(actual, "│ 2022-03-21T10:00:00Z │ ready for review │ Batman         │ --                    │"), true)
+	st.Assert(t, strings.Contains(actual, "│ 2022-03-21T10:05:00Z │ comment          │ github-actions │ --                    │"), true)
+	st.Assert(t, strings.Contains(actual, "│ 2022-03-21T15:00:00Z │ merged           │ --             │ --                    │"), true)
+
+	st.Assert(t, strings.Contains(actual, "Metrics (values counting all days)\n"), true)
+	st.Assert(t, strings.Contains(actual, "│ Time to First Review    │ marked as ready for review                  │ first review by Joker (COMMENTED) │ 2h0m       │ 2h0m  │\n"+
+		"│                         │ 2022-03-21T10:00:00Z                        │ 2022-03-21T12:00:00Z              │            │       │\n"), true)
+	st.Assert(t, strings.Contains(actual, "│ Time to First Response  │ marked as ready for review                  │ first response: comment by Robin  │ 1h0m       │ 1h0m  │"), true)
+	st.Assert(t, strings.Contains(actual, "│ First to Last Review    │ first review by Joker (COMMENTED)           │ last approval by Robin            │ 2h0m       │ 2h0m  │"), true)
+	st.Assert(t, strings.Contains(actual, "│ First Approval to Merge │ first approval by Robin                     │ merged                            │ 1h0m       │ 1h0m  │"), true)
+}
+
+func Test_ExplainCmd_InvalidNumber(t *testing.T) {
+	actual := execute(t, "explain --repo=cli/cli latest")
+
+	st.Assert(t, strings.Contains(actual, `invalid pull request number "latest"`), true)
+}
@@ -0,0 +1,228 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Default number of search queries to run concurrently.
+	DefaultConcurrency = 4
+	// Default timeout of a single API request.
+	DefaultTimeout = 5 * time.Second
+	// Default number of times a request is retried after a secondary rate
+	// limit or a transient server error.
+	DefaultMaxRetries = 5
+	// Remaining GraphQL rate limit points below which queries wait for the
+	// rate limit to reset.
+	DefaultRateLimitThreshold = 100
+	// Delay before the first retry of a failed request. Subsequent retries
+	// double the delay.
+	DefaultRetryDelay = time.Second
+)
+
+// sleep pauses the current goroutine. It is replaced in tests.
+var sleep = time.Sleep
+
+// SearchWindow is a date range covered by a single paginated search.
+type SearchWindow struct {
+	StartDate string
+	EndDate   string
+}
+
+// searchWindows splits a date range into consecutive windows of at most
+// windowDays days. If windowDays is not positive, or either date is not in
+// DefaultDateFormat, the whole range is returned as a single window.
+func searchWindows(startDate, endDate string, windowDays int) []SearchWindow {
+	whole := []SearchWindow{{StartDate: startDate, EndDate: endDate}}
+	if windowDays <= 0 {
+		return whole
+	}
+
+	start, err := time.Parse(DefaultDateFormat, startDate)
+	if err != nil {
+		return whole
+	}
+	end, err := time.Parse(DefaultDateFormat, endDate)
+	if err != nil || end.Before(start) {
+		return whole
+	}
+
+	var windows []SearchWindow
+	for windowStart := start; !windowStart.After(end); windowStart = windowStart.AddDate(0, 0, windowDays) {
+		windowEnd := windowStart.AddDate(0, 0, windowDays-1)
+		if windowEnd.After(end) {
+			windowEnd = end
+		}
+
+		windows = append(windows, SearchWindow{
+			StartDate: windowStart.Format(DefaultDateFormat),
+			EndDate:   windowEnd.Format(DefaultDateFormat),
+		})
+	}
+
+	return windows
+}
+
+// runConcurrently calls fn once for every index in [0, n), running at most
+// concurrency calls at a time, and waits for all of them to return.
+func runConcurrently(n, concurrency int, fn func(i int)) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, concurrency)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		slots <- struct{}{}
+
+		go func(i int) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+
+			fn(i)
+		}(i)
+	}
+
+	wg.Wait()
+}
+
+// rateLimiter tracks the GraphQL rate limit budget shared by concurrent
+// queries, and pauses queries when it runs low.
+type rateLimiter struct {
+	mu        sync.Mutex
+	known     bool
+	remaining int
+	resetAt   time.Time
+	progress  io.Writer
+}
+
+// observe records the rate limit reported by a query.
+func (r *rateLimiter) observe(rateLimit RateLimit) {
+	resetAt, err := time.Parse(time.RFC3339, rateLimit.ResetAt)
+	if err != nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.known = true
+	r.remaining = rateLimit.Remaining
+	r.resetAt = resetAt
+}
+
+// wait blocks until the rate limit resets if the remaining budget is below
+// DefaultRateLimitThreshold. Concurrent callers wait for the same reset.
+func (r *rateLimiter) wait() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.known || r.remaining >= DefaultRateLimitThreshold {
+		return
+	}
+
+	if delay := time.Until(r.resetAt); delay > 0 {
+		r.printf("Rate limit low (%d remaining), waiting %s for reset\n", r.remaining, delay.Round(time.Second))
+		sleep(delay)
+	}
+	r.known = false
+}
+
+func (r *rateLimiter) printf(format string, a ...interface{}) {
+	if r.progress != nil {
+		fmt.Fprintf(r.progress, format, a...)
+	}
+}
+
+// retryTransport returns a RoundTripper that applies timeout to each
+// attempt of a request, and retries requests that hit a secondary rate
+// limit or a transient server error with exponential backoff.
+func retryTransport(base http.RoundTripper, timeout time.Duration, progress io.Writer) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		body, err := readRequestBody(req)
+		if err != nil {
+			return nil, err
+		}
+
+		delay := DefaultRetryDelay
+		for attempt := 0; ; attempt++ {
+			ctx, cancel := context.WithTimeout(req.Context(), timeout)
+			attemptReq := req.Clone(ctx)
+			attemptReq.Body = io.NopCloser(bytes.NewReader(body))
+
+			resp, err := base.RoundTrip(attemptReq)
+			if err != nil {
+				cancel()
+				return nil, err
+			}
+
+			retryAfter, retry := shouldRetry(resp)
+			if !retry || attempt >= DefaultMaxRetries {
+				resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+				return resp, nil
+			}
+
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			cancel()
+
+			if retryAfter > 0 {
+				delay = retryAfter
+			}
+			if progress != nil {
+				fmt.Fprintf(progress, "Request failed with %s, retrying in %s\n", resp.Status, delay)
+			}
+			sleep(delay)
+			delay *= 2
+		}
+	})
+}
+
+// shouldRetry reports whether a response is a secondary rate limit or a
+// transient server error, along with any delay requested by the server.
+func shouldRetry(resp *http.Response) (time.Duration, bool) {
+	var retryAfter time.Duration
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		retryAfter = time.Duration(seconds) * time.Second
+	}
+
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout, http.StatusTooManyRequests:
+		return retryAfter, true
+	case http.StatusForbidden:
+		if retryAfter > 0 {
+			return retryAfter, true
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+
+		return 0, err == nil && strings.Contains(strings.ToLower(string(body)), "secondary rate limit")
+	default:
+		return 0, false
+	}
+}
+
+// cancelOnClose releases the context of a request once its response body
+// is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	defer c.cancel()
+	return c.ReadCloser.Close()
+}
@@ -0,0 +1,226 @@
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nbio/st"
+	"github.com/rickar/cal/v2"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func recordSleeps(t *testing.T) *[]time.Duration {
+	t.Helper()
+
+	var mu sync.Mutex
+	var sleeps []time.Duration
+
+	sleep = func(d time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+		sleeps = append(sleeps, d)
+	}
+	t.Cleanup(func() {
+		sleep = time.Sleep
+	})
+
+	return &sleeps
+}
+
+func stubResponse(status int, header http.Header, body string) *http.Response {
+	if header == nil {
+		header = http.Header{}
+	}
+
+	return &http.Response{
+		StatusCode: status,
+		Status:     http.StatusText(status),
+		Header:     header,
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+}
+
+func Test_searchWindows(t *testing.T) {
+	st.Assert(t, searchWindows(StartDate, EndDate, 0), []SearchWindow{{StartDate, EndDate}})
+	st.Assert(t, searchWindows("yesterday", EndDate, 7), []SearchWindow{{"yesterday", EndDate}})
+	st.Assert(t, searchWindows(EndDate, StartDate, 7), []SearchWindow{{EndDate, StartDate}})
+
+	st.Assert(t, searchWindows(StartDate, EndDate, 4), []SearchWindow{
+		{"2022-03-18", "2022-03-21"},
+		{"2022-03-22", "2022-03-25"},
+		{"2022-03-26", "2022-03-28"},
+	})
+	st.Assert(t, searchWindows(StartDate, StartDate, 7), []SearchWindow{{StartDate, StartDate}})
+}
+
+func Test_runConcurrently(t *testing.T) {
+	var running, maxRunning, calls int32
+
+	runConcurrently(20, 3, func(i int) {
+		current := atomic.AddInt32(&running, 1)
+		for {
+			max := atomic.LoadInt32(&maxRunning)
+			if current <= max || atomic.CompareAndSwapInt32(&maxRunning, max, current) {
+				break
+			}
+		}
+
+		time.Sleep(time.Millisecond)
+		atomic.AddInt32(&calls, 1)
+		atomic.AddInt32(&running, -1)
+	})
+
+	st.Assert(t, calls, int32(20))
+	st.Assert(t, maxRunning <= 3, true)
+}
+
+func Test_rateLimiter_WaitsWhenLow(t *testing.T) {
+	sleeps := recordSleeps(t)
+	progress := new(bytes.Buffer)
+
+	limiter := &rateLimiter{progress: progress}
+	limiter.wait()
+	st.Assert(t, len(*sleeps), 0)
+
+	limiter.observe(RateLimit{Remaining: 4000, ResetAt: time.Now().Add(time.Hour).Format(time.RFC3339)})
+	limiter.wait()
+	st.Assert(t, len(*sleeps), 0)
+
+	limiter.observe(RateLimit{Remaining: 10, ResetAt: time.Now().Add(time.Hour).Format(time.RFC3339)})
+	limiter.wait()
+	st.Assert(t, len(*sleeps), 1)
+	st.Assert(t, (*sleeps)[0] > 59*time.Minute, true)
+	st.Assert(t, strings.Contains(progress.String(), "Rate limit low (10 remaining)"), true)
+
+	// The budget is unknown until the next query reports it.
+	limiter.wait()
+	st.Assert(t, len(*sleeps), 1)
+}
+
+func Test_rateLimiter_IgnoresMissingRateLimit(t *testing.T) {
+	sleeps := recordSleeps(t)
+
+	limiter := &rateLimiter{}
+	limiter.observe(RateLimit{})
+	limiter.wait()
+
+	st.Assert(t, len(*sleeps), 0)
+}
+
+func Test_retryTransport_RetriesTransientErrors(t *testing.T) {
+	sleeps := recordSleeps(t)
+
+	var bodies []string
+	responses := []*http.Response{
+		stubResponse(http.StatusBadGateway, nil, ""),
+		stubResponse(http.StatusForbidden, nil, `{"message":"You have exceeded a secondary rate limit."}`),
+		stubResponse(http.StatusTooManyRequests, http.Header{"Retry-After": []string{"30"}}, ""),
+		stubResponse(http.StatusOK, nil, `{"data":{}}`),
+	}
+	base := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		body, _ := io.ReadAll(req.Body)
+		bodies = append(bodies, string(body))
+
+		resp := responses[0]
+		responses = responses[1:]
+		return resp, nil
+	})
+
+	req, _ := http.NewRequest("POST", "https://api.github.com/graphql", strings.NewReader(`{"query":"query"}`))
+	resp, err := retryTransport(base, time.Second, nil).RoundTrip(req)
+
+	st.Assert(t, err, nil)
+	st.Assert(t, resp.StatusCode, http.StatusOK)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	st.Assert(t, string(body), `{"data":{}}`)
+	st.Assert(t, bodies, []string{`{"query":"query"}`, `{"query":"query"}`, `{"query":"query"}`, `{"query":"query"}`})
+	st.Assert(t, *sleeps, []time.Duration{time.Second, 2 * time.Second, 30 * time.Second})
+}
+
+func Test_retryTransport_DoesNotRetryOtherErrors(t *testing.T) {
+	sleeps := recordSleeps(t)
+
+	calls := 0
+	base := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		calls++
+		return stubResponse(http.StatusForbidden, nil, `{"message":"Resource not accessible by integration"}`), nil
+	})
+
+	req, _ := http.NewRequest("POST", "https://api.github.com/graphql", strings.NewReader(`{}`))
+	resp, err := retryTransport(base, time.Second, nil).RoundTrip(req)
+
+	st.Assert(t, err, nil)
+	st.Assert(t, resp.StatusCode, http.StatusForbidden)
+	body, _ := io.ReadAll(resp.Body)
+	st.Assert(t, strings.Contains(string(body), "Resource not accessible"), true)
+	st.Assert(t, calls, 1)
+	st.Assert(t, len(*sleeps), 0)
+}
+
+func Test_retryTransport_GivesUp(t *testing.T) {
+	sleeps := recordSleeps(t)
+	progress := new(bytes.Buffer)
+
+	calls := 0
+	base := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		calls++
+		return stubResponse(http.StatusServiceUnavailable, nil, ""), nil
+	})
+
+	req, _ := http.NewRequest("POST", "https://api.github.com/graphql", strings.NewReader(`{}`))
+	resp, err := retryTransport(base, time.Second, progress).RoundTrip(req)
+
+	st.Assert(t, err, nil)
+	st.Assert(t, resp.StatusCode, http.StatusServiceUnavailable)
+	st.Assert(t, calls, DefaultMaxRetries+1)
+	st.Assert(t, len(*sleeps), DefaultMaxRetries)
+	st.Assert(t, strings.Count(progress.String(), "retrying in"), DefaultMaxRetries)
+}
+
+func Test_SearchQuery_WithWindows(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.github.com/graphql").
+		Post("/").
+		MatchType("json").
+		AddMatcher(gqlSearchQueryMatcher(Owner, Repository, "2022-03-18", "2022-03-23")).
+		Reply(200).
+		BodyString(ResponseJSON)
+
+	gock.New("https://api.github.com/graphql").
+		Post("/").
+		MatchType("json").
+		AddMatcher(gqlSearchQueryMatcher(Owner, Repository, "2022-03-24", "2022-03-28")).
+		Reply(200).
+		BodyString(strings.ReplaceAll(ResponseJSON, `"number": 53`, `"number": 63`))
+
+	progress := new(bytes.Buffer)
+	ui := &UI{
+		Owner:       Owner,
+		Repository:  Repository,
+		StartDate:   StartDate,
+		EndDate:     EndDate,
+		CSVFormat:   true,
+		WindowDays:  6,
+		Concurrency: 2,
+		Progress:    progress,
+		Calendar:    cal.NewBusinessCalendar(),
+	}
+
+	have := ui.PrintMetrics()
+
+	st.Assert(t, strings.Contains(have, "5339,1,6,3,1"), true)
+	st.Assert(t, strings.Contains(have, "5340,1,12,6,2"), true)
+	st.Assert(t, strings.Contains(have, "6339,1,6,3,1"), true)
+	st.Assert(t, strings.Contains(have, "6340,1,12,6,2"), true)
+	st.Assert(t, strings.Index(have, "5339") < strings.Index(have, "6339"), true)
+	st.Assert(t, strings.Contains(progress.String(), "merged between 2022-03-18 and 2022-03-23"), true)
+	st.Assert(t, strings.Contains(progress.String(), "merged between 2022-03-24 and 2022-03-28"), true)
+}
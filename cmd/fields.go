@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"strings"
+
+	graphql "github.com/cli/shurcooL-graphql"
+)
+
+// PullRequestFields selects the optional connections fetched along with
+// every pull request. They add to the cost of every search, so only those
+// a report needs are fetched.
+type PullRequestFields struct {
+	// Comments and review threads, to measure the time to first response.
+	Responses bool
+	// Changed files, to match them to code owners.
+	Files bool
+	// Last commit and approval dismissals, to check the review policy.
+	Compliance bool
+	// Review requests and their removals, and dismissed reviews that may
+	// have answered them.
+	ReviewRequests bool
+	// Issues closed by the pull request and their assignments and labels.
+	LinkedIssues bool
+	// Additions to and removals from the merge queue.
+	MergeQueue bool
+	// Project items and their field values, to group by a project field.
+	ProjectItems bool
+}
+
+// AllPullRequestFields selects every optional connection, for pull
+// requests stored, recorded in snapshots or explained, which may be
+// reported on with any options.
+var AllPullRequestFields = PullRequestFields{
+	Responses:      true,
+	Files:          true,
+	Compliance:     true,
+	ReviewRequests: true,
+	LinkedIssues:   true,
+	MergeQueue:     true,
+	ProjectItems:   true,
+}
+
+// CoreFields selects the connections the duration metrics summarized by
+// every report are measured from.
+var CoreFields = PullRequestFields{Responses: true}
+
+// merge returns the connections selected by either set of fields.
+func (f PullRequestFields) merge(other PullRequestFields) PullRequestFields {
+	return PullRequestFields{
+		Responses:      f.Responses || other.Responses,
+		Files:          f.Files || other.Files,
+		Compliance:     f.Compliance || other.Compliance,
+		ReviewRequests: f.ReviewRequests || other.ReviewRequests,
+		LinkedIssues:   f.LinkedIssues || other.LinkedIssues,
+		MergeQueue:     f.MergeQueue || other.MergeQueue,
+		ProjectItems:   f.ProjectItems || other.ProjectItems,
+	}
+}
+
+// includes returns true if every connection selected by other is selected.
+func (f PullRequestFields) includes(other PullRequestFields) bool {
+	return f.merge(other) == f
+}
+
+// variables returns the query variables the optional connections of
+// PullRequest are included on.
+func (f PullRequestFields) variables() map[string]interface{} {
+	return map[string]interface{}{
+		"withResponses":      graphql.Boolean(f.Responses),
+		"withFiles":          graphql.Boolean(f.Files),
+		"withCompliance":     graphql.Boolean(f.Compliance),
+		"withReviewRequests": graphql.Boolean(f.ReviewRequests),
+		"withLinkedIssues":   graphql.Boolean(f.LinkedIssues),
+		"withMergeQueue":     graphql.Boolean(f.MergeQueue),
+		"withProjectItems":   graphql.Boolean(f.ProjectItems),
+	}
+}
+
+// summarizes returns true if the report summarizes every duration metric,
+// rather than only showing the selected columns of each PR.
+func (ui *UI) summarizes() bool {
+	switch ui.format() {
+	case FormatHTML, FormatOpenMetrics, FormatXLSX:
+		return true
+	}
+
+	return ui.GroupBy != "" || ui.Header || ui.Template != nil || ui.Actions != nil
+}
+
+// fields returns the optional connections of pull requests the report
+// needs, unless they are set explicitly: those of the columns shown or
+// sorted by, of the duration metrics summarized and thresholds checked,
+// and of the code owner, review request and project field reports.
+// Everything is fetched for snapshots, so that they can be replayed with
+// any options.
+func (ui *UI) fields() PullRequestFields {
+	if ui.Snapshot != nil {
+		return AllPullRequestFields
+	}
+	if ui.Fields != nil {
+		return *ui.Fields
+	}
+
+	var fields PullRequestFields
+	for _, c := range ui.columns() {
+		fields = fields.merge(c.Fields)
+	}
+	if c, err := column(strings.TrimPrefix(ui.Sort, "-")); err == nil {
+		fields = fields.merge(c.Fields)
+	}
+	if ui.summarizes() {
+		fields = fields.merge(CoreFields)
+	}
+	if ui.Actions != nil {
+		for _, threshold := range ui.Actions.Thresholds {
+			fields = fields.merge(threshold.Metric.Fields)
+		}
+	}
+	if ui.CodeOwners != nil {
+		fields.Files = true
+	}
+	if ui.ReviewRequests || ui.ReviewResponses {
+		fields.ReviewRequests = true
+	}
+	if _, ok := projectField(ui.GroupBy); ok {
+		fields.ProjectItems = true
+	}
+
+	return fields
+}
+
+// durationMetrics returns the duration metrics that can be determined from
+// the connections fetched, in the order they are displayed.
+func (ui *UI) durationMetrics() []DurationMetric {
+	fields := ui.fields()
+
+	var metrics []DurationMetric
+	for _, metric := range DurationMetrics {
+		if fields.includes(metric.Fields) {
+			metrics = append(metrics, metric)
+		}
+	}
+
+	return metrics
+}
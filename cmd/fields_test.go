@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/nbio/st"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func Test_fields(t *testing.T) {
+	st.Assert(t, (&UI{}).fields(), PullRequestFields{})
+	st.Assert(t, (&UI{Columns: []string{"number", "time-to-first-response"}}).fields(), PullRequestFields{Responses: true})
+	st.Assert(t, (&UI{Sort: "-time-in-merge-queue"}).fields(), PullRequestFields{MergeQueue: true})
+	st.Assert(t, (&UI{Columns: []string{"linked-issues"}, Format: FormatHTML}).fields(), PullRequestFields{Responses: true, LinkedIssues: true})
+	st.Assert(t, (&UI{GroupBy: GroupByLabel}).fields(), CoreFields)
+	st.Assert(t, (&UI{GroupBy: "project-field:Iteration"}).fields(), PullRequestFields{Responses: true, ProjectItems: true})
+	st.Assert(t, (&UI{ReviewResponses: true, CodeOwners: &CodeOwners{}}).fields(), PullRequestFields{Files: true, ReviewRequests: true})
+	st.Assert(t, (&UI{Fields: &PullRequestFields{Compliance: true}}).fields(), PullRequestFields{Compliance: true})
+	st.Assert(t, (&UI{Fields: &PullRequestFields{}, Snapshot: &Snapshot{}}).fields(), AllPullRequestFields)
+}
+
+func Test_durationMetrics(t *testing.T) {
+	keys := func(ui *UI) []string {
+		var keys []string
+		for _, metric := range ui.durationMetrics() {
+			keys = append(keys, metric.Key)
+		}
+		return keys
+	}
+
+	st.Assert(t, keys(&UI{}), []string{"time-to-first-review", "feature-lead-time", "first-to-last-review", "first-approval-to-merge"})
+	st.Assert(t, keys(&UI{GroupBy: GroupByLabel}), []string{"time-to-first-review", "time-to-first-response", "feature-lead-time", "first-to-last-review", "first-approval-to-merge"})
+	st.Assert(t, keys(&UI{Columns: []string{"issue-started-to-merge"}}), []string{"time-to-first-review", "feature-lead-time", "first-to-last-review", "first-approval-to-merge", "issue-created-to-merge", "issue-started-to-merge"})
+}
+
+func Test_fetchPullRequests_OnlyRequestsNeededFields(t *testing.T) {
+	defer gock.Off()
+
+	var variables map[string]interface{}
+	var query string
+	gock.New("https://api.github.com/graphql").
+		Post("/").
+		MatchType("json").
+		AddMatcher(func(req *http.Request, ereq *gock.Request) (bool, error) {
+			var gqlRequest struct {
+				Query     string
+				Variables map[string]interface{}
+			}
+			body, err := io.ReadAll(req.Body)
+			req.Body = io.NopCloser(bytes.NewReader(body))
+			if err := json.Unmarshal(body, &gqlRequest); err != nil {
+				return false, err
+			}
+			query, variables = gqlRequest.Query, gqlRequest.Variables
+
+			return variables["query"] == fmt.Sprintf("repo:%s/fieldsRepo type:pr merged:%s..%s", Owner, StartDate, EndDate), err
+		}).
+		Reply(200).
+		BodyString(ResponseJSON)
+
+	ui := &UI{Owner: Owner, Repository: "fieldsRepo", StartDate: StartDate, EndDate: EndDate, Columns: []string{"number", "time-in-merge-queue"}}
+	_, err := ui.fetchPullRequests(DefaultResultCount)
+	st.Assert(t, err, nil)
+
+	st.Assert(t, strings.Contains(query, "mergeQueueEvents: timelineItems(first: 20, itemTypes: [ADDED_TO_MERGE_QUEUE_EVENT, REMOVED_FROM_MERGE_QUEUE_EVENT]) @include(if: $withMergeQueue)"), true)
+	st.Assert(t, strings.Contains(query, "$withMergeQueue:Boolean!"), true)
+	st.Assert(t, variables["withMergeQueue"], true)
+	st.Assert(t, variables["withResponses"], false)
+	st.Assert(t, variables["withLinkedIssues"], false)
+}
@@ -5,22 +5,53 @@ type PageInfo struct {
 	EndCursor   string
 }
 
+type RateLimit struct {
+	Cost      int
+	Remaining int
+	ResetAt   string
+}
+
 type Author struct {
-	Login string
+	Login    string
+	Typename string `graphql:"__typename"`
 }
 
 type Participants struct {
 	TotalCount int
 }
 
+type CommentNodes []struct {
+	Author    Author
+	CreatedAt string
+}
+
 type Comments struct {
 	TotalCount int
 }
 
+type IssueComments struct {
+	Nodes CommentNodes
+}
+
+type ReviewThreadNodes []struct {
+	Comments struct {
+		Nodes CommentNodes
+	} `graphql:"comments(first: 1)"`
+}
+
+type ReviewThreads struct {
+	Nodes ReviewThreadNodes
+}
+
+type ReviewComments struct {
+	TotalCount int
+}
+
 type ReviewNodes []struct {
 	Author    Author
 	CreatedAt string
 	State     string
+	Comments  ReviewComments
 }
 
 type Reviews struct {
@@ -54,25 +85,268 @@ type TimelineItems struct {
 	Nodes      TimelineItemNodes
 }
 
+type ReviewDismissedEvent struct {
+	CreatedAt           string
+	PreviousReviewState string
+	Review              struct {
+		Author Author
+	}
+}
+
+type DismissalNodes []struct {
+	ReviewDismissedEvent ReviewDismissedEvent `graphql:"... on ReviewDismissedEvent"`
+}
+
+type Dismissals struct {
+	TotalCount int
+	Nodes      DismissalNodes
+}
+
+type RequestedReviewer struct {
+	User struct {
+		Login string
+	} `graphql:"... on User"`
+	Team struct {
+		CombinedSlug string
+	} `graphql:"... on Team"`
+}
+
+type ReviewRequestEvent struct {
+	CreatedAt         string
+	RequestedReviewer RequestedReviewer
+}
+
+type ReviewRequestNodes []struct {
+	Typename                  string             `graphql:"__typename"`
+	ReviewRequestedEvent      ReviewRequestEvent `graphql:"... on ReviewRequestedEvent"`
+	ReviewRequestRemovedEvent ReviewRequestEvent `graphql:"... on ReviewRequestRemovedEvent"`
+}
+
+type ReviewRequestEvents struct {
+	Nodes ReviewRequestNodes
+}
+
+type IssueEventNodes []struct {
+	Typename      string `graphql:"__typename"`
+	AssignedEvent struct {
+		CreatedAt string
+	} `graphql:"... on AssignedEvent"`
+	LabeledEvent struct {
+		CreatedAt string
+		Label     struct {
+			Name string
+		}
+	} `graphql:"... on LabeledEvent"`
+}
+
+type ClosingIssueNodes []struct {
+	Number        int
+	CreatedAt     string
+	TimelineItems struct {
+		Nodes IssueEventNodes
+	} `graphql:"timelineItems(first: 20, itemTypes: [ASSIGNED_EVENT, LABELED_EVENT])"`
+}
+
+type ClosingIssuesReferences struct {
+	Nodes ClosingIssueNodes
+}
+
+type MergeQueueEventNodes []struct {
+	Typename               string `graphql:"__typename"`
+	AddedToMergeQueueEvent struct {
+		CreatedAt string
+	} `graphql:"... on AddedToMergeQueueEvent"`
+	RemovedFromMergeQueueEvent struct {
+		CreatedAt string
+		Reason    string
+	} `graphql:"... on RemovedFromMergeQueueEvent"`
+}
+
+type MergeQueueEvents struct {
+	Nodes MergeQueueEventNodes
+}
+
+type ProjectItemNodes []struct {
+	Project struct {
+		Number int
+		Owner  struct {
+			Organization struct {
+				Login string
+			} `graphql:"... on Organization"`
+		}
+	}
+	FieldValues struct {
+		TotalCount int
+		Nodes      ProjectFieldValueNodes
+	} `graphql:"fieldValues(first: 20)"`
+}
+
+type ProjectItems struct {
+	TotalCount int
+	Nodes      ProjectItemNodes
+}
+
+type LastCommit struct {
+	Nodes CommitNodes
+}
+
+type FileNodes []struct {
+	Path string
+}
+
+type Files struct {
+	Nodes FileNodes
+}
+
+type LabelNodes []struct {
+	Name string
+}
+
+type Labels struct {
+	Nodes LabelNodes
+}
+
+type PullRequest struct {
+	Author                  Author
+	Additions               int
+	Deletions               int
+	Number                  int
+	Title                   string
+	URL                     string
+	CreatedAt               string
+	ChangedFiles            int
+	IsDraft                 bool
+	MergedAt                string
+	MergedBy                Author
+	Participants            Participants
+	Comments                Comments
+	Labels                  Labels                  `graphql:"labels(first: 100)"`
+	Reviews                 Reviews                 `graphql:"reviews(first: 100, states: [APPROVED, CHANGES_REQUESTED, COMMENTED])"`
+	Commits                 Commits                 `graphql:"commits(first: 100)"`
+	TimelineItems           TimelineItems           `graphql:"timelineItems(first: 1, itemTypes: [READY_FOR_REVIEW_EVENT])"`
+	IssueComments           IssueComments           `graphql:"issueComments: comments(first: 50) @include(if: $withResponses)"`
+	ReviewThreads           ReviewThreads           `graphql:"reviewThreads(first: 10) @include(if: $withResponses)"`
+	Files                   Files                   `graphql:"files(first: 100) @include(if: $withFiles)"`
+	LastCommit              LastCommit              `graphql:"lastCommit: commits(last: 1) @include(if: $withCompliance)"`
+	Dismissals              Dismissals              `graphql:"dismissals: timelineItems(first: 10, itemTypes: [REVIEW_DISMISSED_EVENT]) @include(if: $withCompliance)"`
+	ClosingIssuesReferences ClosingIssuesReferences `graphql:"closingIssuesReferences(first: 5) @include(if: $withLinkedIssues)"`
+	MergeQueueEvents        MergeQueueEvents        `graphql:"mergeQueueEvents: timelineItems(first: 20, itemTypes: [ADDED_TO_MERGE_QUEUE_EVENT, REMOVED_FROM_MERGE_QUEUE_EVENT]) @include(if: $withMergeQueue)"`
+	ReviewRequestEvents     ReviewRequestEvents     `graphql:"reviewRequestEvents: timelineItems(first: 50, itemTypes: [REVIEW_REQUESTED_EVENT, REVIEW_REQUEST_REMOVED_EVENT]) @include(if: $withReviewRequests)"`
+	DismissedReviews        Reviews                 `graphql:"dismissedReviews: reviews(first: 100, states: [DISMISSED]) @include(if: $withReviewRequests)"`
+	ProjectItems            ProjectItems            `graphql:"projectItems(first: 10) @include(if: $withProjectItems)"`
+}
+
 type MetricsGQLQuery struct {
-	Search struct {
+	RateLimit RateLimit
+	Search    struct {
 		PageInfo PageInfo
 		Nodes    []struct {
-			PullRequest struct {
-				Author        Author
-				Additions     int
-				Deletions     int
-				Number        int
-				CreatedAt     string
-				ChangedFiles  int
-				IsDraft       bool
-				MergedAt      string
-				Participants  Participants
-				Comments      Comments
-				Reviews       Reviews       `graphql:"reviews(first: 100, states: [APPROVED, CHANGES_REQUESTED, COMMENTED])"`
-				Commits       Commits       `graphql:"commits(first: 100)"`
-				TimelineItems TimelineItems `graphql:"timelineItems(first: 1, itemTypes: [READY_FOR_REVIEW_EVENT])"`
-			} `graphql:"... on PullRequest"`
+			PullRequest PullRequest `graphql:"... on PullRequest"`
 		}
 	} `graphql:"search(query: $query, type: ISSUE, last: $resultCount, after: $afterCursor)"`
 }
+
+type TeamMembersGQLQuery struct {
+	RateLimit    RateLimit
+	Organization struct {
+		Team struct {
+			Members struct {
+				PageInfo PageInfo
+				Nodes    []Author
+			} `graphql:"members(first: $resultCount, after: $afterCursor)"`
+		} `graphql:"team(slug: $slug)"`
+	} `graphql:"organization(login: $org)"`
+}
+
+type StalePullRequest struct {
+	Author    Author
+	Number    int
+	Title     string
+	URL       string
+	UpdatedAt string
+}
+
+type StalePullRequestsGQLQuery struct {
+	RateLimit RateLimit
+	Search    struct {
+		IssueCount int
+		PageInfo   PageInfo
+		Nodes      []struct {
+			PullRequest StalePullRequest `graphql:"... on PullRequest"`
+		}
+	} `graphql:"search(query: $query, type: ISSUE, first: $resultCount, after: $afterCursor)"`
+}
+
+type CommitDetailNodes []struct {
+	Commit struct {
+		AbbreviatedOid  string
+		MessageHeadline string
+		CommittedDate   string
+	}
+}
+
+type DraftTransitionEvent struct {
+	Actor     Author
+	CreatedAt string
+}
+
+type DraftTransitionNodes []struct {
+	Typename            string               `graphql:"__typename"`
+	ReadyForReviewEvent DraftTransitionEvent `graphql:"... on ReadyForReviewEvent"`
+	ConvertToDraftEvent DraftTransitionEvent `graphql:"... on ConvertToDraftEvent"`
+}
+
+type ExplainPullRequest struct {
+	PullRequest
+	CommitDetails struct {
+		Nodes CommitDetailNodes
+	} `graphql:"commitDetails: commits(first: 100)"`
+	DraftTransitions struct {
+		Nodes DraftTransitionNodes
+	} `graphql:"draftTransitions: timelineItems(first: 100, itemTypes: [READY_FOR_REVIEW_EVENT, CONVERT_TO_DRAFT_EVENT])"`
+}
+
+type Blob struct {
+	Blob struct {
+		Text string
+	} `graphql:"... on Blob"`
+}
+
+type CodeOwnersGQLQuery struct {
+	RateLimit  RateLimit
+	Repository struct {
+		GitHub Blob `graphql:"github: object(expression: \"HEAD:.github/CODEOWNERS\")"`
+		Root   Blob `graphql:"root: object(expression: \"HEAD:CODEOWNERS\")"`
+		Docs   Blob `graphql:"docs: object(expression: \"HEAD:docs/CODEOWNERS\")"`
+	} `graphql:"repository(owner: $owner, name: $name)"`
+}
+
+type PullRequestGQLQuery struct {
+	RateLimit  RateLimit
+	Repository struct {
+		PullRequest ExplainPullRequest `graphql:"pullRequest(number: $number)"`
+	} `graphql:"repository(owner: $owner, name: $name)"`
+}
+
+type ProjectFieldName struct {
+	Common struct {
+		Name string
+	} `graphql:"... on ProjectV2FieldCommon"`
+}
+
+type ProjectFieldValueNodes []struct {
+	Typename  string `graphql:"__typename"`
+	Iteration struct {
+		Title     string
+		StartDate string
+		Field     ProjectFieldName
+	} `graphql:"... on ProjectV2ItemFieldIterationValue"`
+	SingleSelect struct {
+		Name  string
+		Field ProjectFieldName
+	} `graphql:"... on ProjectV2ItemFieldSingleSelectValue"`
+	Text struct {
+		Text  string
+		Field ProjectFieldName
+	} `graphql:"... on ProjectV2ItemFieldTextValue"`
+}
@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+const (
+	// Group pull requests by their labels.
+	GroupByLabel = "label"
+	// Group name used for pull requests without any labels.
+	NoLabelGroup = "(none)"
+)
+
+// GroupByOptions lists the supported values of the `--group-by` flag.
+var GroupByOptions = []string{GroupByLabel, GroupByTeam}
+
+// MetricsGroup holds the pull request metrics that belong to a single group.
+type MetricsGroup struct {
+	Name    string
+	Metrics []PullRequestMetrics
+}
+
+// validateGroupBy returns an error if the given grouping is not supported.
+func validateGroupBy(groupBy string) error {
+	if groupBy == "" {
+		return nil
+	}
+	if _, ok := projectField(groupBy); ok {
+		return nil
+	}
+
+	for _, option := range GroupByOptions {
+		if groupBy == option {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("invalid group-by value %q, must be one of %v or %sFIELD", groupBy, GroupByOptions, GroupByProjectFieldPrefix)
+}
+
+// labelGroups returns the groups a PR belongs to based on its labels. Labels
+// present in the configured label map are replaced by their category, and a
+// PR is counted at most once per category.
+func (ui *UI) labelGroups(pr PullRequest) []string {
+	if len(pr.Labels.Nodes) == 0 {
+		return []string{NoLabelGroup}
+	}
+
+	var groups []string
+	seen := make(map[string]bool)
+	for _, name := range labelNames(pr.Labels) {
+		if category, ok := ui.LabelMap[name]; ok {
+			name = category
+		}
+		if !seen[name] {
+			seen[name] = true
+			groups = append(groups, name)
+		}
+	}
+
+	return groups
+}
+
+// groupMetrics partitions PR metrics into groups using groupsFn. A PR that
+// belongs to several groups is counted in each of them. Groups are sorted
+// by name.
+func groupMetrics(metrics []PullRequestMetrics, groupsFn func(PullRequest) []string) []MetricsGroup {
+	byName := make(map[string]*MetricsGroup)
+	for _, m := range metrics {
+		for _, name := range groupsFn(m.PullRequest) {
+			group, ok := byName[name]
+			if !ok {
+				group = &MetricsGroup{Name: name}
+				byName[name] = group
+			}
+			group.Metrics = append(group.Metrics, m)
+		}
+	}
+
+	groups := make([]MetricsGroup, 0, len(byName))
+	for _, group := range byName {
+		groups = append(groups, *group)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Name < groups[j].Name
+	})
+
+	return groups
+}
+
+// medianDuration returns the median of the valid durations, or an invalid
+// NullDuration if there are none.
+func medianDuration(durations []NullDuration) NullDuration {
+	var valid []time.Duration
+	for _, d := range durations {
+		if d.Valid {
+			valid = append(valid, d.Duration)
+		}
+	}
+
+	if len(valid) == 0 {
+		return NullDuration{}
+	}
+
+	sort.Slice(valid, func(i, j int) bool {
+		return valid[i] < valid[j]
+	})
+
+	mid := len(valid) / 2
+	if len(valid)%2 == 0 {
+		return NullDuration{Duration: (valid[mid-1] + valid[mid]) / 2, Valid: true}
+	}
+
+	return NullDuration{Duration: valid[mid], Valid: true}
+}
+
+// medianMetric returns the median of a single metric across a set of PRs.
+func medianMetric(metrics []PullRequestMetrics, metricFn func(PullRequestMetrics) NullDuration) NullDuration {
+	durations := make([]NullDuration, 0, len(metrics))
+	for _, m := range metrics {
+		durations = append(durations, metricFn(m))
+	}
+
+	return medianDuration(durations)
+}
+
+// groupTable returns a table containing a row of aggregate metrics per
+// group, formatted for a given output format. Durations are the median
+// across the PRs in each group.
+func (ui *UI) groupTable(groupHeader string, groups []MetricsGroup, format string) table.Writer {
+	t := table.NewWriter()
+	t.SetStyle(table.StyleLight)
+
+	header := table.Row{
+		groupHeader,
+		"PRs",
+		"Additions",
+		"Deletions",
+	}
+	durationMetrics := ui.durationMetrics()
+	for _, metric := range durationMetrics {
+		header = append(header, metric.Name)
+	}
+	t.AppendHeader(header)
+
+	for _, group := range groups {
+		additions, deletions := 0, 0
+		for _, m := range group.Metrics {
+			additions += m.PullRequest.Additions
+			deletions += m.PullRequest.Deletions
+		}
+
+		row := table.Row{
+			group.Name,
+			len(group.Metrics),
+			additions,
+			deletions,
+		}
+		for _, metric := range durationMetrics {
+			row = append(row, ui.formatNullDurationIn(medianMetric(group.Metrics, metric.Value), format))
+		}
+		t.AppendRow(row)
+	}
+
+	return t
+}
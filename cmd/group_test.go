@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nbio/st"
+	"github.com/rickar/cal/v2"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func Test_validateGroupBy(t *testing.T) {
+	st.Assert(t, validateGroupBy(""), nil)
+	st.Assert(t, validateGroupBy(GroupByLabel), nil)
+	st.Assert(t, validateGroupBy("color") != nil, true)
+}
+
+func Test_labelGroups(t *testing.T) {
+	pr := PullRequest{
+		Labels: Labels{
+			Nodes: LabelNodes{
+				{Name: "bug"},
+				{Name: "enhancement"},
+			},
+		},
+	}
+
+	ui := &UI{}
+	st.Assert(t, ui.labelGroups(pr), []string{"bug", "enhancement"})
+}
+
+func Test_labelGroups_NoLabels(t *testing.T) {
+	ui := &UI{}
+	st.Assert(t, ui.labelGroups(PullRequest{}), []string{NoLabelGroup})
+}
+
+func Test_labelGroups_WithLabelMap(t *testing.T) {
+	pr := PullRequest{
+		Labels: Labels{
+			Nodes: LabelNodes{
+				{Name: "defect"},
+				{Name: "regression"},
+				{Name: "docs"},
+			},
+		},
+	}
+
+	ui := &UI{
+		LabelMap: map[string]string{
+			"defect":     "bug",
+			"regression": "bug",
+		},
+	}
+	st.Assert(t, ui.labelGroups(pr), []string{"bug", "docs"})
+}
+
+func Test_groupMetrics(t *testing.T) {
+	metrics := []PullRequestMetrics{
+		{PullRequest: PullRequest{Number: 1, Labels: Labels{Nodes: LabelNodes{{Name: "feature"}}}}},
+		{PullRequest: PullRequest{Number: 2, Labels: Labels{Nodes: LabelNodes{{Name: "bug"}, {Name: "feature"}}}}},
+		{PullRequest: PullRequest{Number: 3}},
+	}
+
+	ui := &UI{}
+	groups := groupMetrics(metrics, ui.labelGroups)
+
+	st.Assert(t, len(groups), 3)
+	st.Assert(t, groups[0].Name, NoLabelGroup)
+	st.Assert(t, len(groups[0].Metrics), 1)
+	st.Assert(t, groups[1].Name, "bug")
+	st.Assert(t, len(groups[1].Metrics), 1)
+	st.Assert(t, groups[2].Name, "feature")
+	st.Assert(t, len(groups[2].Metrics), 2)
+}
+
+func Test_medianDuration(t *testing.T) {
+	st.Assert(t, medianDuration(nil), NullDuration{})
+	st.Assert(t, medianDuration([]NullDuration{{}, {}}), NullDuration{})
+
+	odd := []NullDuration{
+		{Duration: 3 * time.Hour, Valid: true},
+		{},
+		{Duration: 1 * time.Hour, Valid: true},
+		{Duration: 2 * time.Hour, Valid: true},
+	}
+	st.Assert(t, medianDuration(odd), NullDuration{Duration: 2 * time.Hour, Valid: true})
+
+	even := []NullDuration{
+		{Duration: 4 * time.Hour, Valid: true},
+		{Duration: 1 * time.Hour, Valid: true},
+	}
+	st.Assert(t, medianDuration(even), NullDuration{Duration: 150 * time.Minute, Valid: true})
+}
+
+func Test_SearchQuery_GroupByLabel(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.github.com/graphql").
+		Post("/").
+		MatchType("json").
+		AddMatcher(gqlSearchQueryMatcher(Owner, Repository, StartDate, EndDate)).
+		Reply(200).
+		BodyString(ResponseJSON)
+
+	ui := &UI{
+		Owner:      Owner,
+		Repository: Repository,
+		StartDate:  StartDate,
+		EndDate:    EndDate,
+		CSVFormat:  true,
+		GroupBy:    GroupByLabel,
+		Calendar:   cal.NewBusinessCalendar(),
+	}
+
+	have := ui.PrintMetrics()
+
+	st.Assert(t, strings.Contains(have, "Label,PRs,Additions,Deletions,Time to First Review,Time to First Response,Feature Lead Time,First to Last Review,First Approval to Merge"), true)
+	st.Assert(t, strings.Contains(have, "bug,2,18,9,38:13,38:12,01:12,08:00,06:51"), true)
+	st.Assert(t, strings.Contains(have, "enhancement,1,12,6,38:13,38:12,01:12,08:00,06:51"), true)
+}
+
+func Test_SearchQuery_GroupByLabelWithLabelMap(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.github.com/graphql").
+		Post("/").
+		MatchType("json").
+		AddMatcher(gqlSearchQueryMatcher(Owner, Repository, StartDate, EndDate)).
+		Reply(200).
+		BodyString(ResponseJSON)
+
+	ui := &UI{
+		Owner:      Owner,
+		Repository: Repository,
+		StartDate:  StartDate,
+		EndDate:    EndDate,
+		CSVFormat:  true,
+		GroupBy:    GroupByLabel,
+		LabelMap:   map[string]string{"bug": "maintenance", "enhancement": "maintenance"},
+		Calendar:   cal.NewBusinessCalendar(),
+	}
+
+	have := ui.PrintMetrics()
+
+	st.Assert(t, strings.Contains(have, "maintenance,2,18,9"), true)
+	st.Assert(t, strings.Contains(have, "bug"), false)
+}
@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"bytes"
+	_ "embed"
+	"html/template"
+	"log"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+var (
+	//go:embed assets/report.html.tmpl
+	reportTemplate string
+	//go:embed assets/report.css
+	reportCSS string
+	//go:embed assets/charts.js
+	chartsJS string
+	//go:embed assets/report.js
+	reportJS string
+)
+
+// ReportPoint is a pull request plotted on the size vs. lead time chart of
+// the HTML report.
+type ReportPoint struct {
+	Number int     `json:"number"`
+	URL    string  `json:"url"`
+	Size   int     `json:"size"`
+	Hours  float64 `json:"hours"`
+}
+
+// ReportData is the data used to draw the charts of the HTML report. Trend
+// holds, per week, the median in hours of every metric, or null when it
+// could not be determined.
+type ReportData struct {
+	Metrics []string      `json:"metrics"`
+	Weeks   []string      `json:"weeks"`
+	Trend   [][]*float64  `json:"trend"`
+	Scatter []ReportPoint `json:"scatter"`
+}
+
+// ReportSummary is a summary row of the HTML report.
+type ReportSummary struct {
+	Name   string
+	Count  int
+	Median string
+	Mean   string
+	P90    string
+}
+
+// reportHours returns a duration in hours, or nil if it is not valid.
+func reportHours(d NullDuration) *float64 {
+	if !d.Valid {
+		return nil
+	}
+
+	hours := d.Duration.Hours()
+	return &hours
+}
+
+// reportData returns the chart data of the HTML report for a set of PRs.
+func reportData(metrics []PullRequestMetrics, durationMetrics []DurationMetric) ReportData {
+	data := ReportData{
+		Metrics: []string{},
+		Weeks:   []string{},
+		Trend:   [][]*float64{},
+		Scatter: []ReportPoint{},
+	}
+
+	for _, metric := range durationMetrics {
+		data.Metrics = append(data.Metrics, metric.Name)
+	}
+
+	for _, point := range weeklyTrend(metrics, durationMetrics) {
+		medians := make([]*float64, 0, len(point.Medians))
+		for _, median := range point.Medians {
+			medians = append(medians, reportHours(median))
+		}
+
+		data.Weeks = append(data.Weeks, point.Week)
+		data.Trend = append(data.Trend, medians)
+	}
+
+	for _, m := range metrics {
+		if !m.FeatureLeadTime.Valid {
+			continue
+		}
+
+		data.Scatter = append(data.Scatter, ReportPoint{
+			Number: m.PullRequest.Number,
+			URL:    m.PullRequest.URL,
+			Size:   m.PullRequest.Additions + m.PullRequest.Deletions,
+			Hours:  m.FeatureLeadTime.Duration.Hours(),
+		})
+	}
+
+	return data
+}
+
+// renderHTML returns a self-contained HTML report containing a summary,
+// charts and a sortable version of the given table.
+func (ui *UI) renderHTML(t table.Writer, metrics []PullRequestMetrics) string {
+	tmpl := template.Must(template.New("report").Parse(reportTemplate))
+
+	var summaries []ReportSummary
+	for _, summary := range summarize(metrics, ui.durationMetrics()) {
+		summaries = append(summaries, ReportSummary{
+			Name:   summary.Name,
+			Count:  summary.Count,
+			Median: ui.formatNullDuration(summary.Median),
+			Mean:   ui.formatNullDuration(summary.Mean),
+			P90:    ui.formatNullDuration(summary.P90),
+		})
+	}
+
+	var b bytes.Buffer
+	err := tmpl.Execute(&b, map[string]interface{}{
+		"Owner":        ui.Owner,
+		"Repository":   ui.Repository,
+		"StartDate":    ui.StartDate,
+		"EndDate":      ui.EndDate,
+		"Query":        ui.Query,
+		"Calendar":     ui.calendarDescription(),
+		"PullRequests": len(metrics),
+		"Version":      Version,
+		"Summary":      summaries,
+		"Table":        template.HTML(t.RenderHTML()),
+		"Data":         reportData(metrics, ui.durationMetrics()),
+		"CSS":          template.CSS(reportCSS),
+		"ChartsJS":     template.JS(chartsJS),
+		"JS":           template.JS(reportJS),
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return b.String()
+}
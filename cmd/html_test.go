@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nbio/st"
+	"github.com/rickar/cal/v2"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func Test_reportData(t *testing.T) {
+	metrics := []PullRequestMetrics{
+		{
+			PullRequest:     PullRequest{Number: 1, URL: "https://github.com/o/r/pull/1", Additions: 10, Deletions: 5, MergedAt: "2022-03-21T10:00:00Z"},
+			FeatureLeadTime: NullDuration{Duration: 90 * time.Minute, Valid: true},
+		},
+		{
+			PullRequest: PullRequest{Number: 2, MergedAt: "2022-03-29T10:00:00Z"},
+		},
+	}
+
+	data := reportData(metrics, DurationMetrics)
+
+	st.Assert(t, len(data.Metrics), len(DurationMetrics))
+	st.Assert(t, data.Weeks, []string{"2022-03-21", "2022-03-28"})
+	st.Assert(t, data.Trend[0][0] == nil, true)
+	st.Assert(t, *data.Trend[0][2], 1.5)
+	st.Assert(t, data.Trend[1][2] == nil, true)
+	st.Assert(t, data.Scatter, []ReportPoint{{Number: 1, URL: "https://github.com/o/r/pull/1", Size: 15, Hours: 1.5}})
+}
+
+func Test_SearchQuery_WithHTML(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.github.com/graphql").
+		Post("/").
+		MatchType("json").
+		AddMatcher(gqlSearchQueryWithFilterMatcher).
+		Reply(200).
+		BodyString(ResponseJSON)
+
+	ui := &UI{
+		Owner:      Owner,
+		Repository: Repository,
+		StartDate:  StartDate,
+		EndDate:    EndDate,
+		Query:      Query,
+		Format:     FormatHTML,
+		Calendar:   cal.NewBusinessCalendar(),
+	}
+
+	have := ui.PrintMetrics()
+
+	st.Assert(t, strings.HasPrefix(have, "<!DOCTYPE html>"), true)
+	st.Assert(t, strings.Contains(have, "<title>Pull request metrics for testOwner/testRepo</title>"), true)
+	st.Assert(t, strings.Contains(have, "<dd>2022-03-18 to 2022-03-28</dd>"), true)
+	st.Assert(t, strings.Contains(have, "<code>author:Batman</code>"), true)
+	st.Assert(t, strings.Contains(have, "<tr><td>Feature Lead Time</td><td>2</td>"), true)
+	st.Assert(t, strings.Contains(have, `<table class="go-pretty-table">`), true)
+	st.Assert(t, strings.Contains(have, `"weeks":["2022-03-21"]`), true)
+	st.Assert(t, strings.Contains(have, `"number":5339`), true)
+	st.Assert(t, strings.Contains(have, "table.go-pretty-table th"), true)
+	st.Assert(t, strings.Contains(have, `JSON.parse(document.getElementById("report-data")`), true)
+	st.Assert(t, strings.Contains(have, "<script src"), false)
+	st.Assert(t, strings.Contains(have, "<link"), false)
+}
+
+func Test_SearchQuery_WithHTMLGroupByLabel(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.github.com/graphql").
+		Post("/").
+		MatchType("json").
+		AddMatcher(gqlSearchQueryMatcher(Owner, Repository, StartDate, EndDate)).
+		Reply(200).
+		BodyString(ResponseJSON)
+
+	ui := &UI{
+		Owner:      Owner,
+		Repository: Repository,
+		StartDate:  StartDate,
+		EndDate:    EndDate,
+		Format:     FormatHTML,
+		GroupBy:    GroupByLabel,
+		Calendar:   cal.NewBusinessCalendar(),
+	}
+
+	have := ui.PrintMetrics()
+
+	st.Assert(t, strings.Contains(have, "<th>Label</th>"), true)
+	st.Assert(t, strings.Contains(have, "<td>enhancement</td>"), true)
+}
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// IssueColumns lists the columns measured from the issues a PR closes.
+var IssueColumns = []string{"linked-issues", "issue-created-to-merge", "issue-started-to-merge"}
+
+// linkedIssues returns the numbers of the issues a PR closes.
+func linkedIssues(pr PullRequest) []string {
+	var numbers []string
+	for _, issue := range pr.ClosingIssuesReferences.Nodes {
+		numbers = append(numbers, fmt.Sprintf("#%d", issue.Number))
+	}
+
+	return numbers
+}
+
+// issueCreatedSpan returns the events the time from creation of the first
+// of the issues a PR closes to its merge is measured between.
+func issueCreatedSpan(pr PullRequest) (Span, error) {
+	var from SpanEvent
+	for _, issue := range pr.ClosingIssuesReferences.Nodes {
+		createdAt, err := time.Parse(time.RFC3339, issue.CreatedAt)
+		if err != nil {
+			return Span{}, fmt.Errorf("invalid issue creation date: %w", err)
+		}
+		if from.Time.IsZero() || createdAt.Before(from.Time) {
+			from = SpanEvent{Time: createdAt, Description: fmt.Sprintf("issue #%d created", issue.Number)}
+		}
+	}
+
+	return issueToMergeSpan(pr, from)
+}
+
+// issueStartedSpan returns the events the time from when work started on
+// the first of the issues a PR closes to its merge is measured between.
+// An issue is started when it is first assigned or, if startedLabel is
+// given, when that label is first applied to it.
+func issueStartedSpan(pr PullRequest, startedLabel string) (Span, error) {
+	var from SpanEvent
+	for _, issue := range pr.ClosingIssuesReferences.Nodes {
+		for _, node := range issue.TimelineItems.Nodes {
+			var at, description string
+			switch {
+			case node.Typename == "AssignedEvent" && startedLabel == "":
+				at, description = node.AssignedEvent.CreatedAt, fmt.Sprintf("issue #%d assigned", issue.Number)
+			case node.Typename == "LabeledEvent" && startedLabel != "" && strings.EqualFold(node.LabeledEvent.Label.Name, startedLabel):
+				at, description = node.LabeledEvent.CreatedAt, fmt.Sprintf("issue #%d labeled %s", issue.Number, node.LabeledEvent.Label.Name)
+			default:
+				continue
+			}
+
+			startedAt, err := time.Parse(time.RFC3339, at)
+			if err != nil {
+				return Span{}, fmt.Errorf("invalid issue event date: %w", err)
+			}
+			if from.Time.IsZero() || startedAt.Before(from.Time) {
+				from = SpanEvent{Time: startedAt, Description: description}
+			}
+		}
+	}
+
+	if from.Time.IsZero() && len(pr.ClosingIssuesReferences.Nodes) > 0 {
+		return Span{}, errors.New("linked issues were never started")
+	}
+
+	return issueToMergeSpan(pr, from)
+}
+
+// issueToMergeSpan returns the span from an issue event to the merge of a
+// PR.
+func issueToMergeSpan(pr PullRequest, from SpanEvent) (Span, error) {
+	if len(pr.ClosingIssuesReferences.Nodes) == 0 {
+		return Span{}, errors.New("no linked issues")
+	}
+	if pr.MergedAt == "" {
+		return Span{}, errors.New("not merged")
+	}
+
+	mergedAt, err := time.Parse(time.RFC3339, pr.MergedAt)
+	if err != nil {
+		return Span{}, fmt.Errorf("invalid merge date: %w", err)
+	}
+
+	return Span{From: from, To: SpanEvent{Time: mergedAt, Description: "merged"}}, nil
+}
+
+// withoutLinkedIssues returns the PRs that don't close any issue.
+func withoutLinkedIssues(metrics []PullRequestMetrics) []PullRequestMetrics {
+	var unlinked []PullRequestMetrics
+	for _, m := range metrics {
+		if len(m.PullRequest.ClosingIssuesReferences.Nodes) == 0 {
+			unlinked = append(unlinked, m)
+		}
+	}
+
+	return unlinked
+}
+
+// unlinkedCaption returns a caption counting the PRs that don't close any
+// issue, which issue cycle times can't be measured for.
+func unlinkedCaption(metrics []PullRequestMetrics) string {
+	unlinked := withoutLinkedIssues(metrics)
+	if len(unlinked) == 0 {
+		return ""
+	}
+
+	numbers := make([]string, 0, len(unlinked))
+	for _, m := range unlinked {
+		numbers = append(numbers, fmt.Sprintf("#%d", m.PullRequest.Number))
+	}
+
+	return fmt.Sprintf("%d of %d pull requests have no linked issue: %s", len(unlinked), len(metrics), strings.Join(numbers, ", "))
+}
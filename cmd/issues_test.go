@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nbio/st"
+	"github.com/rickar/cal/v2"
+	"gopkg.in/h2non/gock.v1"
+)
+
+// ClosingIssuesJSON links PR 5339 to issue #42, created three days before
+// the PR was opened, assigned a day later and labeled in progress the day
+// after.
+const ClosingIssuesJSON = `"closingIssuesReferences": {
+                        "nodes": [
+                            {
+                                "number": 42,
+                                "createdAt": "2022-03-18T09:00:00Z",
+                                "timelineItems": {
+                                    "nodes": [
+                                        {"__typename": "LabeledEvent", "createdAt": "2022-03-18T09:05:00Z", "label": {"name": "bug"}},
+                                        {"__typename": "AssignedEvent", "createdAt": "2022-03-19T09:00:00Z"},
+                                        {"__typename": "LabeledEvent", "createdAt": "2022-03-20T09:00:00Z", "label": {"name": "In Progress"}}
+                                    ]
+                                }
+                            }
+                        ]
+                    },
+                    "reviews": {`
+
+func issuePullRequest() PullRequest {
+	pr := PullRequest{Number: 1, MergedAt: "2022-03-21T16:00:00Z"}
+	pr.ClosingIssuesReferences.Nodes = make(ClosingIssueNodes, 2)
+
+	pr.ClosingIssuesReferences.Nodes[0].Number = 7
+	pr.ClosingIssuesReferences.Nodes[0].CreatedAt = "2022-03-21T10:00:00Z"
+
+	issue := &pr.ClosingIssuesReferences.Nodes[1]
+	issue.Number = 8
+	issue.CreatedAt = "2022-03-21T08:00:00Z"
+	issue.TimelineItems.Nodes = make(IssueEventNodes, 2)
+	issue.TimelineItems.Nodes[0].Typename = "AssignedEvent"
+	issue.TimelineItems.Nodes[0].AssignedEvent.CreatedAt = "2022-03-21T12:00:00Z"
+	issue.TimelineItems.Nodes[1].Typename = "LabeledEvent"
+	issue.TimelineItems.Nodes[1].LabeledEvent.CreatedAt = "2022-03-21T14:00:00Z"
+	issue.TimelineItems.Nodes[1].LabeledEvent.Label.Name = "in progress"
+
+	return pr
+}
+
+func Test_issueCreatedSpan(t *testing.T) {
+	span, err := issueCreatedSpan(issuePullRequest())
+	st.Assert(t, err, nil)
+	st.Assert(t, span.From.Description, "issue #8 created")
+	st.Assert(t, span.To.Time.Sub(span.From.Time), 8*time.Hour)
+
+	_, err = issueCreatedSpan(PullRequest{MergedAt: "2022-03-21T16:00:00Z"})
+	st.Assert(t, err.Error(), "no linked issues")
+}
+
+func Test_issueStartedSpan(t *testing.T) {
+	pr := issuePullRequest()
+
+	span, err := issueStartedSpan(pr, "")
+	st.Assert(t, err, nil)
+	st.Assert(t, span.From.Description, "issue #8 assigned")
+	st.Assert(t, span.To.Time.Sub(span.From.Time), 4*time.Hour)
+
+	span, err = issueStartedSpan(pr, "In Progress")
+	st.Assert(t, err, nil)
+	st.Assert(t, span.From.Description, "issue #8 labeled in progress")
+	st.Assert(t, span.To.Time.Sub(span.From.Time), 2*time.Hour)
+
+	_, err = issueStartedSpan(pr, "blocked")
+	st.Assert(t, err.Error(), "linked issues were never started")
+}
+
+func Test_SearchQuery_IssueColumns(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.github.com/graphql").
+		Post("/").
+		MatchType("json").
+		AddMatcher(gqlSearchQueryMatcher(Owner, "linkedIssuesRepo", StartDate, EndDate)).
+		Reply(200).
+		BodyString(strings.Replace(ResponseJSON, `"reviews": {`, ClosingIssuesJSON, 1))
+
+	ui := &UI{
+		Owner:             Owner,
+		Repository:        "linkedIssuesRepo",
+		StartDate:         StartDate,
+		EndDate:           EndDate,
+		Columns:           []string{"number", "linked-issues", "issue-created-to-merge", "issue-started-to-merge"},
+		IssueStartedLabel: "in progress",
+		Calendar:          cal.NewBusinessCalendar(),
+	}
+
+	have := ui.PrintMetrics()
+
+	st.Assert(t, have, `┌──────┬───────────────┬────────────────────────┬────────────────────────┐
+│   PR │ LINKED ISSUES │ ISSUE CREATED TO MERGE │ ISSUE STARTED TO MERGE │
+├──────┼───────────────┼────────────────────────┼────────────────────────┤
+│ 5339 │ #42           │ 15h22m                 │ 7h22m                  │
+│ 5340 │ --            │ --                     │ --                     │
+└──────┴───────────────┴────────────────────────┴────────────────────────┘
+1 of 2 pull requests have no linked issue: #5340`)
+}
+
+func Test_SearchQuery_IssueColumnsJSON(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.github.com/graphql").
+		Post("/").
+		MatchType("json").
+		AddMatcher(gqlSearchQueryMatcher(Owner, "unlinkedIssuesRepo", StartDate, EndDate)).
+		Reply(200).
+		BodyString(strings.Replace(ResponseJSON, `"reviews": {`, ClosingIssuesJSON, 1))
+
+	progress := new(bytes.Buffer)
+	ui := &UI{
+		Owner:      Owner,
+		Repository: "unlinkedIssuesRepo",
+		StartDate:  StartDate,
+		EndDate:    EndDate,
+		Format:     FormatJSON,
+		Columns:    []string{"number", "issue-created-to-merge"},
+		Progress:   progress,
+		Calendar:   cal.NewBusinessCalendar(),
+	}
+
+	have := ui.PrintMetrics()
+
+	st.Assert(t, strings.Contains(have, `"issue-created-to-merge": 55325,
+    "unlinked": false`), true)
+	st.Assert(t, strings.Contains(have, `"issue-created-to-merge": null,
+    "unlinked": true`), true)
+	st.Assert(t, progress.String(), "1 of 2 pull requests have no linked issue: #5340\n")
+}
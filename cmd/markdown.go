@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+// calendarDescription returns a human readable description of the calendar
+// used to calculate durations.
+func (ui *UI) calendarDescription() string {
+	if ui.OnlyWeekdays {
+		return "weekdays only (Monday to Friday)"
+	}
+
+	return "all days"
+}
+
+// summaryTable returns a table containing summary statistics for every
+// duration metric across a set of PRs, formatted for a given output format.
+func (ui *UI) summaryTable(metrics []PullRequestMetrics, format string) table.Writer {
+	t := table.NewWriter()
+	t.SetStyle(table.StyleLight)
+
+	t.AppendHeader(table.Row{
+		"Metric",
+		"PRs",
+		"Median",
+		"Mean",
+		"90th Percentile",
+	})
+
+	for _, summary := range summarize(metrics, ui.durationMetrics()) {
+		t.AppendRow(table.Row{
+			summary.Name,
+			summary.Count,
+			ui.formatNullDurationIn(summary.Median, format),
+			ui.formatNullDurationIn(summary.Mean, format),
+			ui.formatNullDurationIn(summary.P90, format),
+		})
+	}
+
+	return t
+}
+
+// markdownHeader returns a Markdown block describing the parameters of the
+// report, followed by a summary section.
+func (ui *UI) markdownHeader(metrics []PullRequestMetrics) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "## Pull request metrics for %s/%s\n\n", ui.Owner, ui.Repository)
+	fmt.Fprintf(&b, "- **Date range:** %s to %s\n", ui.StartDate, ui.EndDate)
+	if ui.Query != "" {
+		fmt.Fprintf(&b, "- **Query:** `%s`\n", ui.Query)
+	}
+	fmt.Fprintf(&b, "- **Calendar:** %s\n", ui.calendarDescription())
+	fmt.Fprintf(&b, "- **Pull requests:** %d\n\n", len(metrics))
+
+	b.WriteString("### Summary\n\n")
+	b.WriteString(ui.summaryTable(metrics, FormatMarkdown).RenderMarkdown())
+	b.WriteString("\n\n### Details\n\n")
+
+	return b.String()
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nbio/st"
+	"github.com/rickar/cal/v2"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func Test_calendarDescription(t *testing.T) {
+	st.Assert(t, (&UI{}).calendarDescription(), "all days")
+	st.Assert(t, (&UI{OnlyWeekdays: true}).calendarDescription(), "weekdays only (Monday to Friday)")
+}
+
+func Test_SearchQuery_WithMarkdown(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.github.com/graphql").
+		Post("/").
+		MatchType("json").
+		AddMatcher(gqlSearchQueryMatcher(Owner, Repository, StartDate, EndDate)).
+		Reply(200).
+		BodyString(ResponseJSON)
+
+	ui := &UI{
+		Owner:      Owner,
+		Repository: Repository,
+		StartDate:  StartDate,
+		EndDate:    EndDate,
+		Format:     FormatMarkdown,
+		Calendar:   cal.NewBusinessCalendar(),
+	}
+
+	have := ui.PrintMetrics()
+
+	st.Assert(t, strings.HasPrefix(have, "| PR | Commits |"), true)
+	st.Assert(t, strings.Contains(have, "| [5339](https://github.com/testOwner/testRepo/pull/5339) | 1 | 6 | 3 | 1 | 38h13m |"), true)
+	st.Assert(t, strings.Contains(have, "## Pull request metrics"), false)
+}
+
+func Test_SearchQuery_WithMarkdownHeader(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.github.com/graphql").
+		Post("/").
+		MatchType("json").
+		AddMatcher(gqlSearchQueryWithFilterMatcher).
+		Reply(200).
+		BodyString(ResponseJSON)
+
+	ui := &UI{
+		Owner:        Owner,
+		Repository:   Repository,
+		StartDate:    StartDate,
+		EndDate:      EndDate,
+		Query:        Query,
+		Format:       FormatMarkdown,
+		Header:       true,
+		OnlyWeekdays: true,
+		Calendar:     cal.NewBusinessCalendar(),
+	}
+
+	have := ui.PrintMetrics()
+
+	st.Assert(t, strings.HasPrefix(have, "## Pull request metrics for testOwner/testRepo\n"), true)
+	st.Assert(t, strings.Contains(have, "- **Date range:** 2022-03-18 to 2022-03-28\n"), true)
+	st.Assert(t, strings.Contains(have, "- **Query:** `author:Batman`\n"), true)
+	st.Assert(t, strings.Contains(have, "- **Calendar:** weekdays only (Monday to Friday)\n"), true)
+	st.Assert(t, strings.Contains(have, "- **Pull requests:** 2\n"), true)
+	st.Assert(t, strings.Contains(have, "| Metric | PRs | Median | Mean | 90th Percentile |"), true)
+	st.Assert(t, strings.Contains(have, "| Feature Lead Time | 2 | 1h12m | 1h12m | 1h12m |"), true)
+	st.Assert(t, strings.Contains(have, "### Details\n\n| PR |"), true)
+}
+
+func Test_SearchQuery_WithMarkdownHeaderIgnoredForTable(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.github.com/graphql").
+		Post("/").
+		MatchType("json").
+		AddMatcher(gqlSearchQueryMatcher(Owner, Repository, StartDate, EndDate)).
+		Reply(200).
+		BodyString(ResponseJSON)
+
+	ui := &UI{
+		Owner:      Owner,
+		Repository: Repository,
+		StartDate:  StartDate,
+		EndDate:    EndDate,
+		Header:     true,
+		Calendar:   cal.NewBusinessCalendar(),
+	}
+
+	have := ui.PrintMetrics()
+
+	st.Assert(t, strings.HasPrefix(have, "┌"), true)
+	st.Assert(t, strings.Contains(have, "│ 5339 │"), true)
+}
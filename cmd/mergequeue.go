@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MergeQueueEntry is a stay of a PR in the merge queue, from when it was
+// added until it was ejected or left it to be merged, with the reason it
+// was removed given, if any.
+type MergeQueueEntry struct {
+	AddedAt   time.Time
+	RemovedAt time.Time
+	Ejected   bool
+	Reason    string
+}
+
+// mergedFromQueue returns true if the reason a PR was removed from the
+// merge queue is that it was merged.
+func mergedFromQueue(reason string) bool {
+	return strings.Contains(strings.ToLower(reason), "merged")
+}
+
+// mergeQueueEntries returns the stays of a PR in the merge queue, in
+// order. A removal is an ejection unless its reason is the merge, or the
+// PR was merged after it without being added to the queue again. A PR
+// still in the queue when it was merged left it on merge.
+func mergeQueueEntries(pr PullRequest) ([]MergeQueueEntry, error) {
+	var entries []MergeQueueEntry
+	queued := false
+	for _, node := range pr.MergeQueueEvents.Nodes {
+		switch node.Typename {
+		case "AddedToMergeQueueEvent":
+			addedAt, err := time.Parse(time.RFC3339, node.AddedToMergeQueueEvent.CreatedAt)
+			if err != nil {
+				return nil, fmt.Errorf("invalid merge queue date: %w", err)
+			}
+			if !queued {
+				entries = append(entries, MergeQueueEntry{AddedAt: addedAt})
+				queued = true
+			}
+		case "RemovedFromMergeQueueEvent":
+			removedAt, err := time.Parse(time.RFC3339, node.RemovedFromMergeQueueEvent.CreatedAt)
+			if err != nil {
+				return nil, fmt.Errorf("invalid merge queue date: %w", err)
+			}
+			if queued {
+				reason := node.RemovedFromMergeQueueEvent.Reason
+				entries[len(entries)-1].RemovedAt = removedAt
+				entries[len(entries)-1].Ejected = !mergedFromQueue(reason)
+				entries[len(entries)-1].Reason = reason
+				queued = false
+			}
+		}
+	}
+
+	if len(entries) == 0 || pr.MergedAt == "" {
+		return entries, nil
+	}
+
+	last := &entries[len(entries)-1]
+	last.Ejected = false
+	if queued {
+		mergedAt, err := time.Parse(time.RFC3339, pr.MergedAt)
+		if err != nil {
+			return nil, fmt.Errorf("invalid merge date: %w", err)
+		}
+		last.RemovedAt = mergedAt
+	}
+
+	return entries, nil
+}
+
+// mergeQueueEjections returns the number of times a PR was removed from
+// the merge queue without being merged.
+func mergeQueueEjections(pr PullRequest) int {
+	entries, err := mergeQueueEntries(pr)
+	if err != nil {
+		return 0
+	}
+
+	ejections := 0
+	for _, entry := range entries {
+		if entry.Ejected {
+			ejections++
+		}
+	}
+
+	return ejections
+}
+
+// timeInMergeQueue returns the total time a PR spent in the merge queue
+// across all of its stays, with respect to the configured calendar.
+func (ui *UI) timeInMergeQueue(pr PullRequest) NullDuration {
+	entries, err := mergeQueueEntries(pr)
+	if err != nil || len(entries) == 0 {
+		return NullDuration{}
+	}
+
+	var total time.Duration
+	for _, entry := range entries {
+		if entry.RemovedAt.IsZero() {
+			return NullDuration{}
+		}
+		total += ui.subtractTime(entry.RemovedAt, entry.AddedAt)
+	}
+
+	return NullDuration{Duration: total, Valid: true}
+}
+
+// timeInMergeQueueSpan returns the events bounding the stays of a PR in
+// the merge queue, from when it was first added to when it last left it.
+// Time spent out of the queue between stays isn't counted by
+// timeInMergeQueue.
+func timeInMergeQueueSpan(pr PullRequest) (Span, error) {
+	entries, err := mergeQueueEntries(pr)
+	if err != nil {
+		return Span{}, err
+	}
+	if len(entries) == 0 {
+		return Span{}, errors.New("never added to the merge queue")
+	}
+
+	last := entries[len(entries)-1]
+	if last.RemovedAt.IsZero() {
+		return Span{}, errors.New("still in the merge queue")
+	}
+
+	left := "removed from merge queue"
+	if mergedAt, err := time.Parse(time.RFC3339, pr.MergedAt); err == nil && last.RemovedAt.Equal(mergedAt) {
+		left = "merged"
+	}
+
+	return Span{
+		From: SpanEvent{Time: entries[0].AddedAt, Description: "added to merge queue"},
+		To:   SpanEvent{Time: last.RemovedAt, Description: left},
+	}, nil
+}
+
+// enqueuedSpanEvent returns when a PR was first added to the merge queue
+// no earlier than a given time.
+func enqueuedSpanEvent(pr PullRequest, after time.Time) (SpanEvent, error) {
+	entries, err := mergeQueueEntries(pr)
+	if err != nil {
+		return SpanEvent{}, err
+	}
+
+	for _, entry := range entries {
+		if !entry.AddedAt.Before(after) {
+			return SpanEvent{Time: entry.AddedAt, Description: "added to merge queue"}, nil
+		}
+	}
+
+	return SpanEvent{}, errors.New("not added to the merge queue after approval")
+}
+
+// approvalToEnqueueSpan returns the events the time from first approval of
+// a PR to it being added to the merge queue is measured between.
+func approvalToEnqueueSpan(pr PullRequest) (Span, error) {
+	approval, err := firstApprovalToMergeSpan(pr.Author.Login, pr.MergedAt, pr.Reviews)
+	if err != nil {
+		return Span{}, err
+	}
+
+	enqueued, err := enqueuedSpanEvent(pr, approval.From.Time)
+	if err != nil {
+		return Span{}, err
+	}
+
+	return Span{From: approval.From, To: enqueued}, nil
+}
+
+// enqueueToMergeSpan returns the events the time from a PR being added to
+// the merge queue after its first approval to its merge is measured
+// between, so that together with approvalToEnqueueSpan it makes up the
+// first approval to merge time.
+func enqueueToMergeSpan(pr PullRequest) (Span, error) {
+	approval, err := firstApprovalToMergeSpan(pr.Author.Login, pr.MergedAt, pr.Reviews)
+	if err != nil {
+		return Span{}, err
+	}
+
+	enqueued, err := enqueuedSpanEvent(pr, approval.From.Time)
+	if err != nil {
+		return Span{}, err
+	}
+
+	return Span{From: enqueued, To: approval.To}, nil
+}
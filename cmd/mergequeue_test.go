@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nbio/st"
+	"github.com/rickar/cal/v2"
+	"gopkg.in/h2non/gock.v1"
+)
+
+// MergeQueueEventsJSON adds PR 5339 to the merge queue before its first
+// approval, ejecting it, and again after it, until it is merged.
+const MergeQueueEventsJSON = `"mergeQueueEvents": {
+                        "nodes": [
+                            {"__typename": "AddedToMergeQueueEvent", "createdAt": "2022-03-21T15:20:00Z"},
+                            {"__typename": "RemovedFromMergeQueueEvent", "createdAt": "2022-03-21T15:40:00Z", "reason": "Merge conflict"},
+                            {"__typename": "AddedToMergeQueueEvent", "createdAt": "2022-03-22T15:30:00Z"}
+                        ]
+                    },
+                    "reviews": {`
+
+func mergeQueueEvent(typename, at, reason string) MergeQueueEventNodes {
+	nodes := make(MergeQueueEventNodes, 1)
+	nodes[0].Typename = typename
+	nodes[0].AddedToMergeQueueEvent.CreatedAt = at
+	nodes[0].RemovedFromMergeQueueEvent.CreatedAt = at
+	nodes[0].RemovedFromMergeQueueEvent.Reason = reason
+
+	return nodes
+}
+
+func Test_mergeQueueEntries(t *testing.T) {
+	var events MergeQueueEventNodes
+	events = append(events, mergeQueueEvent("AddedToMergeQueueEvent", "2022-03-21T10:00:00Z", "")...)
+	events = append(events, mergeQueueEvent("RemovedFromMergeQueueEvent", "2022-03-21T10:30:00Z", "CI failed")...)
+	events = append(events, mergeQueueEvent("AddedToMergeQueueEvent", "2022-03-21T11:00:00Z", "")...)
+	pr := PullRequest{MergedAt: "2022-03-21T12:00:00Z", MergeQueueEvents: MergeQueueEvents{Nodes: events}}
+
+	entries, err := mergeQueueEntries(pr)
+	st.Assert(t, err, nil)
+	st.Assert(t, len(entries), 2)
+	st.Assert(t, entries[0].Reason, "CI failed")
+	st.Assert(t, entries[1].Ejected, false)
+	st.Assert(t, entries[1].RemovedAt, time.Date(2022, 3, 21, 12, 0, 0, 0, time.UTC))
+
+	ui := &UI{Calendar: newCalendar(false)}
+	st.Assert(t, mergeQueueEjections(pr), 1)
+	st.Assert(t, ui.timeInMergeQueue(pr), NullDuration{Duration: 90 * time.Minute, Valid: true})
+	st.Assert(t, ui.timeInMergeQueue(PullRequest{MergedAt: "2022-03-21T12:00:00Z"}), NullDuration{})
+
+	span, err := timeInMergeQueueSpan(pr)
+	st.Assert(t, err, nil)
+	st.Assert(t, span.From, SpanEvent{Time: time.Date(2022, 3, 21, 10, 0, 0, 0, time.UTC), Description: "added to merge queue"})
+	st.Assert(t, span.To, SpanEvent{Time: time.Date(2022, 3, 21, 12, 0, 0, 0, time.UTC), Description: "merged"})
+
+	pr.MergedAt = ""
+	st.Assert(t, ui.timeInMergeQueue(pr), NullDuration{})
+	_, err = timeInMergeQueueSpan(pr)
+	st.Assert(t, err.Error(), "still in the merge queue")
+}
+
+func Test_mergeQueueEntries_RemovedOnMerge(t *testing.T) {
+	var events MergeQueueEventNodes
+	events = append(events, mergeQueueEvent("AddedToMergeQueueEvent", "2022-03-21T10:00:00Z", "")...)
+	events = append(events, mergeQueueEvent("RemovedFromMergeQueueEvent", "2022-03-21T10:30:00Z", "")...)
+	pr := PullRequest{MergedAt: "2022-03-21T10:31:00Z", MergeQueueEvents: MergeQueueEvents{Nodes: events}}
+
+	ui := &UI{Calendar: newCalendar(false)}
+	st.Assert(t, mergeQueueEjections(pr), 0)
+	st.Assert(t, ui.timeInMergeQueue(pr), NullDuration{Duration: 30 * time.Minute, Valid: true})
+
+	span, err := timeInMergeQueueSpan(pr)
+	st.Assert(t, err, nil)
+	st.Assert(t, span.To.Description, "removed from merge queue")
+
+	pr.MergedAt = ""
+	st.Assert(t, mergeQueueEjections(pr), 1)
+
+	events = append(events, mergeQueueEvent("AddedToMergeQueueEvent", "2022-03-21T11:00:00Z", "")...)
+	events = append(events, mergeQueueEvent("RemovedFromMergeQueueEvent", "2022-03-21T11:30:00Z", "Merged")...)
+	pr.MergeQueueEvents.Nodes = events
+	st.Assert(t, mergeQueueEjections(pr), 1)
+}
+
+func Test_approvalToEnqueueSpan(t *testing.T) {
+	var events MergeQueueEventNodes
+	events = append(events, mergeQueueEvent("AddedToMergeQueueEvent", "2022-03-21T09:00:00Z", "")...)
+	events = append(events, mergeQueueEvent("RemovedFromMergeQueueEvent", "2022-03-21T09:10:00Z", "")...)
+	events = append(events, mergeQueueEvent("AddedToMergeQueueEvent", "2022-03-21T11:00:00Z", "")...)
+	pr := PullRequest{
+		Author:           Author{Login: "Batman"},
+		MergedAt:         "2022-03-21T12:00:00Z",
+		MergeQueueEvents: MergeQueueEvents{Nodes: events},
+		Reviews: Reviews{Nodes: ReviewNodes{
+			{Author: Author{Login: "Joker"}, CreatedAt: "2022-03-21T10:00:00Z", State: "APPROVED"},
+		}},
+	}
+
+	span, err := approvalToEnqueueSpan(pr)
+	st.Assert(t, err, nil)
+	st.Assert(t, span.To.Time.Sub(span.From.Time), time.Hour)
+
+	span, err = enqueueToMergeSpan(pr)
+	st.Assert(t, err, nil)
+	st.Assert(t, span.From.Description, "added to merge queue")
+	st.Assert(t, span.To.Time.Sub(span.From.Time), time.Hour)
+
+	pr.MergeQueueEvents.Nodes = events[:2]
+	_, err = approvalToEnqueueSpan(pr)
+	st.Assert(t, err.Error(), "not added to the merge queue after approval")
+}
+
+func Test_SearchQuery_MergeQueueColumns(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.github.com/graphql").
+		Post("/").
+		MatchType("json").
+		AddMatcher(gqlSearchQueryMatcher(Owner, "mergeQueueRepo", StartDate, EndDate)).
+		Reply(200).
+		BodyString(strings.Replace(
+			strings.Replace(ResponseJSON, `"mergedAt": "2022-03-21T16:22:05Z"`, `"mergedAt": "2022-03-22T16:22:05Z"`, 1),
+			`"reviews": {`, MergeQueueEventsJSON, 1))
+
+	ui := &UI{
+		Owner:      Owner,
+		Repository: "mergeQueueRepo",
+		StartDate:  StartDate,
+		EndDate:    EndDate,
+		CSVFormat:  true,
+		Columns:    []string{"number", "first-approval-to-merge", "approval-to-enqueue", "enqueue-to-merge", "time-in-merge-queue", "merge-queue-ejections"},
+		Calendar:   cal.NewBusinessCalendar(),
+	}
+
+	have := ui.PrintMetrics()
+
+	st.Assert(t, have, "PR,First Approval to Merge,Approval to Enqueue,Enqueue to Merge,Time in Merge Queue,Merge Queue Ejections\n"+
+		"5339,01:09,00:17,00:52,01:12,1\n"+
+		"5340,06:51,--,--,--,0")
+}
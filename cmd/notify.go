@@ -0,0 +1,578 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	graphql "github.com/cli/shurcooL-graphql"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// Send a Slack Block Kit message.
+	PayloadSlack = "slack"
+	// Send a Microsoft Teams message containing an Adaptive Card.
+	PayloadTeams = "teams"
+	// Send the digest as plain JSON.
+	PayloadGeneric = "generic"
+	// Default maximum number of slowest outliers included in a digest.
+	DefaultSlowest = 3
+	// Default number of days without updates after which an open pull
+	// request is stale.
+	DefaultStaleDays = 7
+	// Maximum number of stale pull requests listed in a digest.
+	DefaultStaleCount = 5
+	// Environment variable holding the webhook URL, if --webhook-url isn't
+	// given.
+	WebhookURLEnv = "GH_METRICS_WEBHOOK_URL"
+)
+
+// PayloadOptions lists the supported values of the `--payload` flag.
+var PayloadOptions = []string{PayloadSlack, PayloadTeams, PayloadGeneric}
+
+// Digest summarizes the pull requests merged in a date range, along with
+// the PRs that took the longest and the open PRs that went stale.
+type Digest struct {
+	Owner         string
+	Repository    string
+	StartDate     string
+	EndDate       string
+	PullRequests  int
+	Summary       []MetricSummary
+	SlowestMetric DurationMetric
+	Slowest       []PullRequestMetrics
+	StaleDays     int
+	StaleCount    int
+	Stale         []StalePullRequest
+}
+
+// APIStalePullRequest is the generic webhook representation of a stale PR.
+type APIStalePullRequest struct {
+	Number    int    `json:"number"`
+	Title     string `json:"title"`
+	URL       string `json:"url"`
+	Author    string `json:"author"`
+	UpdatedAt string `json:"updatedAt"`
+}
+
+// validatePayload returns an error if the given payload is not supported.
+func validatePayload(payload string) error {
+	for _, option := range PayloadOptions {
+		if payload == option {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("invalid payload %q, must be one of %v", payload, PayloadOptions)
+}
+
+// slowestOutliers returns up to n PRs whose durations of a metric are
+// outliers above the upper bound of a rule, longest first. PRs for which
+// the metric can't be determined are skipped.
+func slowestOutliers(metrics []PullRequestMetrics, metric DurationMetric, rule OutlierRule, n int) []PullRequestMetrics {
+	var valid []PullRequestMetrics
+	var durations []time.Duration
+	for _, m := range metrics {
+		if d := metric.Value(m); d.Valid {
+			valid = append(valid, m)
+			durations = append(durations, d.Duration)
+		}
+	}
+
+	_, high, ok := rule.outlierBounds(durations)
+	if !ok {
+		return nil
+	}
+
+	var outliers []PullRequestMetrics
+	for _, m := range valid {
+		if metric.Value(m).Duration > high {
+			outliers = append(outliers, m)
+		}
+	}
+
+	sort.SliceStable(outliers, func(i, j int) bool {
+		return metric.Value(outliers[i]).Duration > metric.Value(outliers[j]).Duration
+	})
+
+	if len(outliers) > n {
+		outliers = outliers[:n]
+	}
+
+	return outliers
+}
+
+// fetchStalePullRequests returns up to count open, non-draft PRs that were
+// not updated since a given date, least recently updated first, along with
+// the total number of such PRs. If teams are selected, only the PRs
+// authored by their members are counted, so every stale PR is fetched.
+func (ui *UI) fetchStalePullRequests(updatedBefore time.Time, count int) ([]StalePullRequest, int, error) {
+	client, err := ui.gqlClient()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	resultCount := count
+	if len(ui.Teams) > 0 {
+		resultCount = DefaultResultCount
+	}
+
+	var gqlQuery StalePullRequestsGQLQuery
+	gqlQueryVariables := map[string]interface{}{
+		"query": graphql.String(fmt.Sprintf("repo:%s/%s type:pr is:open draft:false updated:<%s sort:updated-asc",
+			ui.Owner,
+			ui.Repository,
+			updatedBefore.UTC().Format(DefaultDateFormat))),
+		"resultCount": graphql.Int(resultCount),
+		"afterCursor": (*graphql.String)(nil),
+	}
+
+	var pullRequests []StalePullRequest
+	total := 0
+	for {
+		ui.rateLimit().wait()
+		if err := client.Query("StalePullRequests", &gqlQuery, gqlQueryVariables); err != nil {
+			return nil, 0, err
+		}
+		ui.rateLimit().observe(gqlQuery.RateLimit)
+
+		if len(ui.Teams) == 0 {
+			for _, node := range gqlQuery.Search.Nodes {
+				pullRequests = append(pullRequests, node.PullRequest)
+			}
+			return pullRequests, gqlQuery.Search.IssueCount, nil
+		}
+
+		for _, node := range gqlQuery.Search.Nodes {
+			if len(ui.teamsOf(node.PullRequest.Author.Login)) == 0 {
+				continue
+			}
+			total++
+			if len(pullRequests) < count {
+				pullRequests = append(pullRequests, node.PullRequest)
+			}
+		}
+
+		if !gqlQuery.Search.PageInfo.HasNextPage {
+			break
+		}
+		gqlQueryVariables["afterCursor"] = graphql.String(gqlQuery.Search.PageInfo.EndCursor)
+	}
+
+	return pullRequests, total, nil
+}
+
+// digest returns a digest of the PRs merged in the configured date range,
+// with the slowest outliers by a metric and the PRs not updated in
+// staleDays days as of now. Stale PRs are left out if staleDays is not
+// positive.
+func (ui *UI) digest(slowestMetric DurationMetric, rule OutlierRule, slowestCount, staleDays int, now time.Time) (Digest, error) {
+	pullRequests, err := ui.loadPullRequests(DefaultResultCount)
+	if err != nil {
+		return Digest{}, err
+	}
+	pullRequests, err = ui.filterByTeams(pullRequests)
+	if err != nil {
+		return Digest{}, err
+	}
+	metrics, err := ui.metricsOf(pullRequests)
+	if err != nil {
+		return Digest{}, err
+	}
+
+	digest := Digest{
+		Owner:         ui.Owner,
+		Repository:    ui.Repository,
+		StartDate:     ui.StartDate,
+		EndDate:       ui.EndDate,
+		PullRequests:  len(metrics),
+		Summary:       summarize(metrics, ui.durationMetrics()),
+		SlowestMetric: slowestMetric,
+		Slowest:       slowestOutliers(metrics, slowestMetric, rule, slowestCount),
+		StaleDays:     staleDays,
+	}
+
+	if staleDays > 0 {
+		digest.Stale, digest.StaleCount, err = ui.fetchStalePullRequests(now.AddDate(0, 0, -staleDays), DefaultStaleCount)
+		if err != nil {
+			return Digest{}, err
+		}
+	}
+
+	return digest, nil
+}
+
+// escapeSlack escapes the characters Slack interprets as control sequences
+// in message text.
+func escapeSlack(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+// escapeSlackLink escapes the text of a Slack link, in which a vertical
+// bar would end the URL, so it is replaced with a broken bar.
+func escapeSlackLink(s string) string {
+	return strings.ReplaceAll(escapeSlack(s), "|", "¦")
+}
+
+// escapeTeams escapes the characters that would break a Markdown link in
+// Adaptive Card text.
+func escapeTeams(s string) string {
+	return strings.NewReplacer("[", "\\[", "]", "\\]").Replace(s)
+}
+
+// digestTitle returns the title of a digest.
+func digestTitle(d Digest) string {
+	return fmt.Sprintf("Pull request metrics for %s/%s", d.Owner, d.Repository)
+}
+
+// digestContext returns a line describing the date range of a digest.
+func digestContext(d Digest) string {
+	return fmt.Sprintf("%d pull requests merged between %s and %s", d.PullRequests, d.StartDate, d.EndDate)
+}
+
+// digestStatistics returns the median and 90th percentile of a metric.
+func (ui *UI) digestStatistics(summary MetricSummary) string {
+	return fmt.Sprintf("median %s, 90th percentile %s", ui.formatNullDuration(summary.Median), ui.formatNullDuration(summary.P90))
+}
+
+// slackPayload returns a digest as a Slack Block Kit message.
+func (ui *UI) slackPayload(d Digest) map[string]interface{} {
+	text := func(s string) map[string]interface{} {
+		return map[string]interface{}{"type": "mrkdwn", "text": s}
+	}
+
+	var fields []interface{}
+	for _, summary := range d.Summary {
+		fields = append(fields, text(fmt.Sprintf("*%s*\n%s", summary.Name, ui.digestStatistics(summary))))
+	}
+
+	blocks := []interface{}{
+		map[string]interface{}{
+			"type": "header",
+			"text": map[string]interface{}{"type": "plain_text", "text": digestTitle(d)},
+		},
+		map[string]interface{}{
+			"type":     "context",
+			"elements": []interface{}{text(digestContext(d))},
+		},
+		map[string]interface{}{"type": "section", "fields": fields},
+	}
+
+	if len(d.Slowest) > 0 {
+		lines := []string{fmt.Sprintf("*Slowest %s outliers*", strings.ToLower(d.SlowestMetric.Name))}
+		for _, m := range d.Slowest {
+			pr := m.PullRequest
+			lines = append(lines, fmt.Sprintf("• <%s|#%d %s> by %s: %s",
+				pr.URL,
+				pr.Number,
+				escapeSlackLink(pr.Title),
+				escapeSlack(pr.Author.Login),
+				ui.formatNullDuration(d.SlowestMetric.Value(m))))
+		}
+		blocks = append(blocks, map[string]interface{}{"type": "divider"}, map[string]interface{}{
+			"type": "section",
+			"text": text(strings.Join(lines, "\n")),
+		})
+	}
+
+	if d.StaleCount > 0 {
+		lines := []string{fmt.Sprintf("*%d open pull requests not updated in %d days*", d.StaleCount, d.StaleDays)}
+		for _, pr := range d.Stale {
+			lines = append(lines, fmt.Sprintf("• <%s|#%d %s> by %s, last updated %s",
+				pr.URL,
+				pr.Number,
+				escapeSlackLink(pr.Title),
+				escapeSlack(pr.Author.Login),
+				formatDate(pr.UpdatedAt)))
+		}
+		blocks = append(blocks, map[string]interface{}{"type": "divider"}, map[string]interface{}{
+			"type": "section",
+			"text": text(strings.Join(lines, "\n")),
+		})
+	}
+
+	return map[string]interface{}{
+		"text":   fmt.Sprintf("%s: %s", digestTitle(d), digestContext(d)),
+		"blocks": blocks,
+	}
+}
+
+// teamsPayload returns a digest as a Microsoft Teams message containing an
+// Adaptive Card.
+func (ui *UI) teamsPayload(d Digest) map[string]interface{} {
+	textBlock := func(s string, attributes map[string]interface{}) map[string]interface{} {
+		block := map[string]interface{}{"type": "TextBlock", "text": s, "wrap": true}
+		for k, v := range attributes {
+			block[k] = v
+		}
+		return block
+	}
+
+	var facts []interface{}
+	for _, summary := range d.Summary {
+		facts = append(facts, map[string]interface{}{"title": summary.Name, "value": ui.digestStatistics(summary)})
+	}
+
+	body := []interface{}{
+		textBlock(digestTitle(d), map[string]interface{}{"size": "Large", "weight": "Bolder"}),
+		textBlock(digestContext(d), map[string]interface{}{"isSubtle": true, "spacing": "None"}),
+		map[string]interface{}{"type": "FactSet", "facts": facts},
+	}
+
+	if len(d.Slowest) > 0 {
+		var lines []string
+		for _, m := range d.Slowest {
+			pr := m.PullRequest
+			lines = append(lines, fmt.Sprintf("- [#%d %s](%s) by %s: %s",
+				pr.Number,
+				escapeTeams(pr.Title),
+				pr.URL,
+				pr.Author.Login,
+				ui.formatNullDuration(d.SlowestMetric.Value(m))))
+		}
+		body = append(body,
+			textBlock(fmt.Sprintf("Slowest %s outliers", strings.ToLower(d.SlowestMetric.Name)), map[string]interface{}{"weight": "Bolder", "separator": true}),
+			textBlock(strings.Join(lines, "\n"), nil))
+	}
+
+	if d.StaleCount > 0 {
+		var lines []string
+		for _, pr := range d.Stale {
+			lines = append(lines, fmt.Sprintf("- [#%d %s](%s) by %s, last updated %s",
+				pr.Number,
+				escapeTeams(pr.Title),
+				pr.URL,
+				pr.Author.Login,
+				formatDate(pr.UpdatedAt)))
+		}
+		body = append(body,
+			textBlock(fmt.Sprintf("%d open pull requests not updated in %d days", d.StaleCount, d.StaleDays), map[string]interface{}{"weight": "Bolder", "separator": true}),
+			textBlock(strings.Join(lines, "\n"), nil))
+	}
+
+	return map[string]interface{}{
+		"type": "message",
+		"attachments": []interface{}{
+			map[string]interface{}{
+				"contentType": "application/vnd.microsoft.card.adaptive",
+				"content": map[string]interface{}{
+					"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+					"type":    "AdaptiveCard",
+					"version": "1.4",
+					"body":    body,
+				},
+			},
+		},
+	}
+}
+
+// genericPayload returns a digest as plain JSON, with durations in seconds.
+func genericPayload(d Digest) map[string]interface{} {
+	stale := []APIStalePullRequest{}
+	for _, pr := range d.Stale {
+		stale = append(stale, APIStalePullRequest{
+			Number:    pr.Number,
+			Title:     pr.Title,
+			URL:       pr.URL,
+			Author:    pr.Author.Login,
+			UpdatedAt: pr.UpdatedAt,
+		})
+	}
+
+	return map[string]interface{}{
+		"repository":    fmt.Sprintf("%s/%s", d.Owner, d.Repository),
+		"startDate":     d.StartDate,
+		"endDate":       d.EndDate,
+		"pullRequests":  d.PullRequests,
+		"metrics":       apiMetricSummaries(d.Summary),
+		"slowestMetric": d.SlowestMetric.Key,
+		"slowest":       apiPullRequests(d.Slowest),
+		"stale": map[string]interface{}{
+			"days":         d.StaleDays,
+			"count":        d.StaleCount,
+			"pullRequests": stale,
+		},
+	}
+}
+
+// formatDate returns the date of an RFC 3339 timestamp, or the timestamp
+// itself if it can't be parsed.
+func formatDate(timestamp string) string {
+	t, err := time.Parse(time.RFC3339, timestamp)
+	if err != nil {
+		return timestamp
+	}
+
+	return t.Format(DefaultDateFormat)
+}
+
+// payload returns a digest encoded as the given payload.
+func (ui *UI) payload(d Digest, payload string) ([]byte, error) {
+	var v interface{}
+	switch payload {
+	case PayloadSlack:
+		v = ui.slackPayload(d)
+	case PayloadTeams:
+		v = ui.teamsPayload(d)
+	default:
+		v = genericPayload(d)
+	}
+
+	// Slack links are delimited by angle brackets, which are kept
+	// readable rather than escaped as \u003c and \u003e.
+	var b bytes.Buffer
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimSuffix(b.Bytes(), []byte("\n")), nil
+}
+
+// postWebhook posts a JSON payload to a webhook, returning an error unless
+// it responds with a 2xx status.
+func postWebhook(client *http.Client, url string, payload []byte) error {
+	resp, err := client.Post(url, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook responded with %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	return nil
+}
+
+var NotifyCmd = &cobra.Command{
+	Use:   "notify",
+	Short: "Send a digest of pull request metrics to a webhook",
+	Long: `Send a digest of the pull requests merged in a date range to an incoming
+webhook: the median and 90th percentile of every duration metric, the pull
+requests that took the longest as outliers by --outlier-threshold, and the
+open pull requests that haven't been updated in --stale-days days.
+
+The webhook URL is read from --webhook-url, or the ` + WebhookURLEnv + `
+environment variable. Use --dry-run to print the payload instead of sending it.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		repository, _ := cmd.Flags().GetString("repo")
+		startDate, _ := cmd.Flags().GetString("start")
+		endDate, _ := cmd.Flags().GetString("end")
+		windowDays, _ := cmd.Flags().GetInt("window-days")
+		concurrency, _ := cmd.Flags().GetInt("concurrency")
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+		query, _ := cmd.Flags().GetString("query")
+		onlyWeekdays, _ := cmd.Flags().GetBool("only-weekdays")
+		teamNames, _ := cmd.Flags().GetStringArray("team")
+		webhookURL, _ := cmd.Flags().GetString("webhook-url")
+		payload, _ := cmd.Flags().GetString("payload")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		slowestCount, _ := cmd.Flags().GetInt("slowest")
+		slowestMetricKey, _ := cmd.Flags().GetString("slowest-metric")
+		outlierThreshold, _ := cmd.Flags().GetString("outlier-threshold")
+		staleDays, _ := cmd.Flags().GetInt("stale-days")
+
+		repo, err := newGHRepo(repository)
+		if err != nil {
+			return err
+		}
+
+		if err := validatePayload(payload); err != nil {
+			return err
+		}
+
+		slowestMetric, err := durationMetric(slowestMetricKey)
+		if err != nil {
+			return err
+		}
+
+		outlierRule, err := newOutlierRule(outlierThreshold)
+		if err != nil {
+			return err
+		}
+
+		if webhookURL == "" {
+			webhookURL = os.Getenv(WebhookURLEnv)
+		}
+		if webhookURL == "" && !dryRun {
+			return fmt.Errorf("--webhook-url or %s is required, unless --dry-run is given", WebhookURLEnv)
+		}
+
+		var teams []GHTeam
+		for _, teamName := range teamNames {
+			team, err := newGHTeam(teamName)
+			if err != nil {
+				return err
+			}
+			teams = append(teams, *team)
+		}
+
+		fields := CoreFields.merge(slowestMetric.Fields)
+		ui := &UI{
+			Owner:        repo.Owner,
+			Repository:   repo.Name,
+			Host:         repo.Host,
+			StartDate:    startDate,
+			EndDate:      endDate,
+			Query:        query,
+			OnlyWeekdays: onlyWeekdays,
+			Teams:        teams,
+			Fields:       &fields,
+			WindowDays:   windowDays,
+			Concurrency:  concurrency,
+			Timeout:      timeout,
+			Progress:     cmd.ErrOrStderr(),
+			Calendar:     newCalendar(onlyWeekdays),
+		}
+
+		digest, err := ui.digest(slowestMetric, *outlierRule, slowestCount, staleDays, time.Now())
+		if err != nil {
+			return err
+		}
+
+		body, err := ui.payload(digest, payload)
+		if err != nil {
+			return err
+		}
+
+		if dryRun {
+			cmd.Println(string(body))
+			return nil
+		}
+
+		if err := postWebhook(&http.Client{Timeout: timeout}, webhookURL, body); err != nil {
+			return fmt.Errorf("failed to send digest: %w", err)
+		}
+
+		cmd.Printf("Sent digest of %d pull requests merged between %s and %s\n", digest.PullRequests, startDate, endDate)
+
+		return nil
+	},
+}
+
+func init() {
+	NotifyCmd.Flags().StringP("query", "q", "", "additional query filter for merged pull requests")
+	NotifyCmd.Flags().BoolP("only-weekdays", "w", false, "only include weekdays (M-F) in date range calculations")
+	NotifyCmd.Flags().StringArrayP("team", "t", nil, "only include pull requests authored by members of the team in 'ORG/TEAM-SLUG' format (repeatable)")
+	NotifyCmd.Flags().String("webhook-url", "", fmt.Sprintf("incoming webhook URL to send the digest to (defaults to $%s)", WebhookURLEnv))
+	NotifyCmd.Flags().String("payload", PayloadSlack, fmt.Sprintf("webhook payload, one of %v", PayloadOptions))
+	NotifyCmd.Flags().Bool("dry-run", false, "print the payload instead of sending it")
+	NotifyCmd.Flags().Int("slowest", DefaultSlowest, "maximum number of outliers that took the longest to include")
+	NotifyCmd.Flags().String("slowest-metric", "feature-lead-time", "duration metric to find the slowest outliers by")
+	NotifyCmd.Flags().String("outlier-threshold", DefaultOutlierMADs, "number of median absolute deviations from the median of the slowest metric, or percentile such as p95, above which pull requests are outliers")
+	NotifyCmd.Flags().Int("stale-days", DefaultStaleDays, "number of days without updates after which open pull requests are listed as stale (0 to disable)")
+
+	RootCmd.AddCommand(NotifyCmd)
+}
@@ -0,0 +1,246 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nbio/st"
+	"gopkg.in/h2non/gock.v1"
+)
+
+const StaleResponseJSON = `{
+  "data": {
+    "search": {
+      "issueCount": 7,
+      "nodes": [
+        {
+          "author": {"login": "Robin"},
+          "number": 5100,
+          "title": "Refactor the <Batcave> & lights",
+          "url": "https://github.com/testOwner/testRepo/pull/5100",
+          "updatedAt": "2022-02-01T10:00:00Z"
+        }
+      ]
+    }
+  }
+}`
+
+// gqlStaleQueryMatcher matches searches for stale pull requests.
+func gqlStaleQueryMatcher(req *http.Request, ereq *gock.Request) (bool, error) {
+	var gqlRequest GQLRequest
+
+	body, err := io.ReadAll(req.Body)
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	err = json.Unmarshal(body, &gqlRequest)
+
+	return strings.Contains(gqlRequest.Variables.Query, "is:open draft:false updated:<"), err
+}
+
+func mockDigest(repo, response string) {
+	gock.New("https://api.github.com/graphql").
+		Post("/").
+		MatchType("json").
+		AddMatcher(gqlSearchQueryMatcher(Owner, repo, StartDate, EndDate)).
+		Reply(200).
+		BodyString(response)
+
+	gock.New("https://api.github.com/graphql").
+		Post("/").
+		MatchType("json").
+		AddMatcher(gqlStaleQueryMatcher).
+		Reply(200).
+		BodyString(StaleResponseJSON)
+}
+
+func Test_slowestOutliers(t *testing.T) {
+	metrics := []PullRequestMetrics{
+		{PullRequest: PullRequest{Number: 1}, FeatureLeadTime: NullDuration{Duration: time.Hour, Valid: true}},
+		{PullRequest: PullRequest{Number: 2}},
+		{PullRequest: PullRequest{Number: 3}, FeatureLeadTime: NullDuration{Duration: 30 * time.Hour, Valid: true}},
+		{PullRequest: PullRequest{Number: 4}, FeatureLeadTime: NullDuration{Duration: 2 * time.Hour, Valid: true}},
+		{PullRequest: PullRequest{Number: 5}, FeatureLeadTime: NullDuration{Duration: 90 * time.Minute, Valid: true}},
+		{PullRequest: PullRequest{Number: 6}, FeatureLeadTime: NullDuration{Duration: 20 * time.Hour, Valid: true}},
+	}
+	metric, _ := durationMetric("feature-lead-time")
+
+	var numbers []int
+	for _, m := range slowestOutliers(metrics, metric, OutlierRule{MADs: 3}, 10) {
+		numbers = append(numbers, m.PullRequest.Number)
+	}
+	st.Assert(t, numbers, []int{3, 6})
+
+	st.Assert(t, len(slowestOutliers(metrics, metric, OutlierRule{MADs: 3}, 1)), 1)
+	st.Assert(t, len(slowestOutliers(metrics, metric, OutlierRule{Percentile: 40}, 10)), 3)
+	st.Assert(t, len(slowestOutliers(metrics[:2], metric, OutlierRule{MADs: 3}, 10)), 0)
+}
+
+func Test_escapeSlack(t *testing.T) {
+	st.Assert(t, escapeSlack("<Batcave> & lights"), "&lt;Batcave&gt; &amp; lights")
+}
+
+func Test_escapeSlackLink(t *testing.T) {
+	st.Assert(t, escapeSlackLink("Batcave | <lights>"), "Batcave ¦ &lt;lights&gt;")
+}
+
+func Test_escapeTeams(t *testing.T) {
+	st.Assert(t, escapeTeams("[WIP] Batcave"), "\\[WIP\\] Batcave")
+}
+
+func Test_formatDate(t *testing.T) {
+	st.Assert(t, formatDate("2022-02-01T10:00:00Z"), "2022-02-01")
+	st.Assert(t, formatDate("yesterday"), "yesterday")
+}
+
+func Test_postWebhook(t *testing.T) {
+	var contentType, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+
+		if strings.Contains(body, "fail") {
+			http.Error(w, "invalid_payload", http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	st.Assert(t, postWebhook(server.Client(), server.URL, []byte(`{"text":"ok"}`)), nil)
+	st.Assert(t, contentType, "application/json")
+	st.Assert(t, body, `{"text":"ok"}`)
+
+	err := postWebhook(server.Client(), server.URL, []byte(`{"text":"fail"}`))
+	st.Assert(t, err.Error(), "webhook responded with 400 Bad Request: invalid_payload")
+}
+
+func Test_NotifyCmd_DryRunSlack(t *testing.T) {
+	defer gock.Off()
+	mockDigest("slowRepo", strings.Replace(ResponseJSON, `"mergedAt": "2022-03-22T16:22:05Z"`, `"mergedAt": "2022-03-22T18:22:05Z"`, 1))
+
+	actual := execute(t, fmt.Sprintf("notify --repo=%s/slowRepo --start=%s --end=%s --dry-run --slowest=1 --outlier-threshold=p50", Owner, StartDate, EndDate))
+
+	var payload struct {
+		Text   string
+		Blocks []struct {
+			Type   string
+			Fields []struct{ Text string }
+			Text   struct{ Text string }
+		}
+	}
+	st.Assert(t, json.Unmarshal([]byte(actual), &payload), nil)
+
+	st.Assert(t, payload.Text, "Pull request metrics for testOwner/slowRepo: 2 pull requests merged between 2022-03-18 and 2022-03-28")
+	st.Assert(t, len(payload.Blocks), 7)
+	st.Assert(t, payload.Blocks[0].Text.Text, "Pull request metrics for testOwner/slowRepo")
+	st.Assert(t, payload.Blocks[2].Fields[2].Text, "*Feature Lead Time*\nmedian 2h12m, 90th percentile 3h12m")
+	st.Assert(t, payload.Blocks[4].Text.Text, "*Slowest feature lead time outliers*\n• <https://github.com/testOwner/testRepo/pull/5340|#5340 Add a grappling hook> by Batman: 3h12m")
+	st.Assert(t, payload.Blocks[6].Text.Text, "*7 open pull requests not updated in 7 days*\n• <https://github.com/testOwner/testRepo/pull/5100|#5100 Refactor the &lt;Batcave&gt; &amp; lights> by Robin, last updated 2022-02-01")
+}
+
+func Test_NotifyCmd_DryRunTeams(t *testing.T) {
+	defer gock.Off()
+	mockDigest(Repository, ResponseJSON)
+
+	actual := execute(t, fmt.Sprintf("notify --repo=%s/%s --start=%s --end=%s --dry-run --payload=teams --stale-days=0", Owner, Repository, StartDate, EndDate))
+
+	st.Assert(t, strings.Contains(actual, `"contentType": "application/vnd.microsoft.card.adaptive"`), true)
+	st.Assert(t, strings.Contains(actual, `"title": "Time to First Review"`), true)
+	st.Assert(t, strings.Contains(actual, "outliers"), false)
+	st.Assert(t, strings.Contains(actual, "not updated"), false)
+}
+
+func Test_NotifyCmd_Generic(t *testing.T) {
+	defer gock.Off()
+	mockDigest(Repository, ResponseJSON)
+
+	var received map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received)
+	}))
+	defer server.Close()
+
+	// Only the webhook is reached over the network, as matched mocks
+	// are otherwise sent too.
+	gock.EnableNetworking()
+	gock.NetworkingFilter(func(req *http.Request) bool {
+		return strings.HasPrefix(server.URL, "http://"+req.URL.Host)
+	})
+	defer gock.DisableNetworking()
+	defer gock.DisableNetworkingFilters()
+
+	actual := execute(t, fmt.Sprintf("notify --repo=%s/%s --start=%s --end=%s --payload=generic --webhook-url=%s", Owner, Repository, StartDate, EndDate, server.URL))
+
+	st.Assert(t, actual, "Sent digest of 2 pull requests merged between 2022-03-18 and 2022-03-28\n")
+	st.Assert(t, received["repository"], "testOwner/testRepo")
+	st.Assert(t, received["pullRequests"], float64(2))
+	st.Assert(t, received["slowestMetric"], "feature-lead-time")
+	st.Assert(t, len(received["slowest"].([]interface{})), 0)
+	st.Assert(t, received["stale"].(map[string]interface{})["count"], float64(7))
+}
+
+func Test_NotifyCmd_TeamStale(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.github.com/graphql").
+		Post("/").
+		MatchType("json").
+		AddMatcher(gqlTeamMembersQueryMatcher("gotham", "digest")).
+		Reply(200).
+		BodyString(TeamMembersJSON)
+
+	stale := strings.Replace(StaleResponseJSON, `"nodes": [`, `"nodes": [
+        {
+          "author": {"login": "Joker"},
+          "number": 5050,
+          "title": "Flood the Batcave",
+          "url": "https://github.com/testOwner/teamDigestRepo/pull/5050",
+          "updatedAt": "2022-01-01T10:00:00Z"
+        },`, 1)
+	gock.New("https://api.github.com/graphql").
+		Post("/").
+		MatchType("json").
+		AddMatcher(gqlSearchQueryMatcher(Owner, "teamDigestRepo", StartDate, EndDate)).
+		Reply(200).
+		BodyString(ResponseJSON)
+	gock.New("https://api.github.com/graphql").
+		Post("/").
+		MatchType("json").
+		AddMatcher(gqlStaleQueryMatcher).
+		Reply(200).
+		BodyString(stale)
+
+	actual := execute(t, fmt.Sprintf("notify --repo=%s/teamDigestRepo --start=%s --end=%s --dry-run --payload=generic --team=gotham/digest", Owner, StartDate, EndDate))
+
+	var payload struct {
+		Stale struct {
+			Count        int
+			PullRequests []APIStalePullRequest
+		}
+	}
+	st.Assert(t, json.Unmarshal([]byte(actual), &payload), nil)
+	st.Assert(t, payload.Stale.Count, 1)
+	st.Assert(t, payload.Stale.PullRequests[0].Number, 5100)
+}
+
+func Test_NotifyCmd_WithoutWebhook(t *testing.T) {
+	t.Setenv(WebhookURLEnv, "")
+
+	actual := execute(t, "notify --repo=cli/cli")
+
+	st.Assert(t, strings.Contains(actual, "--webhook-url or GH_METRICS_WEBHOOK_URL is required, unless --dry-run is given"), true)
+}
+
+func Test_NotifyCmd_InvalidPayload(t *testing.T) {
+	actual := execute(t, "notify --repo=cli/cli --payload=irc")
+
+	st.Assert(t, strings.Contains(actual, `invalid payload "irc"`), true)
+}
@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OpenMetricsPrefix is the prefix of every exported metric name.
+const OpenMetricsPrefix = "gh_metrics"
+
+// HistogramBuckets are the upper bounds of the buckets of the exported
+// duration gauge histograms.
+var HistogramBuckets = []time.Duration{
+	time.Hour,
+	4 * time.Hour,
+	8 * time.Hour,
+	24 * time.Hour,
+	72 * time.Hour,
+	168 * time.Hour,
+	336 * time.Hour,
+}
+
+// SizeBucket is a named range of changed lines (additions and deletions)
+// used to label pull requests by size.
+type SizeBucket struct {
+	Name     string
+	MaxLines int
+}
+
+// SizeBuckets lists the pull request size buckets, from smallest to
+// largest. The last bucket has no upper bound.
+var SizeBuckets = []SizeBucket{
+	{Name: "XS", MaxLines: 9},
+	{Name: "S", MaxLines: 99},
+	{Name: "M", MaxLines: 499},
+	{Name: "L", MaxLines: 999},
+	{Name: "XL"},
+}
+
+// sizeBucket returns the name of the size bucket of a given PR.
+func sizeBucket(pr PullRequest) string {
+	lines := pr.Additions + pr.Deletions
+	for _, bucket := range SizeBuckets[:len(SizeBuckets)-1] {
+		if lines <= bucket.MaxLines {
+			return bucket.Name
+		}
+	}
+
+	return SizeBuckets[len(SizeBuckets)-1].Name
+}
+
+// Observation is a pull request, with the labels it is exported under.
+type Observation struct {
+	Labels  string
+	Metrics PullRequestMetrics
+}
+
+// escapeLabelValue escapes a label value for the exposition format.
+func escapeLabelValue(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// observations returns the observations of a set of PRs, labelled by
+// repository, author team and size bucket. A PR whose author is a member of
+// several teams is observed once per team.
+func (ui *UI) observations(metrics []PullRequestMetrics) []Observation {
+	var observations []Observation
+	for _, m := range metrics {
+		for _, team := range ui.teamGroups(m.PullRequest) {
+			labels := fmt.Sprintf(`repo="%s",team="%s",size="%s"`,
+				escapeLabelValue(ui.Owner+"/"+ui.Repository),
+				escapeLabelValue(team),
+				sizeBucket(m.PullRequest))
+			observations = append(observations, Observation{Labels: labels, Metrics: m})
+		}
+	}
+
+	return observations
+}
+
+// formatSeconds formats a duration as a number of seconds.
+func formatSeconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64)
+}
+
+// renderOpenMetrics returns a set of observations in the OpenMetrics text
+// exposition format: a gauge histogram per given duration metric, and
+// gauges of merged pull requests and changed lines, per set of labels.
+// They are gauges as they describe a sliding reporting period, so they go
+// down as pull requests fall out of it.
+func renderOpenMetrics(observations []Observation, durationMetrics []DurationMetric) string {
+	byLabels := make(map[string][]PullRequestMetrics)
+	for _, o := range observations {
+		byLabels[o.Labels] = append(byLabels[o.Labels], o.Metrics)
+	}
+
+	labelSets := make([]string, 0, len(byLabels))
+	for labels := range byLabels {
+		labelSets = append(labelSets, labels)
+	}
+	sort.Strings(labelSets)
+
+	var b strings.Builder
+
+	for _, metric := range durationMetrics {
+		name := fmt.Sprintf("%s_%s_seconds", OpenMetricsPrefix, strings.ReplaceAll(metric.Key, "-", "_"))
+		fmt.Fprintf(&b, "# HELP %s %s of pull requests merged in the reporting period.\n", name, metric.Name)
+		fmt.Fprintf(&b, "# TYPE %s gaugehistogram\n", name)
+		fmt.Fprintf(&b, "# UNIT %s seconds\n", name)
+
+		for _, labels := range labelSets {
+			counts := make([]int, len(HistogramBuckets))
+			count := 0
+			var sum time.Duration
+
+			for _, m := range byLabels[labels] {
+				d := metric.Value(m)
+				if !d.Valid {
+					continue
+				}
+
+				count++
+				sum += d.Duration
+				for i, bound := range HistogramBuckets {
+					if d.Duration <= bound {
+						counts[i]++
+					}
+				}
+			}
+
+			for i, bound := range HistogramBuckets {
+				fmt.Fprintf(&b, "%s_bucket{%s,le=\"%s\"} %d\n", name, labels, formatSeconds(bound), counts[i])
+			}
+			fmt.Fprintf(&b, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, labels, count)
+			fmt.Fprintf(&b, "%s_gcount{%s} %d\n", name, labels, count)
+			fmt.Fprintf(&b, "%s_gsum{%s} %s\n", name, labels, formatSeconds(sum))
+		}
+	}
+
+	gauges := []struct {
+		name  string
+		help  string
+		value func(PullRequest) int
+	}{
+		{"pull_requests", "Pull requests merged in the reporting period.", func(PullRequest) int { return 1 }},
+		{"changed_lines", "Lines added and deleted by pull requests merged in the reporting period.", func(pr PullRequest) int { return pr.Additions + pr.Deletions }},
+	}
+
+	for _, gauge := range gauges {
+		name := fmt.Sprintf("%s_%s", OpenMetricsPrefix, gauge.name)
+		fmt.Fprintf(&b, "# HELP %s %s\n", name, gauge.help)
+		fmt.Fprintf(&b, "# TYPE %s gauge\n", name)
+
+		for _, labels := range labelSets {
+			total := 0
+			for _, m := range byLabels[labels] {
+				total += gauge.value(m.PullRequest)
+			}
+			fmt.Fprintf(&b, "%s{%s} %d\n", name, labels, total)
+		}
+	}
+
+	b.WriteString("# EOF")
+
+	return b.String()
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nbio/st"
+	"github.com/rickar/cal/v2"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func Test_sizeBucket(t *testing.T) {
+	st.Assert(t, sizeBucket(PullRequest{}), "XS")
+	st.Assert(t, sizeBucket(PullRequest{Additions: 6, Deletions: 3}), "XS")
+	st.Assert(t, sizeBucket(PullRequest{Additions: 6, Deletions: 4}), "S")
+	st.Assert(t, sizeBucket(PullRequest{Additions: 499}), "M")
+	st.Assert(t, sizeBucket(PullRequest{Additions: 500, Deletions: 499}), "L")
+	st.Assert(t, sizeBucket(PullRequest{Additions: 1000}), "XL")
+}
+
+func Test_escapeLabelValue(t *testing.T) {
+	st.Assert(t, escapeLabelValue(`a\b"c`+"\n"), `a\\b\"c\n`)
+}
+
+func Test_renderOpenMetrics(t *testing.T) {
+	labels := `repo="o/r",team="(none)",size="XS"`
+	observations := []Observation{
+		{Labels: labels, Metrics: PullRequestMetrics{
+			PullRequest:       PullRequest{Additions: 1, Deletions: 2},
+			TimeToFirstReview: NullDuration{Duration: 30 * time.Minute, Valid: true},
+		}},
+		{Labels: labels, Metrics: PullRequestMetrics{
+			PullRequest:       PullRequest{Additions: 3},
+			TimeToFirstReview: NullDuration{Duration: 5 * time.Hour, Valid: true},
+		}},
+		{Labels: labels, Metrics: PullRequestMetrics{}},
+	}
+
+	have := renderOpenMetrics(observations, DurationMetrics)
+
+	st.Assert(t, strings.Contains(have, "# TYPE gh_metrics_time_to_first_review_seconds gaugehistogram\n"), true)
+	st.Assert(t, strings.Contains(have, `gh_metrics_time_to_first_review_seconds_bucket{repo="o/r",team="(none)",size="XS",le="3600"} 1`+"\n"), true)
+	st.Assert(t, strings.Contains(have, `gh_metrics_time_to_first_review_seconds_bucket{repo="o/r",team="(none)",size="XS",le="14400"} 1`+"\n"), true)
+	st.Assert(t, strings.Contains(have, `gh_metrics_time_to_first_review_seconds_bucket{repo="o/r",team="(none)",size="XS",le="28800"} 2`+"\n"), true)
+	st.Assert(t, strings.Contains(have, `gh_metrics_time_to_first_review_seconds_bucket{repo="o/r",team="(none)",size="XS",le="+Inf"} 2`+"\n"), true)
+	st.Assert(t, strings.Contains(have, `gh_metrics_time_to_first_review_seconds_gsum{repo="o/r",team="(none)",size="XS"} 19800`+"\n"), true)
+	st.Assert(t, strings.Contains(have, `gh_metrics_time_to_first_review_seconds_gcount{repo="o/r",team="(none)",size="XS"} 2`+"\n"), true)
+	st.Assert(t, strings.Contains(have, `gh_metrics_feature_lead_time_seconds_gcount{repo="o/r",team="(none)",size="XS"} 0`+"\n"), true)
+	st.Assert(t, strings.Contains(have, "# TYPE gh_metrics_pull_requests gauge\n"), true)
+	st.Assert(t, strings.Contains(have, `gh_metrics_pull_requests{repo="o/r",team="(none)",size="XS"} 3`+"\n"), true)
+	st.Assert(t, strings.Contains(have, `gh_metrics_changed_lines{repo="o/r",team="(none)",size="XS"} 6`+"\n"), true)
+	st.Assert(t, strings.HasSuffix(have, "\n# EOF"), true)
+}
+
+func Test_SearchQuery_WithOpenMetrics(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.github.com/graphql").
+		Post("/").
+		MatchType("json").
+		AddMatcher(gqlSearchQueryMatcher(Owner, Repository, StartDate, EndDate)).
+		Reply(200).
+		BodyString(ResponseJSON)
+
+	ui := &UI{
+		Owner:      Owner,
+		Repository: Repository,
+		StartDate:  StartDate,
+		EndDate:    EndDate,
+		Format:     FormatOpenMetrics,
+		Calendar:   cal.NewBusinessCalendar(),
+	}
+
+	have := ui.PrintMetrics()
+
+	st.Assert(t, strings.Contains(have, `gh_metrics_pull_requests{repo="testOwner/testRepo",team="(none)",size="XS"} 1`+"\n"), true)
+	st.Assert(t, strings.Contains(have, `gh_metrics_pull_requests{repo="testOwner/testRepo",team="(none)",size="S"} 1`+"\n"), true)
+	st.Assert(t, strings.Contains(have, `gh_metrics_changed_lines{repo="testOwner/testRepo",team="(none)",size="S"} 18`+"\n"), true)
+}
@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/text"
+)
+
+// DefaultOutlierMADs is the number of median absolute deviations from the
+// median a duration must be to be an outlier, unless `--outlier-threshold`
+// is given.
+const DefaultOutlierMADs = "3"
+
+// OutlierRule decides which durations of a metric are outliers: either
+// those more than MADs median absolute deviations from the median, or, if
+// Percentile is set, those above that percentile.
+type OutlierRule struct {
+	MADs       float64
+	Percentile float64
+}
+
+// newOutlierRule parses an `--outlier-threshold` value: a number of
+// median absolute deviations, such as "3", or a percentile prefixed with
+// 'p', such as "p95".
+func newOutlierRule(value string) (*OutlierRule, error) {
+	if p, ok := strings.CutPrefix(value, "p"); ok {
+		percentile, err := strconv.ParseFloat(p, 64)
+		if err != nil || percentile <= 0 || percentile >= 100 {
+			return nil, fmt.Errorf("invalid outlier percentile %q, must be between p0 and p100", value)
+		}
+		return &OutlierRule{Percentile: percentile}, nil
+	}
+
+	mads, err := strconv.ParseFloat(value, 64)
+	if err != nil || mads <= 0 {
+		return nil, fmt.Errorf("invalid outlier threshold %q, must be a positive number of median absolute deviations or a percentile such as p95", value)
+	}
+
+	return &OutlierRule{MADs: mads}, nil
+}
+
+// outlierBounds returns the range outside of which durations are outliers.
+// Durations identical to the median are never outliers, so a distribution
+// without any deviation has none.
+func (r OutlierRule) outlierBounds(durations []time.Duration) (time.Duration, time.Duration, bool) {
+	if len(durations) == 0 {
+		return 0, 0, false
+	}
+
+	if r.Percentile > 0 {
+		return 0, percentileDuration(durations, r.Percentile).Duration, true
+	}
+
+	var values, deviations []NullDuration
+	for _, d := range durations {
+		values = append(values, NullDuration{Duration: d, Valid: true})
+	}
+	median := medianDuration(values).Duration
+	for _, d := range durations {
+		deviation := d - median
+		if deviation < 0 {
+			deviation = -deviation
+		}
+		deviations = append(deviations, NullDuration{Duration: deviation, Valid: true})
+	}
+	mad := medianDuration(deviations).Duration
+	if mad == 0 {
+		return 0, 0, false
+	}
+
+	spread := time.Duration(r.MADs * float64(mad))
+	return median - spread, median + spread, true
+}
+
+// markOutliers flags, for every column holding durations, the PRs whose
+// value is an outlier of the distribution across the set.
+func markOutliers(metrics []PullRequestMetrics, rule OutlierRule) {
+	for _, c := range Columns {
+		value := func(m PullRequestMetrics) NullDuration {
+			d, _ := c.Value(m).(NullDuration)
+			return d
+		}
+
+		var durations []time.Duration
+		for _, m := range metrics {
+			if d := value(m); d.Valid {
+				durations = append(durations, d.Duration)
+			}
+		}
+
+		low, high, ok := rule.outlierBounds(durations)
+		if !ok {
+			continue
+		}
+
+		for i := range metrics {
+			d := value(metrics[i])
+			if !d.Valid || (d.Duration >= low && d.Duration <= high) {
+				continue
+			}
+			if metrics[i].Outliers == nil {
+				metrics[i].Outliers = make(map[string]bool)
+			}
+			metrics[i].Outliers[c.Key] = true
+		}
+	}
+}
+
+// onlyOutliers returns the PRs with an outlier in any duration column.
+func onlyOutliers(metrics []PullRequestMetrics) []PullRequestMetrics {
+	var outliers []PullRequestMetrics
+	for _, m := range metrics {
+		if m.IsOutlier() {
+			outliers = append(outliers, m)
+		}
+	}
+
+	return outliers
+}
+
+// highlightOutlier colours a table cell if it holds an outlier. Only the
+// table format is coloured, as escape codes would corrupt other formats.
+func highlightOutlier(c Column, m PullRequestMetrics, cell interface{}, format string) interface{} {
+	if !m.Outliers[c.Key] || format != FormatTable {
+		return cell
+	}
+
+	return text.Colors{text.FgRed, text.Bold}.Sprint(cell)
+}
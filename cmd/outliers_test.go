@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nbio/st"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func Test_newOutlierRule(t *testing.T) {
+	rule, err := newOutlierRule("2.5")
+	st.Assert(t, err, nil)
+	st.Assert(t, *rule, OutlierRule{MADs: 2.5})
+
+	rule, err = newOutlierRule("p95")
+	st.Assert(t, err, nil)
+	st.Assert(t, *rule, OutlierRule{Percentile: 95})
+
+	for _, value := range []string{"0", "-1", "many", "p100", "p"} {
+		_, err = newOutlierRule(value)
+		st.Assert(t, err != nil, true)
+	}
+}
+
+func Test_markOutliers(t *testing.T) {
+	hours := func(values ...int) []PullRequestMetrics {
+		var metrics []PullRequestMetrics
+		for i, h := range values {
+			metrics = append(metrics, PullRequestMetrics{
+				PullRequest:       PullRequest{Number: i + 1},
+				TimeToFirstReview: NullDuration{Duration: time.Duration(h) * time.Hour, Valid: h >= 0},
+			})
+		}
+		return metrics
+	}
+	outliers := func(metrics []PullRequestMetrics) []int {
+		var numbers []int
+		for _, m := range onlyOutliers(metrics) {
+			numbers = append(numbers, m.PullRequest.Number)
+		}
+		return numbers
+	}
+
+	metrics := hours(1, 2, 2, 3, 3, 4, 40, -1)
+	markOutliers(metrics, OutlierRule{MADs: 3})
+	st.Assert(t, outliers(metrics), []int{7})
+	st.Assert(t, metrics[6].Outliers, map[string]bool{"time-to-first-review": true})
+
+	metrics = hours(1, 2, 3, 4, 5, 6, 7, 8, 9, 10)
+	markOutliers(metrics, OutlierRule{Percentile: 80})
+	st.Assert(t, outliers(metrics), []int{9, 10})
+
+	metrics = hours(5, 5, 5, 5)
+	markOutliers(metrics, OutlierRule{MADs: 3})
+	st.Assert(t, outliers(metrics), []int(nil))
+	// Durations outside of the duration metrics are flagged too.
+	metrics = hours(5, 5, 5, 5)
+	for i, h := range []int{1, 2, 2, 30} {
+		metrics[i].TimeToFirstOwnerReview = NullDuration{Duration: time.Duration(h) * time.Hour, Valid: true}
+		metrics[i].TimeToRequestedReview = NullDuration{Duration: time.Duration(h) * time.Hour, Valid: true}
+	}
+	markOutliers(metrics, OutlierRule{MADs: 3})
+	st.Assert(t, metrics[3].Outliers, map[string]bool{"time-to-first-owner-review": true, "time-to-requested-review": true})
+}
+
+func Test_RootCmd_OnlyOutliersJSON(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.github.com/graphql").
+		Post("/").
+		MatchType("json").
+		AddMatcher(gqlSearchQueryMatcher(Owner, "outliersRepo", StartDate, EndDate)).
+		Reply(200).
+		BodyString(strings.Replace(ResponseJSON, `"mergedAt": "2022-03-22T16:22:05Z"`, `"mergedAt": "2022-03-24T16:22:05Z"`, 1))
+
+	actual := execute(t, fmt.Sprintf("--repo=%s/outliersRepo --start=%s --end=%s --outlier-threshold=p50 --only-outliers --columns=number,feature-lead-time --format=json", Owner, StartDate, EndDate))
+
+	st.Assert(t, actual, `[
+  {
+    "number": 5340,
+    "feature-lead-time": 177131,
+    "outlier": true
+  }
+]
+`)
+}
+
+func Test_RootCmd_OutliersWithGroupBy(t *testing.T) {
+	actual := execute(t, "--repo=cli/cli --outliers --group-by=label")
+
+	st.Assert(t, strings.Contains(actual, "--outliers and --only-outliers are not supported with"), true)
+}
+
+func Test_RootCmd_OutlierThresholdWithoutOutliers(t *testing.T) {
+	actual := execute(t, "--repo=cli/cli --outlier-threshold=p95")
+
+	st.Assert(t, strings.Contains(actual, "--outlier-threshold requires --outliers or --only-outliers"), true)
+}
+
+func Test_RootCmd_OutliersFollowedByArgument(t *testing.T) {
+	actual := execute(t, "--outliers --outlier-threshold p95 --repo=cli/cli --group-by=label")
+
+	st.Assert(t, strings.Contains(actual, "unknown command"), false)
+	st.Assert(t, strings.Contains(actual, "--outliers and --only-outliers are not supported with"), true)
+}
+
+func Test_highlightOutlier(t *testing.T) {
+	c, _ := column("feature-lead-time")
+	m := PullRequestMetrics{Outliers: map[string]bool{"feature-lead-time": true}}
+
+	st.Assert(t, highlightOutlier(c, m, "49:12", FormatTable), "\x1b[31;1m49:12\x1b[0m")
+	st.Assert(t, highlightOutlier(c, m, "49:12", FormatCSV), "49:12")
+	st.Assert(t, highlightOutlier(c, PullRequestMetrics{}, "1:12", FormatTable), "1:12")
+}
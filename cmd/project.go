@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// Group pull requests by the value of a field of the selected project,
+	// e.g. 'project-field:Iteration'.
+	GroupByProjectFieldPrefix = "project-field:"
+	// Group name used for pull requests without a value for the field.
+	NoProjectFieldGroup = "(none)"
+)
+
+// GHProject represents a GitHub project (v2) owned by an organization.
+type GHProject struct {
+	Org    string
+	Number int
+}
+
+// newGHProject returns a GHProject configured via the 'ORG/NUMBER' string
+// it's passed.
+func newGHProject(name string) (*GHProject, error) {
+	org, number, ok := strings.Cut(name, "/")
+	n, err := strconv.Atoi(number)
+	if !ok || org == "" || err != nil || n <= 0 {
+		return nil, errors.New("invalid project, must be ORG/NUMBER")
+	}
+
+	return &GHProject{Org: org, Number: n}, nil
+}
+
+// String returns the project in 'ORG/NUMBER' format.
+func (p GHProject) String() string {
+	return fmt.Sprintf("%s/%d", p.Org, p.Number)
+}
+
+// ProjectFieldValue is the value of a field of a project item. Iterations
+// have the date they start on, so they can be ordered as on the board.
+type ProjectFieldValue struct {
+	Value     string
+	StartDate string
+}
+
+// projectField returns the field name grouped by, if grouping by a
+// project field.
+func projectField(groupBy string) (string, bool) {
+	field, ok := strings.CutPrefix(groupBy, GroupByProjectFieldPrefix)
+	return field, ok && field != ""
+}
+
+// projectFieldValues returns the values of the fields of a project item,
+// keyed by lower case field name. Only iteration, single select and text
+// fields are supported.
+func projectFieldValues(nodes ProjectFieldValueNodes) map[string]ProjectFieldValue {
+	values := make(map[string]ProjectFieldValue)
+	for _, node := range nodes {
+		switch node.Typename {
+		case "ProjectV2ItemFieldIterationValue":
+			values[strings.ToLower(node.Iteration.Field.Common.Name)] = ProjectFieldValue{Value: node.Iteration.Title, StartDate: node.Iteration.StartDate}
+		case "ProjectV2ItemFieldSingleSelectValue":
+			values[strings.ToLower(node.SingleSelect.Field.Common.Name)] = ProjectFieldValue{Value: node.SingleSelect.Name}
+		case "ProjectV2ItemFieldTextValue":
+			values[strings.ToLower(node.Text.Field.Common.Name)] = ProjectFieldValue{Value: node.Text.Text}
+		}
+	}
+
+	return values
+}
+
+// projectFieldValue returns the value of a field of the item of a PR in a
+// given project, which is empty if the PR isn't an item of the project or
+// the field isn't set. An error is returned if the value can't be
+// determined because more projects or field values were involved than
+// fetched.
+func projectFieldValue(pr PullRequest, project GHProject, field string) (ProjectFieldValue, error) {
+	for _, item := range pr.ProjectItems.Nodes {
+		if item.Project.Number != project.Number || !strings.EqualFold(item.Project.Owner.Organization.Login, project.Org) {
+			continue
+		}
+
+		value, ok := projectFieldValues(item.FieldValues.Nodes)[strings.ToLower(field)]
+		if !ok && item.FieldValues.TotalCount > len(item.FieldValues.Nodes) {
+			return ProjectFieldValue{}, fmt.Errorf("#%d has %d values in project %s, %s may be among those not fetched", pr.Number, item.FieldValues.TotalCount, project, field)
+		}
+
+		return value, nil
+	}
+
+	if pr.ProjectItems.TotalCount > len(pr.ProjectItems.Nodes) {
+		return ProjectFieldValue{}, fmt.Errorf("#%d is in %d projects, %s may be among those not fetched", pr.Number, pr.ProjectItems.TotalCount, project)
+	}
+
+	return ProjectFieldValue{}, nil
+}
+
+// projectFieldGroups groups PRs by the value of a field of their item in
+// the selected project.
+func (ui *UI) projectFieldGroups(metrics []PullRequestMetrics, field string) ([]MetricsGroup, error) {
+	values := make(map[int]ProjectFieldValue, len(metrics))
+	for _, m := range metrics {
+		value, err := projectFieldValue(m.PullRequest, *ui.Project, field)
+		if err != nil {
+			return nil, err
+		}
+		values[m.PullRequest.Number] = value
+	}
+
+	groups := groupMetrics(metrics, func(pr PullRequest) []string {
+		if value := values[pr.Number]; value.Value != "" {
+			return []string{value.Value}
+		}
+
+		return []string{NoProjectFieldGroup}
+	})
+	sortProjectFieldGroups(values, groups)
+
+	return groups, nil
+}
+
+// sortProjectFieldGroups orders iteration groups by start date, as on the
+// board, ahead of groups without one, which keep their order by name.
+func sortProjectFieldGroups(values map[int]ProjectFieldValue, groups []MetricsGroup) {
+	startDates := make(map[string]string)
+	for _, value := range values {
+		if value.StartDate != "" {
+			startDates[value.Value] = value.StartDate
+		}
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		a, b := startDates[groups[i].Name], startDates[groups[j].Name]
+		if (a == "") != (b == "") {
+			return a != ""
+		}
+		return a < b
+	})
+}
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nbio/st"
+	"github.com/rickar/cal/v2"
+	"gopkg.in/h2non/gock.v1"
+)
+
+// ProjectItemsJSON puts PR 5339 in Sprint 10 of the Iteration field of
+// project gotham/7, along with an item of another project that is ignored.
+const ProjectItemsJSON = `"number": 5339,
+                    "projectItems": {
+                        "totalCount": 2,
+                        "nodes": [
+                            {
+                                "project": {"number": 8, "owner": {"login": "gotham"}},
+                                "fieldValues": {"totalCount": 1, "nodes": [
+                                    {"__typename": "ProjectV2ItemFieldIterationValue", "title": "Sprint 1", "startDate": "2022-01-03", "field": {"name": "Iteration"}}
+                                ]}
+                            },
+                            {
+                                "project": {"number": 7, "owner": {"login": "gotham"}},
+                                "fieldValues": {"totalCount": 2, "nodes": [
+                                    {"__typename": "ProjectV2ItemFieldIterationValue", "title": "Sprint 10", "startDate": "2022-03-14", "field": {"name": "Iteration"}},
+                                    {"__typename": "ProjectV2ItemFieldSingleSelectValue", "name": "Gadgets", "field": {"name": "Team"}}
+                                ]}
+                            }
+                        ]
+                    },`
+
+// OtherProjectItemsJSON puts PR 5340 in Sprint 9 of the Iteration field of
+// project gotham/7.
+const OtherProjectItemsJSON = `"number": 5340,
+                    "projectItems": {
+                        "totalCount": 1,
+                        "nodes": [
+                            {
+                                "project": {"number": 7, "owner": {"login": "gotham"}},
+                                "fieldValues": {"totalCount": 1, "nodes": [
+                                    {"__typename": "ProjectV2ItemFieldIterationValue", "title": "Sprint 9", "startDate": "2022-03-07", "field": {"name": "Iteration"}}
+                                ]}
+                            }
+                        ]
+                    },`
+
+func Test_newGHProject(t *testing.T) {
+	project, err := newGHProject("gotham/7")
+	st.Assert(t, err, nil)
+	st.Assert(t, *project, GHProject{Org: "gotham", Number: 7})
+	st.Assert(t, project.String(), "gotham/7")
+
+	for _, name := range []string{"gotham", "gotham/", "/7", "gotham/seven", "gotham/0"} {
+		_, err = newGHProject(name)
+		st.Assert(t, err.Error(), "invalid project, must be ORG/NUMBER")
+	}
+}
+
+func Test_validateGroupBy_ProjectField(t *testing.T) {
+	st.Assert(t, validateGroupBy("project-field:Iteration"), nil)
+	st.Assert(t, validateGroupBy("project-field:").Error(), `invalid group-by value "project-field:", must be one of [label team] or project-field:FIELD`)
+}
+
+func Test_SearchQuery_GroupByProjectField(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.github.com/graphql").
+		Post("/").
+		MatchType("json").
+		AddMatcher(gqlSearchQueryMatcher(Owner, "projectFieldRepo", StartDate, EndDate)).
+		Reply(200).
+		BodyString(strings.NewReplacer(`"number": 5339,`, ProjectItemsJSON, `"number": 5340,`, OtherProjectItemsJSON).Replace(ResponseJSON))
+
+	ui := &UI{
+		Owner:      Owner,
+		Repository: "projectFieldRepo",
+		StartDate:  StartDate,
+		EndDate:    EndDate,
+		CSVFormat:  true,
+		GroupBy:    "project-field:Iteration",
+		Project:    &GHProject{Org: "gotham", Number: 7},
+		Calendar:   cal.NewBusinessCalendar(),
+	}
+
+	have := ui.PrintMetrics()
+
+	st.Assert(t, have, "Iteration,PRs,Additions,Deletions,Time to First Review,Time to First Response,Feature Lead Time,First to Last Review,First Approval to Merge\n"+
+		"Sprint 9,1,12,6,38:13,38:12,01:12,08:00,06:51\n"+
+		"Sprint 10,1,6,3,38:13,38:12,01:12,08:00,06:51")
+}
+
+func Test_projectFieldValue(t *testing.T) {
+	project := GHProject{Org: "gotham", Number: 7}
+	pr := PullRequest{Number: 5339}
+	pr.ProjectItems.TotalCount = 1
+	pr.ProjectItems.Nodes = make(ProjectItemNodes, 1)
+	item := &pr.ProjectItems.Nodes[0]
+	item.Project.Number = 7
+	item.Project.Owner.Organization.Login = "Gotham"
+	item.FieldValues.TotalCount = 1
+	item.FieldValues.Nodes = make(ProjectFieldValueNodes, 1)
+	item.FieldValues.Nodes[0].Typename = "ProjectV2ItemFieldSingleSelectValue"
+	item.FieldValues.Nodes[0].SingleSelect.Name = "Gadgets"
+	item.FieldValues.Nodes[0].SingleSelect.Field.Common.Name = "Team"
+
+	value, err := projectFieldValue(pr, project, "team")
+	st.Assert(t, err, nil)
+	st.Assert(t, value, ProjectFieldValue{Value: "Gadgets"})
+
+	value, err = projectFieldValue(pr, project, "Iteration")
+	st.Assert(t, err, nil)
+	st.Assert(t, value, ProjectFieldValue{})
+
+	item.FieldValues.TotalCount = 21
+	_, err = projectFieldValue(pr, project, "Iteration")
+	st.Assert(t, err.Error(), "#5339 has 21 values in project gotham/7, Iteration may be among those not fetched")
+
+	value, err = projectFieldValue(pr, GHProject{Org: "gotham", Number: 8}, "Iteration")
+	st.Assert(t, err, nil)
+	st.Assert(t, value, ProjectFieldValue{})
+
+	pr.ProjectItems.TotalCount = 11
+	_, err = projectFieldValue(pr, GHProject{Org: "gotham", Number: 8}, "Iteration")
+	st.Assert(t, err.Error(), "#5339 is in 11 projects, gotham/8 may be among those not fetched")
+}
+
+func Test_RootCmd_GroupByProjectFieldWithoutProject(t *testing.T) {
+	actual := execute(t, "--repo=cli/cli --group-by=project-field:Iteration")
+
+	st.Assert(t, strings.Contains(actual, "--project is required to group by a project field"), true)
+}
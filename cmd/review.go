@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+const (
+	// DefaultRubberStampLines is the minimum number of changed lines of a
+	// PR approved without review comments for it to be flagged.
+	DefaultRubberStampLines = 500
+
+	RubberStampNoComments = "approved with no review comments"
+	RubberStampSelfMerged = "self-merged with no approval"
+)
+
+// RubberStamp is a PR that was merged with little or no scrutiny.
+type RubberStamp struct {
+	PullRequest PullRequest
+	Reason      string
+}
+
+// changedLines returns the number of lines added and deleted by a PR.
+func changedLines(pr PullRequest) int {
+	return pr.Additions + pr.Deletions
+}
+
+// reviewComments returns the number of review comments left on a PR by
+// anyone other than the author.
+func reviewComments(pr PullRequest) int {
+	comments := 0
+	for _, review := range pr.Reviews.Nodes {
+		if review.Author.Login != pr.Author.Login {
+			comments += review.Comments.TotalCount
+		}
+	}
+
+	return comments
+}
+
+// reviewDepth returns the number of review comments per 100 changed lines
+// of a PR, or an absent number if no lines were changed.
+func reviewDepth(pr PullRequest) NullFloat {
+	lines := changedLines(pr)
+	if lines == 0 {
+		return NullFloat{}
+	}
+
+	return NullFloat{Float64: float64(reviewComments(pr)) * 100 / float64(lines), Valid: true}
+}
+
+// approved returns true if a PR was approved by anyone other than the
+// author.
+func approved(pr PullRequest) bool {
+	for _, review := range pr.Reviews.Nodes {
+		if review.Author.Login != pr.Author.Login && review.State == ReviewApprovedState {
+			return true
+		}
+	}
+
+	return false
+}
+
+// rubberStamps returns the PRs merged by their author without an approval
+// from anyone else, and the PRs of at least minLines changed lines that
+// were approved without any review comments.
+func rubberStamps(pullRequests []PullRequest, minLines int) []RubberStamp {
+	var stamps []RubberStamp
+	for _, pr := range pullRequests {
+		switch {
+		case !approved(pr):
+			if pr.MergedBy.Login != "" && pr.MergedBy.Login == pr.Author.Login {
+				stamps = append(stamps, RubberStamp{PullRequest: pr, Reason: RubberStampSelfMerged})
+			}
+		case reviewComments(pr) == 0 && changedLines(pr) >= minLines:
+			stamps = append(stamps, RubberStamp{PullRequest: pr, Reason: RubberStampNoComments})
+		}
+	}
+
+	return stamps
+}
+
+// rubberStampsTable returns a table containing a row per flagged PR,
+// formatted for a given output format.
+func (ui *UI) rubberStampsTable(stamps []RubberStamp, format string) table.Writer {
+	t := table.NewWriter()
+	t.SetStyle(table.StyleLight)
+
+	t.AppendHeader(table.Row{
+		"PR",
+		"Author",
+		"Merged By",
+		"Changed Lines",
+		"Review Comments",
+		"Reason",
+	})
+
+	for _, s := range stamps {
+		mergedBy := s.PullRequest.MergedBy.Login
+		if mergedBy == "" {
+			mergedBy = DefaultEmptyCell
+		}
+
+		t.AppendRow(table.Row{
+			ui.formatNumber(s.PullRequest, format),
+			s.PullRequest.Author.Login,
+			mergedBy,
+			changedLines(s.PullRequest),
+			reviewComments(s.PullRequest),
+			s.Reason,
+		})
+	}
+
+	return t
+}
+
+// formatNullFloat formats a number with two decimals, or DefaultEmptyCell
+// if it is absent.
+func formatNullFloat(f NullFloat) string {
+	if !f.Valid {
+		return DefaultEmptyCell
+	}
+
+	return fmt.Sprintf("%.2f", f.Float64)
+}
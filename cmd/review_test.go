@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/nbio/st"
+	"github.com/rickar/cal/v2"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func Test_reviewDepth(t *testing.T) {
+	pr := PullRequest{
+		Author:    Author{Login: "Batman"},
+		Additions: 150,
+		Deletions: 50,
+		Reviews: Reviews{
+			Nodes: ReviewNodes{
+				{Author: Author{Login: "Joker"}, State: "COMMENTED", Comments: ReviewComments{TotalCount: 3}},
+				{Author: Author{Login: "Batman"}, State: "COMMENTED", Comments: ReviewComments{TotalCount: 5}},
+				{Author: Author{Login: "Robin"}, State: "APPROVED", Comments: ReviewComments{TotalCount: 1}},
+			},
+		},
+	}
+
+	st.Assert(t, reviewComments(pr), 4)
+	st.Assert(t, reviewDepth(pr), NullFloat{Float64: 2, Valid: true})
+	st.Assert(t, reviewDepth(PullRequest{}), NullFloat{})
+}
+
+func Test_rubberStamps(t *testing.T) {
+	pullRequests := []PullRequest{
+		{
+			Number:    1,
+			Author:    Author{Login: "Batman"},
+			Additions: 2000,
+			Reviews: Reviews{Nodes: ReviewNodes{
+				{Author: Author{Login: "Joker"}, State: "APPROVED"},
+			}},
+		},
+		{
+			Number:    2,
+			Author:    Author{Login: "Batman"},
+			Additions: 2000,
+			Reviews: Reviews{Nodes: ReviewNodes{
+				{Author: Author{Login: "Joker"}, State: "APPROVED", Comments: ReviewComments{TotalCount: 1}},
+			}},
+		},
+		{
+			Number:    3,
+			Author:    Author{Login: "Batman"},
+			Additions: 10,
+			Reviews: Reviews{Nodes: ReviewNodes{
+				{Author: Author{Login: "Joker"}, State: "APPROVED"},
+			}},
+		},
+		{
+			Number:    4,
+			Author:    Author{Login: "Batman"},
+			MergedBy:  Author{Login: "Batman"},
+			Additions: 10,
+			Reviews: Reviews{Nodes: ReviewNodes{
+				{Author: Author{Login: "Batman"}, State: "APPROVED"},
+			}},
+		},
+		{
+			Number:    5,
+			Author:    Author{Login: "Batman"},
+			MergedBy:  Author{Login: "Robin"},
+			Additions: 10,
+		},
+	}
+
+	var flagged []string
+	for _, s := range rubberStamps(pullRequests, DefaultRubberStampLines) {
+		flagged = append(flagged, fmt.Sprintf("%d %s", s.PullRequest.Number, s.Reason))
+	}
+
+	st.Assert(t, flagged, []string{
+		"1 approved with no review comments",
+		"4 self-merged with no approval",
+	})
+}
+
+func Test_SearchQuery_ReviewDepthColumn(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.github.com/graphql").
+		Post("/").
+		MatchType("json").
+		AddMatcher(gqlSearchQueryMatcher(Owner, Repository, StartDate, EndDate)).
+		Reply(200).
+		BodyString(ResponseJSON)
+
+	ui := &UI{
+		Owner:      Owner,
+		Repository: Repository,
+		StartDate:  StartDate,
+		EndDate:    EndDate,
+		CSVFormat:  true,
+		Columns:    []string{"number", "review-comments", "review-depth"},
+		Sort:       "-review-depth",
+		Calendar:   cal.NewBusinessCalendar(),
+	}
+
+	have := ui.PrintMetrics()
+
+	st.Assert(t, have, "PR,Review Comments,Review Comments per 100 Lines\n5339,2,22.22\n5340,0,0.00")
+}
+
+func Test_SearchQuery_RubberStamps(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.github.com/graphql").
+		Post("/").
+		MatchType("json").
+		AddMatcher(gqlSearchQueryMatcher(Owner, Repository, StartDate, EndDate)).
+		Reply(200).
+		BodyString(ResponseJSON)
+
+	ui := &UI{
+		Owner:            Owner,
+		Repository:       Repository,
+		StartDate:        StartDate,
+		EndDate:          EndDate,
+		CSVFormat:        true,
+		RubberStamps:     true,
+		RubberStampLines: 10,
+		Calendar:         cal.NewBusinessCalendar(),
+	}
+
+	have := ui.PrintMetrics()
+
+	st.Assert(t, strings.Contains(have, "PR,Author,Merged By,Changed Lines,Review Comments,Reason"), true)
+	st.Assert(t, strings.Contains(have, "5340,Batman,Joker,18,0,approved with no review comments"), true)
+	st.Assert(t, strings.Contains(have, "5339"), false)
+}
+
+func Test_RootCmd_RubberStampsWithGroupBy(t *testing.T) {
+	actual := execute(t, "--repo=cli/cli --flag-rubber-stamps --group-by=label")
+
+	st.Assert(t, strings.Contains(actual, "--flag-rubber-stamps can't be combined with --group-by or --cross-team-reviews"), true)
+}
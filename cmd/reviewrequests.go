@@ -0,0 +1,263 @@
+package cmd
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+// ReviewRequestColumns lists the columns that require the review requests
+// of PRs to be matched to their reviews.
+var ReviewRequestColumns = []string{"time-to-requested-review", "unanswered-review-requests"}
+
+// ReviewRequest is a request for a user or a team to review a PR, and the
+// review that answered it, if any. A request removed before it was
+// answered is withdrawn.
+type ReviewRequest struct {
+	Reviewer    string
+	Team        bool
+	RequestedAt time.Time
+	AnsweredBy  string
+	AnsweredAt  time.Time
+	Withdrawn   bool
+}
+
+// Answered returns true if the requested reviewer, or a member of the
+// requested team, reviewed the PR.
+func (r ReviewRequest) Answered() bool {
+	return !r.AnsweredAt.IsZero()
+}
+
+// Unanswered returns true if the request is still waiting for a review.
+func (r ReviewRequest) Unanswered() bool {
+	return !r.Answered() && !r.Withdrawn
+}
+
+// requestedReviewer returns the login of the user, or the org/slug of the
+// team, a review request event is about.
+func requestedReviewer(event ReviewRequestEvent) (string, bool) {
+	if slug := event.RequestedReviewer.Team.CombinedSlug; slug != "" {
+		return slug, true
+	}
+
+	return event.RequestedReviewer.User.Login, false
+}
+
+// isRequestedReviewer returns true if a user is the reviewer of a request,
+// or a member of the requested team.
+func (ui *UI) isRequestedReviewer(login string, request ReviewRequest) bool {
+	if request.Team {
+		team, err := newGHTeam(request.Reviewer)
+		return err == nil && ui.isTeamMember(*team, login)
+	}
+
+	return strings.EqualFold(request.Reviewer, login)
+}
+
+// answeringReviews returns the reviews of a PR that may answer review
+// requests, including those since dismissed.
+func answeringReviews(pr PullRequest) ReviewNodes {
+	reviews := make(ReviewNodes, 0, len(pr.Reviews.Nodes)+len(pr.DismissedReviews.Nodes))
+	reviews = append(reviews, pr.Reviews.Nodes...)
+
+	return append(reviews, pr.DismissedReviews.Nodes...)
+}
+
+// reviewRequests returns the review requests of a PR in the order they
+// were made, each answered by the first review of the requested reviewer
+// that followed it, even if it was dismissed since. Requesting a reviewer
+// again while a request is pending doesn't start a new one.
+func (ui *UI) reviewRequests(pr PullRequest) []ReviewRequest {
+	type event struct {
+		at      time.Time
+		kind    string
+		login   string
+		request ReviewRequestEvent
+	}
+
+	var events []event
+	for _, node := range pr.ReviewRequestEvents.Nodes {
+		request := node.ReviewRequestedEvent
+		if node.Typename == "ReviewRequestRemovedEvent" {
+			request = node.ReviewRequestRemovedEvent
+		}
+		at, err := time.Parse(time.RFC3339, request.CreatedAt)
+		if err != nil {
+			continue
+		}
+		events = append(events, event{at: at, kind: node.Typename, request: request})
+	}
+	for _, review := range answeringReviews(pr) {
+		at, err := time.Parse(time.RFC3339, review.CreatedAt)
+		if err != nil || review.Author.Login == pr.Author.Login {
+			continue
+		}
+		events = append(events, event{at: at, kind: "Review", login: review.Author.Login})
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].at.Before(events[j].at)
+	})
+
+	var requests []ReviewRequest
+	pending := make(map[string]int)
+	for _, e := range events {
+		switch e.kind {
+		case "ReviewRequestedEvent":
+			reviewer, team := requestedReviewer(e.request)
+			if _, ok := pending[reviewer]; reviewer == "" || ok {
+				continue
+			}
+			pending[reviewer] = len(requests)
+			requests = append(requests, ReviewRequest{Reviewer: reviewer, Team: team, RequestedAt: e.at})
+		case "ReviewRequestRemovedEvent":
+			reviewer, _ := requestedReviewer(e.request)
+			if i, ok := pending[reviewer]; ok {
+				requests[i].Withdrawn = true
+				delete(pending, reviewer)
+			}
+		case "Review":
+			for reviewer, i := range pending {
+				if ui.isRequestedReviewer(e.login, requests[i]) {
+					requests[i].AnsweredBy = e.login
+					requests[i].AnsweredAt = e.at
+					delete(pending, reviewer)
+				}
+			}
+		}
+	}
+
+	return requests
+}
+
+// requestLatency returns the time between a review request and the review
+// answering it, with respect to the configured calendar.
+func (ui *UI) requestLatency(request ReviewRequest) NullDuration {
+	if !request.Answered() {
+		return NullDuration{}
+	}
+
+	return NullDuration{Duration: ui.subtractTime(request.AnsweredAt, request.RequestedAt), Valid: true}
+}
+
+// timeToRequestedReview returns the median time requested reviewers of a
+// PR took to answer their requests.
+func (ui *UI) timeToRequestedReview(requests []ReviewRequest) NullDuration {
+	var latencies []NullDuration
+	for _, request := range requests {
+		latencies = append(latencies, ui.requestLatency(request))
+	}
+
+	return medianDuration(latencies)
+}
+
+// unansweredReviewers returns the reviewers of the requests still waiting
+// for a review.
+func unansweredReviewers(requests []ReviewRequest) []string {
+	var reviewers []string
+	for _, request := range requests {
+		if request.Unanswered() {
+			reviewers = append(reviewers, request.Reviewer)
+		}
+	}
+
+	return reviewers
+}
+
+// ReviewerResponse holds how a requested reviewer, user or team, answered
+// the requests to review PRs.
+type ReviewerResponse struct {
+	Reviewer   string
+	Requests   int
+	Answered   int
+	Unanswered int
+	Withdrawn  int
+	Median     NullDuration
+	P90        NullDuration
+}
+
+// reviewerResponses returns the response time of each requested reviewer.
+// Reviewers with the most unanswered requests are listed first, then those
+// taking the longest to answer.
+func (ui *UI) reviewerResponses(metrics []PullRequestMetrics) []ReviewerResponse {
+	byReviewer := make(map[string]*ReviewerResponse)
+	durations := make(map[string][]time.Duration)
+	for _, m := range metrics {
+		for _, request := range m.ReviewRequests {
+			response, ok := byReviewer[request.Reviewer]
+			if !ok {
+				response = &ReviewerResponse{Reviewer: request.Reviewer}
+				byReviewer[request.Reviewer] = response
+			}
+			response.Requests++
+
+			switch {
+			case request.Answered():
+				response.Answered++
+				durations[request.Reviewer] = append(durations[request.Reviewer], ui.requestLatency(request).Duration)
+			case request.Withdrawn:
+				response.Withdrawn++
+			default:
+				response.Unanswered++
+			}
+		}
+	}
+
+	responses := make([]ReviewerResponse, 0, len(byReviewer))
+	for reviewer, response := range byReviewer {
+		var nullDurations []NullDuration
+		for _, d := range durations[reviewer] {
+			nullDurations = append(nullDurations, NullDuration{Duration: d, Valid: true})
+		}
+		response.Median = medianDuration(nullDurations)
+		response.P90 = percentileDuration(durations[reviewer], 90)
+		responses = append(responses, *response)
+	}
+	sort.Slice(responses, func(i, j int) bool {
+		a, b := responses[i], responses[j]
+		if a.Unanswered != b.Unanswered {
+			return a.Unanswered > b.Unanswered
+		}
+		if a.Median.Valid != b.Median.Valid {
+			return a.Median.Valid
+		}
+		if a.Median.Duration != b.Median.Duration {
+			return a.Median.Duration > b.Median.Duration
+		}
+		return a.Reviewer < b.Reviewer
+	})
+
+	return responses
+}
+
+// reviewerResponsesTable returns a table containing a row per requested
+// reviewer, formatted for a given output format.
+func (ui *UI) reviewerResponsesTable(responses []ReviewerResponse, format string) table.Writer {
+	t := table.NewWriter()
+	t.SetStyle(table.StyleLight)
+
+	t.AppendHeader(table.Row{
+		"Reviewer",
+		"Requests",
+		"Answered",
+		"Unanswered",
+		"Withdrawn",
+		"Median Response Time",
+		"90th Percentile",
+	})
+
+	for _, r := range responses {
+		t.AppendRow(table.Row{
+			r.Reviewer,
+			r.Requests,
+			r.Answered,
+			r.Unanswered,
+			r.Withdrawn,
+			ui.formatNullDurationIn(r.Median, format),
+			ui.formatNullDurationIn(r.P90, format),
+		})
+	}
+
+	return t
+}
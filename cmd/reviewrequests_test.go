@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nbio/st"
+	"github.com/rickar/cal/v2"
+	"gopkg.in/h2non/gock.v1"
+)
+
+// ReviewRequestEventsJSON requests reviews of PR 5339 from Joker, who
+// answers, from the gotham/heroes team, which doesn't, and from Alfred,
+// whose request is removed.
+const ReviewRequestEventsJSON = `"reviewRequestEvents": {
+                        "nodes": [
+                            {"__typename": "ReviewRequestedEvent", "createdAt": "2022-03-21T15:11:10Z", "requestedReviewer": {"login": "Joker"}},
+                            {"__typename": "ReviewRequestedEvent", "createdAt": "2022-03-21T15:11:10Z", "requestedReviewer": {"combinedSlug": "gotham/heroes"}},
+                            {"__typename": "ReviewRequestedEvent", "createdAt": "2022-03-21T15:11:10Z", "requestedReviewer": {"login": "Alfred"}},
+                            {"__typename": "ReviewRequestRemovedEvent", "createdAt": "2022-03-21T15:30:00Z", "requestedReviewer": {"login": "Alfred"}}
+                        ]
+                    },
+                    "reviews": {`
+
+func reviewRequestEvent(typename, at, login, team string) ReviewRequestNodes {
+	var event ReviewRequestEvent
+	event.CreatedAt = at
+	event.RequestedReviewer.User.Login = login
+	event.RequestedReviewer.Team.CombinedSlug = team
+
+	nodes := make(ReviewRequestNodes, 1)
+	nodes[0].Typename = typename
+	if typename == "ReviewRequestRemovedEvent" {
+		nodes[0].ReviewRequestRemovedEvent = event
+	} else {
+		nodes[0].ReviewRequestedEvent = event
+	}
+
+	return nodes
+}
+
+func Test_reviewRequests(t *testing.T) {
+	var events ReviewRequestNodes
+	events = append(events, reviewRequestEvent("ReviewRequestedEvent", "2022-03-21T10:00:00Z", "Joker", "")...)
+	events = append(events, reviewRequestEvent("ReviewRequestedEvent", "2022-03-21T10:00:00Z", "", "gotham/heroes")...)
+	events = append(events, reviewRequestEvent("ReviewRequestedEvent", "2022-03-21T10:00:00Z", "Alfred", "")...)
+	events = append(events, reviewRequestEvent("ReviewRequestRemovedEvent", "2022-03-21T11:00:00Z", "Alfred", "")...)
+	events = append(events, reviewRequestEvent("ReviewRequestedEvent", "2022-03-21T13:00:00Z", "Joker", "")...)
+	events = append(events, reviewRequestEvent("ReviewRequestedEvent", "2022-03-21T13:00:00Z", "", "gotham/villains")...)
+
+	pr := PullRequest{
+		Author:              Author{Login: "Batman"},
+		ReviewRequestEvents: ReviewRequestEvents{Nodes: events},
+		Reviews: Reviews{Nodes: ReviewNodes{
+			{Author: Author{Login: "Joker"}, CreatedAt: "2022-03-21T12:00:00Z", State: "COMMENTED"},
+			{Author: Author{Login: "Batman"}, CreatedAt: "2022-03-21T12:30:00Z", State: "COMMENTED"},
+		}},
+		DismissedReviews: Reviews{Nodes: ReviewNodes{
+			{Author: Author{Login: "Joker"}, CreatedAt: "2022-03-21T14:00:00Z", State: "DISMISSED"},
+		}},
+	}
+
+	ui := newTeamsUI()
+	ui.Calendar = newCalendar(false)
+
+	var have []string
+	for _, r := range ui.reviewRequests(pr) {
+		have = append(have, fmt.Sprintf("%s %t %s %t %s", r.Reviewer, r.Team, r.AnsweredBy, r.Withdrawn, ui.formatNullDuration(ui.requestLatency(r))))
+	}
+
+	st.Assert(t, have, []string{
+		"Joker false Joker false 2h0m",
+		"gotham/heroes true  false --",
+		"Alfred false  true --",
+		"Joker false Joker false 1h0m",
+		"gotham/villains true Joker false 1h0m",
+	})
+	st.Assert(t, unansweredReviewers(ui.reviewRequests(pr)), []string{"gotham/heroes"})
+}
+
+func Test_reviewerResponses(t *testing.T) {
+	requestedAt := time.Date(2022, 3, 21, 10, 0, 0, 0, time.UTC)
+	metrics := []PullRequestMetrics{
+		{ReviewRequests: []ReviewRequest{
+			{Reviewer: "Joker", RequestedAt: requestedAt, AnsweredBy: "Joker", AnsweredAt: requestedAt.Add(time.Hour)},
+			{Reviewer: "gotham/heroes", Team: true, RequestedAt: requestedAt},
+		}},
+		{ReviewRequests: []ReviewRequest{
+			{Reviewer: "Joker", RequestedAt: requestedAt, AnsweredBy: "Joker", AnsweredAt: requestedAt.Add(3 * time.Hour)},
+			{Reviewer: "Alfred", RequestedAt: requestedAt, Withdrawn: true},
+		}},
+	}
+
+	ui := &UI{Calendar: cal.NewBusinessCalendar()}
+
+	st.Assert(t, ui.reviewerResponses(metrics), []ReviewerResponse{
+		{Reviewer: "gotham/heroes", Requests: 1, Unanswered: 1},
+		{Reviewer: "Joker", Requests: 2, Answered: 2, Median: NullDuration{Duration: 2 * time.Hour, Valid: true}, P90: NullDuration{Duration: 3 * time.Hour, Valid: true}},
+		{Reviewer: "Alfred", Requests: 1, Withdrawn: 1},
+	})
+}
+
+func Test_SearchQuery_ReviewRequests(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.github.com/graphql").
+		Post("/").
+		MatchType("json").
+		AddMatcher(gqlSearchQueryMatcher(Owner, "reviewRequestsRepo", StartDate, EndDate)).
+		Reply(200).
+		BodyString(strings.Replace(ResponseJSON, `"reviews": {`, ReviewRequestEventsJSON, 1))
+
+	ui := newTeamsUI()
+	ui.Teams = nil
+	ui.Owner = Owner
+	ui.Repository = "reviewRequestsRepo"
+	ui.StartDate = StartDate
+	ui.EndDate = EndDate
+	ui.CSVFormat = true
+	ui.Calendar = cal.NewBusinessCalendar()
+	ui.Columns = []string{"number", "time-to-requested-review", "unanswered-review-requests"}
+	ui.ReviewRequests = true
+
+	have := ui.PrintMetrics()
+
+	st.Assert(t, have, "PR,Time to Requested Review,Unanswered Review Requests\n5339,00:02,gotham/heroes\n5340,--,--")
+}
+
+func Test_RootCmd_ReviewRequests(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.github.com/graphql").
+		Post("/").
+		MatchType("json").
+		AddMatcher(gqlSearchQueryMatcher(Owner, "reviewRequestsRepo", StartDate, EndDate)).
+		Reply(200).
+		BodyString(strings.Replace(ResponseJSON, `"reviews": {`, ReviewRequestEventsJSON, 1))
+
+	gock.New("https://api.github.com/graphql").
+		Post("/").
+		MatchType("json").
+		AddMatcher(gqlTeamMembersQueryMatcher("gotham", "heroes")).
+		Reply(200).
+		BodyString(TeamMembersJSON)
+
+	actual := execute(t, fmt.Sprintf("--repo=%s/reviewRequestsRepo --start=%s --end=%s --review-requests --format=csv", Owner, StartDate, EndDate))
+
+	st.Assert(t, actual, "Reviewer,Requests,Answered,Unanswered,Withdrawn,Median Response Time,90th Percentile\n"+
+		"gotham/heroes,1,0,1,0,--,--\n"+
+		"Joker,1,1,0,0,00:02,00:02\n"+
+		"Alfred,1,0,0,1,--,--\n")
+}
+
+func Test_RootCmd_ReviewRequestsWithOwnerLatency(t *testing.T) {
+	actual := execute(t, "--repo=cli/cli --review-requests --owner-latency")
+
+	st.Assert(t, strings.Contains(actual, "--review-requests can't be combined with --group-by, --cross-team-reviews, --flag-rubber-stamps or --owner-latency"), true)
+}
@@ -3,7 +3,10 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"text/template"
 	"time"
 
 	gh "github.com/cli/go-gh"
@@ -19,6 +22,8 @@ const (
 	DefaultDaysBack = 10
 	// Default date format to use when displaying dates.
 	DefaultDateFormat = "2006-01-02"
+	// Flags that replace per pull request metrics with another report.
+	AggregateReportFlags = "--group-by, --cross-team-reviews, --flag-rubber-stamps, --owner-latency or --review-requests"
 )
 
 var (
@@ -56,6 +61,23 @@ func WorkdayEnd(d time.Time) time.Time {
 	return time.Date(year, month, day, 23, 59, 59, 0, d.Location())
 }
 
+// newCalendar returns the calendar used to calculate durations, counting
+// either all days or only weekdays.
+func newCalendar(onlyWeekdays bool) *cal.BusinessCalendar {
+	var workdayFunc cal.WorkdayFn
+	if onlyWeekdays {
+		workdayFunc = WorkdayOnlyWeekdays
+	} else {
+		workdayFunc = WorkdayAllDays
+	}
+
+	return &cal.BusinessCalendar{
+		WorkdayFunc:      workdayFunc,
+		WorkdayStartFunc: WorkdayStart,
+		WorkdayEndFunc:   WorkdayEnd,
+	}
+}
+
 var RootCmd = &cobra.Command{
 	Use:     "gh-metrics",
 	Short:   "gh-metrics: provide summary pull request metrics",
@@ -67,37 +89,302 @@ var RootCmd = &cobra.Command{
 		query, _ := cmd.Flags().GetString("query")
 		onlyWeekdays, _ := cmd.Flags().GetBool("only-weekdays")
 		csvFormat, _ := cmd.Flags().GetBool("csv")
+		format, _ := cmd.Flags().GetString("format")
+		header, _ := cmd.Flags().GetBool("header")
+		durationFormat, _ := cmd.Flags().GetString("duration-format")
+		output, _ := cmd.Flags().GetString("output")
+		columns, _ := cmd.Flags().GetStringSlice("columns")
+		sortBy, _ := cmd.Flags().GetString("sort")
+		templateName, _ := cmd.Flags().GetString("template")
+		templateString, _ := cmd.Flags().GetString("template-string")
+		githubStepSummary, _ := cmd.Flags().GetBool("github-step-summary")
+		thresholdValues, _ := cmd.Flags().GetStringArray("threshold")
+		groupBy, _ := cmd.Flags().GetString("group-by")
+		labelMap, _ := cmd.Flags().GetStringToString("label-map")
+		teamNames, _ := cmd.Flags().GetStringArray("team")
+		crossTeamReviews, _ := cmd.Flags().GetBool("cross-team-reviews")
+		flagRubberStamps, _ := cmd.Flags().GetBool("flag-rubber-stamps")
+		rubberStampLines, _ := cmd.Flags().GetInt("rubber-stamp-lines")
+		codeOwnersFile, _ := cmd.Flags().GetString("codeowners")
+		ownerLatency, _ := cmd.Flags().GetBool("owner-latency")
+		reviewRequests, _ := cmd.Flags().GetBool("review-requests")
+		outliers, _ := cmd.Flags().GetBool("outliers")
+		outlierThreshold, _ := cmd.Flags().GetString("outlier-threshold")
+		onlyOutliers, _ := cmd.Flags().GetBool("only-outliers")
+		issueStartedLabel, _ := cmd.Flags().GetString("issue-started-label")
+		projectName, _ := cmd.Flags().GetString("project")
+		offline, _ := cmd.Flags().GetBool("offline")
+		saveSnapshot, _ := cmd.Flags().GetString("save-snapshot")
+		fromSnapshot, _ := cmd.Flags().GetString("from-snapshot")
+		windowDays, _ := cmd.Flags().GetInt("window-days")
+		concurrency, _ := cmd.Flags().GetInt("concurrency")
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+
+		// Reports replayed from a snapshot use the parameters it was
+		// taken with.
+		var snapshot *Snapshot
+		if fromSnapshot != "" {
+			var err error
+			snapshot, err = readSnapshot(fromSnapshot)
+			if err != nil {
+				return err
+			}
+
+			parameters := snapshot.Parameters
+			repository = fmt.Sprintf("%s/%s/%s", parameters.Host, parameters.Owner, parameters.Repository)
+			startDate = parameters.StartDate
+			endDate = parameters.EndDate
+			query = parameters.Query
+			onlyWeekdays = parameters.OnlyWeekdays
+			teamNames = parameters.Teams
+			if parameters.WindowDays > 0 {
+				windowDays = parameters.WindowDays
+			}
+		}
 
 		repo, err := newGHRepo(repository)
 		if err != nil {
 			return err
 		}
 
-		var workdayFunc cal.WorkdayFn
-		if onlyWeekdays {
-			workdayFunc = WorkdayOnlyWeekdays
-		} else {
-			workdayFunc = WorkdayAllDays
+		if csvFormat {
+			format = FormatCSV
 		}
+		if err := validateFormat(format); err != nil {
+			return err
+		}
+
+		if durationFormat != "" {
+			if err := validateDurationFormat(durationFormat); err != nil {
+				return err
+			}
+		}
+
+		if err := validateGroupBy(groupBy); err != nil {
+			return err
+		}
+
+		if err := validateColumns(columns); err != nil {
+			return err
+		}
+		if sortBy != "" {
+			if _, _, err := parseSort(sortBy); err != nil {
+				return err
+			}
+		}
+		if flagRubberStamps && (groupBy != "" || crossTeamReviews) {
+			return errors.New("--flag-rubber-stamps can't be combined with --group-by or --cross-team-reviews")
+		}
+		if rubberStampLines < 0 {
+			return errors.New("--rubber-stamp-lines must not be negative")
+		}
+
+		if ownerLatency && (groupBy != "" || crossTeamReviews || flagRubberStamps) {
+			return errors.New("--owner-latency can't be combined with --group-by, --cross-team-reviews or --flag-rubber-stamps")
+		}
+
+		if reviewRequests && (groupBy != "" || crossTeamReviews || flagRubberStamps || ownerLatency) {
+			return errors.New("--review-requests can't be combined with --group-by, --cross-team-reviews, --flag-rubber-stamps or --owner-latency")
+		}
+
+		aggregate := groupBy != "" || crossTeamReviews || flagRubberStamps || ownerLatency || reviewRequests
+		if aggregate {
+			if len(columns) > 0 || sortBy != "" {
+				return fmt.Errorf("--columns and --sort are not supported with %s", AggregateReportFlags)
+			}
+			// OpenMetrics exposes per pull request observations, and
+			// HTML reports embed the aggregate table.
+			if format == FormatJSON || format == FormatXLSX || format == FormatOpenMetrics {
+				return fmt.Errorf("--format %s is not supported with %s", format, AggregateReportFlags)
+			}
+			if outliers || onlyOutliers {
+				return fmt.Errorf("--outliers and --only-outliers are not supported with %s", AggregateReportFlags)
+			}
+		}
+
+		if cmd.Flags().Changed("outlier-threshold") && !outliers && !onlyOutliers {
+			return errors.New("--outlier-threshold requires --outliers or --only-outliers")
+		}
+
+		var outlierRule *OutlierRule
+		if outliers || onlyOutliers {
+			var err error
+			outlierRule, err = newOutlierRule(outlierThreshold)
+			if err != nil {
+				return err
+			}
+		}
+
+		var tmpl *template.Template
+		if templateName != "" || templateString != "" {
+			if cmd.Flags().Changed("format") || csvFormat {
+				return errors.New("--template can't be combined with --format or --csv")
+			}
+			if aggregate {
+				return fmt.Errorf("--template is not supported with %s", AggregateReportFlags)
+			}
 
-		calendar := &cal.BusinessCalendar{
-			WorkdayFunc:      workdayFunc,
-			WorkdayStartFunc: WorkdayStart,
-			WorkdayEndFunc:   WorkdayEnd,
+			var err error
+			if templateName != "" {
+				tmpl, err = loadTemplate(templateName)
+			} else {
+				tmpl, err = newTemplate("template-string", templateString)
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		if format == FormatXLSX && output == "" {
+			return errors.New("--format xlsx requires --output")
+		}
+
+		if offline && query != "" {
+			return errors.New("--query is not supported with --offline")
+		}
+		if offline && codeOwnersFile == "" && (ownerLatency || usesAnyColumn(columns, sortBy, CodeOwnerColumns)) {
+			return errors.New("--codeowners is required to report on code owners with --offline")
+		}
+
+		var teams []GHTeam
+		for _, teamName := range teamNames {
+			team, err := newGHTeam(teamName)
+			if err != nil {
+				return err
+			}
+			teams = append(teams, *team)
+		}
+
+		if (groupBy == GroupByTeam || crossTeamReviews) && len(teams) == 0 {
+			return errors.New("at least one --team is required to report on teams")
+		}
+
+		var project *GHProject
+		if projectName != "" {
+			project, err = newGHProject(projectName)
+			if err != nil {
+				return err
+			}
+		}
+		if _, ok := projectField(groupBy); ok && project == nil {
+			return errors.New("--project is required to group by a project field")
+		}
+
+		thresholds, err := newThresholds(thresholdValues)
+		if err != nil {
+			return err
+		}
+		if len(thresholds) > 0 && !githubStepSummary {
+			return errors.New("--threshold requires --github-step-summary")
+		}
+
+		var actions *GitHubActions
+		if githubStepSummary {
+			actions, err = newGitHubActions(thresholds, cmd.ErrOrStderr())
+			if err != nil {
+				return err
+			}
 		}
 
 		ui := &UI{
-			Owner:      repo.Owner,
-			Repository: repo.Name,
-			Host:       repo.Host,
-			StartDate:  startDate,
-			EndDate:    endDate,
-			Query:      query,
-			CSVFormat:  csvFormat,
-			Calendar:   calendar,
+			Owner:             repo.Owner,
+			Repository:        repo.Name,
+			Host:              repo.Host,
+			StartDate:         startDate,
+			EndDate:           endDate,
+			Query:             query,
+			Format:            format,
+			Header:            header,
+			DurationFormat:    durationFormat,
+			Columns:           columns,
+			Sort:              sortBy,
+			Template:          tmpl,
+			OnlyWeekdays:      onlyWeekdays,
+			GroupBy:           groupBy,
+			LabelMap:          labelMap,
+			Teams:             teams,
+			CrossTeamReviews:  crossTeamReviews,
+			RubberStamps:      flagRubberStamps,
+			RubberStampLines:  rubberStampLines,
+			OwnerLatency:      ownerLatency,
+			ReviewRequests:    usesAnyColumn(columns, sortBy, ReviewRequestColumns),
+			ReviewResponses:   reviewRequests,
+			Outliers:          outlierRule,
+			OnlyOutliers:      onlyOutliers,
+			IssueStartedLabel: issueStartedLabel,
+			Project:           project,
+			Offline:           offline,
+			Snapshot:          snapshot,
+			Actions:           actions,
+			ReplaySnapshot:    snapshot != nil,
+			WindowDays:        windowDays,
+			Concurrency:       concurrency,
+			Timeout:           timeout,
+			Progress:          cmd.ErrOrStderr(),
+			Calendar:          newCalendar(onlyWeekdays),
+		}
+
+		// Team members are only recorded for the lookups of the report
+		// a snapshot was taken for, so replaying a report with others is
+		// rejected.
+		if snapshot != nil && (ui.ReviewRequests || ui.ReviewResponses) && !snapshot.Parameters.ReviewRequests {
+			return fmt.Errorf("snapshot %s was not taken with review requests, take it with --review-requests to report on them", fromSnapshot)
+		}
+
+		if saveSnapshot != "" {
+			ui.Snapshot = newSnapshot(SnapshotParameters{
+				Host:           repo.Host,
+				Owner:          repo.Owner,
+				Repository:     repo.Name,
+				StartDate:      startDate,
+				EndDate:        endDate,
+				Query:          query,
+				OnlyWeekdays:   onlyWeekdays,
+				Teams:          teamNames,
+				WindowDays:     windowDays,
+				ReviewRequests: ui.ReviewRequests || ui.ReviewResponses,
+			})
+		}
+
+		if codeOwnersFile != "" || ownerLatency || usesAnyColumn(columns, sortBy, CodeOwnerColumns) {
+			var text string
+			switch {
+			case snapshot != nil && snapshot.Parameters.CodeOwners == "":
+				return fmt.Errorf("snapshot %s was not taken with code owners, take it with --codeowners or --owner-latency to report on them", fromSnapshot)
+			case snapshot != nil:
+				text = snapshot.Parameters.CodeOwners
+			default:
+				text, err = ui.readCodeOwners(codeOwnersFile)
+				if err != nil {
+					return err
+				}
+			}
+
+			ui.CodeOwners, err = parseCodeOwners(text)
+			if err != nil {
+				return err
+			}
+			if saveSnapshot != "" {
+				ui.Snapshot.Parameters.CodeOwners = text
+			}
+		}
+
+		if output != "" {
+			content := ui.PrintMetrics()
+			// Workbooks are binary, so aren't terminated by a newline.
+			if ui.format() != FormatXLSX {
+				content += "\n"
+			}
+			if err := os.WriteFile(output, []byte(content), 0o644); err != nil {
+				return err
+			}
+		} else {
+			cmd.Println(ui.PrintMetrics())
 		}
 
-		cmd.Println(ui.PrintMetrics())
+		if saveSnapshot != "" {
+			return ui.Snapshot.Write(saveSnapshot)
+		}
 
 		return nil
 	},
@@ -114,16 +401,50 @@ func init() {
 		defaultRepo = fmt.Sprintf("%s/%s", currentRepo.Owner(), currentRepo.Name())
 	}
 
-	RootCmd.Flags().StringP("repo", "R", defaultRepo, "target repository in '[HOST/]OWNER/REPO' format (defaults to the current working directory's repository)")
+	RootCmd.PersistentFlags().StringP("repo", "R", defaultRepo, "target repository in '[HOST/]OWNER/REPO' format (defaults to the current working directory's repository)")
 
 	today := time.Now().UTC()
 	defaultStart = today.AddDate(0, 0, -DefaultDaysBack).Format(DefaultDateFormat)
 	defaultEnd = today.Format(DefaultDateFormat)
 
-	RootCmd.Flags().StringP("start", "s", defaultStart, "target start of date range for merged pull requests")
-	RootCmd.Flags().StringP("end", "e", defaultEnd, "target end of date range for merged pull requests")
+	RootCmd.PersistentFlags().StringP("start", "s", defaultStart, "target start of date range for merged pull requests")
+	RootCmd.PersistentFlags().StringP("end", "e", defaultEnd, "target end of date range for merged pull requests")
+	RootCmd.PersistentFlags().Int("window-days", 0, "split the date range into searches of this many days, run concurrently (0 searches the whole range at once)")
+	RootCmd.PersistentFlags().Int("concurrency", DefaultConcurrency, "maximum number of searches to run concurrently")
+	RootCmd.PersistentFlags().Duration("timeout", DefaultTimeout, "timeout of a single API request")
+
 	RootCmd.Flags().StringP("query", "q", "", "additional query filter for merged pull requests")
 
 	RootCmd.Flags().BoolP("only-weekdays", "w", false, "only include weekdays (M-F) in date range calculations")
-	RootCmd.Flags().BoolP("csv", "c", false, "print output as CSV")
+	RootCmd.Flags().BoolP("csv", "c", false, "print output as CSV (shorthand for --format csv)")
+	RootCmd.Flags().StringP("format", "f", FormatTable, fmt.Sprintf("output format, one of %v", FormatOptions))
+	RootCmd.Flags().Bool("header", false, "precede Markdown output with a description of the report and a summary section")
+	RootCmd.Flags().String("duration-format", "", fmt.Sprintf("format of durations in human-readable output, one of %v (defaults to clock for CSV and go otherwise)", DurationFormatOptions))
+	RootCmd.Flags().StringSlice("columns", nil, fmt.Sprintf("comma-separated list of columns to display, in order, from %v", columnKeys()))
+	RootCmd.Flags().String("sort", "", "column to sort pull requests by, prefixed with '-' for descending order, e.g. '-feature-lead-time'")
+	RootCmd.Flags().String("template", "", fmt.Sprintf("render output with a Go text/template file, or one of the built-in templates %v", builtinTemplateNames()))
+	RootCmd.Flags().String("template-string", "", "render output with a Go text/template given inline")
+	RootCmd.Flags().StringP("output", "o", "", "write output to a file instead of standard output")
+	RootCmd.Flags().Bool("github-step-summary", false, "in GitHub Actions, append a Markdown report to the job summary and write summary statistics as step outputs")
+	RootCmd.Flags().StringArray("threshold", nil, "with --github-step-summary, emit a warning for pull requests exceeding a duration in 'METRIC=DURATION' format, e.g. 'feature-lead-time=72h' (repeatable)")
+
+	RootCmd.Flags().StringP("group-by", "g", "", fmt.Sprintf("aggregate metrics by group, one of %v or %sFIELD", GroupByOptions, GroupByProjectFieldPrefix))
+	RootCmd.Flags().StringToString("label-map", nil, "map labels to categories when grouping by label, e.g. 'defect=bug,enhancement=feature'")
+	RootCmd.Flags().StringArrayP("team", "t", nil, "only include pull requests authored by members of the team in 'ORG/TEAM-SLUG' format (repeatable)")
+	RootCmd.Flags().Bool("cross-team-reviews", false, "report reviews given by members of other teams instead of per pull request metrics")
+	RootCmd.Flags().Bool("flag-rubber-stamps", false, "report pull requests approved without review comments, or self-merged without approval, instead of per pull request metrics")
+	RootCmd.Flags().Int("rubber-stamp-lines", DefaultRubberStampLines, "with --flag-rubber-stamps, minimum changed lines for an approval without review comments to be flagged")
+	RootCmd.Flags().String("codeowners", "", "CODEOWNERS file to attribute reviews to code owners with, instead of the one of the repository")
+	RootCmd.Flags().Bool("owner-latency", false, "report the time to first review by each code owner instead of per pull request metrics")
+	RootCmd.Flags().Bool("review-requests", false, "report how quickly requested reviewers answered review requests instead of per pull request metrics")
+	RootCmd.Flags().Bool("outliers", false, "highlight durations that are outliers of each duration metric")
+	RootCmd.Flags().String("outlier-threshold", DefaultOutlierMADs, "number of median absolute deviations from the median of each metric, or percentile such as p95, beyond which durations are outliers")
+	RootCmd.Flags().Bool("only-outliers", false, "only report pull requests with an outlier in any duration column")
+	RootCmd.Flags().String("issue-started-label", "", "label marking linked issues as started, instead of their first assignment")
+	RootCmd.Flags().String("project", "", "project (v2) to group pull requests by the fields of, in 'ORG/NUMBER' format")
+	RootCmd.Flags().Bool("offline", false, "report from pull requests stored by the sync command instead of querying the API")
+	RootCmd.Flags().String("save-snapshot", "", "save the GraphQL responses and parameters of this run to a file (gzip compressed if it ends in .gz)")
+	RootCmd.Flags().String("from-snapshot", "", "generate the report from a file written by --save-snapshot, without network access")
+	RootCmd.MarkFlagsMutuallyExclusive("offline", "save-snapshot", "from-snapshot")
+	RootCmd.MarkFlagsMutuallyExclusive("template", "template-string")
 }
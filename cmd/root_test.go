@@ -19,10 +19,18 @@ func ResetSubCommandFlagValues(t *testing.T, root *cobra.Command) {
 
 	root.Flags().VisitAll(func(f *pflag.Flag) {
 		if f.Changed {
-			f.Value.Set(f.DefValue)
+			if v, ok := f.Value.(pflag.SliceValue); ok {
+				v.Replace([]string{})
+			} else {
+				f.Value.Set(f.DefValue)
+			}
 			f.Changed = false
 		}
 	})
+
+	for _, cmd := range root.Commands() {
+		ResetSubCommandFlagValues(t, cmd)
+	}
 }
 
 func execute(t *testing.T, args string) string {
@@ -55,12 +63,12 @@ func Test_RootCmd_NoArgs(t *testing.T) {
 		BodyString(ResponseJSON)
 
 	actual := execute(t, "")
-	expected := `┌──────┬─────────┬───────────┬───────────┬───────────────┬──────────────────────┬──────────┬──────────────┬───────────────────┬──────────────────────┬─────────────────────────┐
-│   PR │ COMMITS │ ADDITIONS │ DELETIONS │ CHANGED FILES │ TIME TO FIRST REVIEW │ COMMENTS │ PARTICIPANTS │ FEATURE LEAD TIME │ FIRST TO LAST REVIEW │ FIRST APPROVAL TO MERGE │
-├──────┼─────────┼───────────┼───────────┼───────────────┼──────────────────────┼──────────┼──────────────┼───────────────────┼──────────────────────┼─────────────────────────┤
-│ 5339 │       1 │         6 │         3 │             1 │ 155h26m              │        0 │            3 │ 1h12m             │ 24h0m                │ 22h51m                  │
-│ 5340 │       1 │        12 │         6 │             2 │ 155h26m              │        0 │            3 │ 1h12m             │ 24h0m                │ 22h51m                  │
-└──────┴─────────┴───────────┴───────────┴───────────────┴──────────────────────┴──────────┴──────────────┴───────────────────┴──────────────────────┴─────────────────────────┘`
+	expected := `┌──────┬─────────┬───────────┬───────────┬───────────────┬──────────────────────┬──────────┬──────────────┬───────────────────┬──────────────────────┬─────────────────────────┬──────────────────┐
+│   PR │ COMMITS │ ADDITIONS │ DELETIONS │ CHANGED FILES │ TIME TO FIRST REVIEW │ COMMENTS │ PARTICIPANTS │ FEATURE LEAD TIME │ FIRST TO LAST REVIEW │ FIRST APPROVAL TO MERGE │ LABELS           │
+├──────┼─────────┼───────────┼───────────┼───────────────┼──────────────────────┼──────────┼──────────────┼───────────────────┼──────────────────────┼─────────────────────────┼──────────────────┤
+│ 5339 │       1 │         6 │         3 │             1 │ 155h26m              │        0 │            3 │ 1h12m             │ 24h0m                │ 22h51m                  │ bug              │
+│ 5340 │       1 │        12 │         6 │             2 │ 155h26m              │        0 │            3 │ 1h12m             │ 24h0m                │ 22h51m                  │ bug, enhancement │
+└──────┴─────────┴───────────┴───────────┴───────────────┴──────────────────────┴──────────┴──────────────┴───────────────────┴──────────────────────┴─────────────────────────┴──────────────────┘`
 
 	st.Assert(t, strings.Contains(actual, expected), true)
 }
@@ -79,6 +87,34 @@ func Test_RootCmd_OnlyRepo(t *testing.T) {
 	st.Assert(t, strings.Contains(actual, expected), true)
 }
 
+func Test_RootCmd_InvalidFormat(t *testing.T) {
+	actual := execute(t, "--repo=cli/cli --format=yaml")
+	expected := "invalid format \"yaml\""
+
+	st.Assert(t, strings.Contains(actual, expected), true)
+}
+
+func Test_RootCmd_InvalidGroupBy(t *testing.T) {
+	actual := execute(t, "--repo=cli/cli --group-by=color")
+	expected := "invalid group-by value"
+
+	st.Assert(t, strings.Contains(actual, expected), true)
+}
+
+func Test_RootCmd_GroupByTeamWithoutTeam(t *testing.T) {
+	actual := execute(t, "--repo=cli/cli --group-by=team")
+	expected := "at least one --team is required"
+
+	st.Assert(t, strings.Contains(actual, expected), true)
+}
+
+func Test_RootCmd_InvalidTeam(t *testing.T) {
+	actual := execute(t, "--repo=cli/cli --team=justice-league")
+	expected := "invalid team name"
+
+	st.Assert(t, strings.Contains(actual, expected), true)
+}
+
 func Test_WorkdayOnlyWeekdays(t *testing.T) {
 	friday := time.Date(2022, 4, 1, 0, 0, 0, 0, time.UTC)
 	saturday := time.Date(2022, 4, 2, 0, 0, 0, 0, time.UTC)
@@ -0,0 +1,247 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	// Default interval between refreshes of the exported metrics.
+	DefaultRefreshInterval = 15 * time.Minute
+	// Content type of the OpenMetrics text exposition format.
+	OpenMetricsContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+)
+
+// Exporter periodically recomputes the metrics of pull requests merged in
+// the last Days days for a set of repositories, and serves them in the
+// OpenMetrics text exposition format.
+type Exporter struct {
+	UIs      []*UI
+	Days     int
+	Progress io.Writer
+
+	mu   sync.RWMutex
+	body string
+}
+
+// Refresh recomputes the exported metrics as of a given time. If the pull
+// requests of a repository can't be loaded, the previous metrics are kept.
+func (e *Exporter) Refresh(now time.Time) error {
+	endDate := now.UTC().Format(DefaultDateFormat)
+	startDate := now.UTC().AddDate(0, 0, -e.Days).Format(DefaultDateFormat)
+
+	var observations []Observation
+	var durationMetrics []DurationMetric
+	for _, ui := range e.UIs {
+		ui.StartDate = startDate
+		ui.EndDate = endDate
+		ui.resetTeamMembers()
+
+		pullRequests, err := ui.loadPullRequests(DefaultResultCount)
+		if err != nil {
+			return err
+		}
+
+		pullRequests, err = ui.filterByTeams(pullRequests)
+		if err != nil {
+			return err
+		}
+		metrics, err := ui.metricsOf(pullRequests)
+		if err != nil {
+			return err
+		}
+		observations = append(observations, ui.observations(metrics)...)
+		durationMetrics = ui.durationMetrics()
+	}
+
+	body := renderOpenMetrics(observations, durationMetrics) + "\n"
+
+	e.mu.Lock()
+	e.body = body
+	e.mu.Unlock()
+
+	if e.Progress != nil {
+		fmt.Fprintf(e.Progress, "Refreshed metrics for %d repositories merged between %s and %s\n", len(e.UIs), startDate, endDate)
+	}
+
+	return nil
+}
+
+// ServeHTTP serves the metrics computed by the last refresh.
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	e.mu.RLock()
+	body := e.body
+	e.mu.RUnlock()
+
+	if body == "" {
+		http.Error(w, "metrics have not been computed yet", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", OpenMetricsContentType)
+	io.WriteString(w, body)
+}
+
+var ServeCmd = &cobra.Command{
+	Use:   "serve [[HOST/]OWNER/REPO...]",
+	Short: "Serve pull request metrics over HTTP",
+	Long: `Serve pull request metrics over HTTP.
+
+With --addr, an interactive dashboard is served at /, backed by a JSON API
+returning the pull requests (/api/prs), summary statistics (/api/summary)
+and weekly trend (/api/trend) of a report on --repo. The start, end, query
+and only_weekdays parameters select the report. The dashboard is served on
+127.0.0.1 unless --addr includes a host, and only on loopback addresses
+unless --allow-remote is given. With --offline or --from-snapshot, the
+dashboard runs without network access.
+
+With --metrics-addr, metrics of pull requests merged in the last --days days
+are recomputed every --interval, and gauge histograms of every duration
+metric and gauges of merged pull requests and changed lines, labelled by
+repository, author team and size, are served at /metrics in the OpenMetrics
+text exposition format for Prometheus to scrape.
+
+Exported repositories default to --repo.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		repository, _ := cmd.Flags().GetString("repo")
+		addr, _ := cmd.Flags().GetString("addr")
+		metricsAddr, _ := cmd.Flags().GetString("metrics-addr")
+		interval, _ := cmd.Flags().GetDuration("interval")
+		days, _ := cmd.Flags().GetInt("days")
+		teamNames, _ := cmd.Flags().GetStringArray("team")
+		onlyWeekdays, _ := cmd.Flags().GetBool("only-weekdays")
+		offline, _ := cmd.Flags().GetBool("offline")
+		fromSnapshot, _ := cmd.Flags().GetString("from-snapshot")
+		windowDays, _ := cmd.Flags().GetInt("window-days")
+		concurrency, _ := cmd.Flags().GetInt("concurrency")
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+		allowRemote, _ := cmd.Flags().GetBool("allow-remote")
+
+		if addr == "" && metricsAddr == "" {
+			return errors.New("at least one of --addr or --metrics-addr is required")
+		}
+		if interval <= 0 {
+			return errors.New("--interval must be positive")
+		}
+		if metricsAddr != "" && fromSnapshot != "" {
+			return errors.New("--from-snapshot is not supported with --metrics-addr")
+		}
+
+		var teams []GHTeam
+		for _, teamName := range teamNames {
+			team, err := newGHTeam(teamName)
+			if err != nil {
+				return err
+			}
+			teams = append(teams, *team)
+		}
+
+		muxes := make(map[string]*http.ServeMux)
+		mux := func(addr string) *http.ServeMux {
+			if muxes[addr] == nil {
+				muxes[addr] = http.NewServeMux()
+			}
+			return muxes[addr]
+		}
+
+		if addr != "" {
+			var err error
+			addr, err = dashboardAddr(addr, allowRemote)
+			if err != nil {
+				return err
+			}
+
+			dashboard := &Dashboard{
+				Repository:  repository,
+				Offline:     offline,
+				WindowDays:  windowDays,
+				Concurrency: concurrency,
+				Timeout:     timeout,
+				Progress:    cmd.ErrOrStderr(),
+			}
+			if fromSnapshot != "" {
+				dashboard.Snapshot, err = readSnapshot(fromSnapshot)
+				if err != nil {
+					return err
+				}
+			}
+
+			dashboard.register(mux(addr))
+			cmd.PrintErrf("Serving dashboard on http://%s/\n", addr)
+		}
+
+		if metricsAddr != "" {
+			repositories := args
+			if len(repositories) == 0 {
+				repositories = []string{repository}
+			}
+
+			exporter := &Exporter{Days: days, Progress: cmd.ErrOrStderr()}
+			for _, repository := range repositories {
+				repo, err := newGHRepo(repository)
+				if err != nil {
+					return err
+				}
+
+				exporter.UIs = append(exporter.UIs, &UI{
+					Owner:        repo.Owner,
+					Repository:   repo.Name,
+					Host:         repo.Host,
+					OnlyWeekdays: onlyWeekdays,
+					Teams:        teams,
+					Fields:       &CoreFields,
+					Offline:      offline,
+					WindowDays:   windowDays,
+					Concurrency:  concurrency,
+					Timeout:      timeout,
+					Progress:     cmd.ErrOrStderr(),
+					Calendar:     newCalendar(onlyWeekdays),
+				})
+			}
+
+			if err := exporter.Refresh(time.Now()); err != nil {
+				return err
+			}
+			go func() {
+				for now := range time.Tick(interval) {
+					if err := exporter.Refresh(now); err != nil {
+						cmd.PrintErrf("Failed to refresh metrics: %s\n", err)
+					}
+				}
+			}()
+
+			mux(metricsAddr).Handle("/metrics", exporter)
+			cmd.PrintErrf("Serving metrics on http://%s/metrics\n", metricsAddr)
+		}
+
+		errs := make(chan error, len(muxes))
+		for addr, mux := range muxes {
+			go func(addr string, mux *http.ServeMux) {
+				errs <- http.ListenAndServe(addr, mux)
+			}(addr, mux)
+		}
+
+		return <-errs
+	},
+}
+
+func init() {
+	ServeCmd.Flags().String("addr", "", "address to serve the dashboard on, e.g. 'localhost:8080' (127.0.0.1 unless a host is given)")
+	ServeCmd.Flags().Bool("allow-remote", false, "allow serving the dashboard on an address other than a loopback one")
+	ServeCmd.Flags().String("metrics-addr", "", "address to serve metrics in the OpenMetrics text exposition format on, e.g. ':9090'")
+	ServeCmd.Flags().Duration("interval", DefaultRefreshInterval, "interval between refreshes of the metrics")
+	ServeCmd.Flags().Int("days", DefaultDaysBack, "number of days in the past to include merged pull requests from")
+	ServeCmd.Flags().StringArrayP("team", "t", nil, "only include pull requests authored by members of the team in 'ORG/TEAM-SLUG' format, and label metrics by team (repeatable)")
+	ServeCmd.Flags().BoolP("only-weekdays", "w", false, "only include weekdays (M-F) in date range calculations of the exported metrics")
+	ServeCmd.Flags().Bool("offline", false, "report from pull requests stored by the sync command instead of querying the API")
+	ServeCmd.Flags().String("from-snapshot", "", "serve the dashboard from a file written by --save-snapshot, without network access")
+	ServeCmd.MarkFlagsMutuallyExclusive("offline", "from-snapshot")
+
+	RootCmd.AddCommand(ServeCmd)
+}
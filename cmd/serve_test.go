@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nbio/st"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func Test_Exporter(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.github.com/graphql").
+		Post("/").
+		MatchType("json").
+		AddMatcher(gqlSearchQueryMatcher(Owner, Repository, "2022-03-18", EndDate)).
+		Reply(200).
+		BodyString(ResponseJSON)
+
+	progress := new(bytes.Buffer)
+	exporter := &Exporter{
+		UIs: []*UI{
+			{Owner: Owner, Repository: Repository, Calendar: newCalendar(false)},
+		},
+		Days:     10,
+		Progress: progress,
+	}
+
+	rec := httptest.NewRecorder()
+	exporter.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	st.Assert(t, rec.Code, http.StatusServiceUnavailable)
+
+	exporter.Refresh(time.Date(2022, 3, 28, 12, 0, 0, 0, time.UTC))
+
+	rec = httptest.NewRecorder()
+	exporter.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	st.Assert(t, rec.Code, http.StatusOK)
+	st.Assert(t, rec.Header().Get("Content-Type"), OpenMetricsContentType)
+	st.Assert(t, strings.Contains(rec.Body.String(), `gh_metrics_pull_requests{repo="testOwner/testRepo",team="(none)",size="XS"} 1`), true)
+	st.Assert(t, strings.HasSuffix(rec.Body.String(), "# EOF\n"), true)
+	st.Assert(t, strings.Contains(progress.String(), "merged between 2022-03-18 and 2022-03-28"), true)
+}
+
+func Test_ServeCmd_WithoutAddress(t *testing.T) {
+	actual := execute(t, "serve --repo=cli/cli")
+	expected := "at least one of --addr or --metrics-addr is required"
+
+	st.Assert(t, strings.Contains(actual, expected), true)
+}
+
+func Test_ServeCmd_RemoteAddress(t *testing.T) {
+	actual := execute(t, "serve --repo=cli/cli --addr=0.0.0.0:8080")
+	expected := "--addr 0.0.0.0:8080 is not a loopback address, pass --allow-remote to serve the dashboard on it"
+
+	st.Assert(t, strings.Contains(actual, expected), true)
+}
+
+func Test_Exporter_TeamMembersError(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.github.com/graphql").
+		Post("/").
+		MatchType("json").
+		AddMatcher(gqlSearchQueryMatcher(Owner, "exporterTeamsRepo", "2022-03-18", EndDate)).
+		Reply(200).
+		BodyString(ResponseJSON)
+	gock.New("https://api.github.com/graphql").
+		Post("/").
+		MatchType("json").
+		AddMatcher(gqlTeamMembersQueryMatcher("gotham", "bats")).
+		Reply(502)
+
+	exporter := &Exporter{
+		UIs: []*UI{
+			{Owner: Owner, Repository: "exporterTeamsRepo", Teams: []GHTeam{{Org: "gotham", Slug: "bats"}}, Calendar: newCalendar(false)},
+		},
+		Days: 10,
+	}
+
+	err := exporter.Refresh(time.Date(2022, 3, 28, 12, 0, 0, 0, time.UTC))
+	st.Assert(t, strings.HasPrefix(err.Error(), "failed to fetch members of gotham/bats"), true)
+
+	rec := httptest.NewRecorder()
+	exporter.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	st.Assert(t, rec.Code, http.StatusServiceUnavailable)
+}
@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Placeholder token used when replaying a snapshot, as no requests are
+// sent to GitHub.
+const snapshotAuthToken = "snapshot"
+
+// SnapshotParameters holds the run parameters a snapshot was taken with.
+type SnapshotParameters struct {
+	Host         string
+	Owner        string
+	Repository   string
+	StartDate    string
+	EndDate      string
+	Query        string
+	OnlyWeekdays bool
+	Teams        []string
+	WindowDays   int
+	// The CODEOWNERS rules code owners were looked up with, if any, as
+	// only the members of the teams they name are recorded.
+	CodeOwners string
+	// Whether the members of the teams requested to review were recorded.
+	ReviewRequests bool
+}
+
+// SnapshotResponse holds a GraphQL request and the raw response it received.
+type SnapshotResponse struct {
+	Request  json.RawMessage
+	Response json.RawMessage
+}
+
+// Snapshot holds the raw GraphQL responses a report was based on, so that
+// the report can be reproduced without network access.
+type Snapshot struct {
+	Version    string
+	CreatedAt  string
+	Parameters SnapshotParameters
+	Responses  []SnapshotResponse
+
+	mu sync.Mutex
+}
+
+// newSnapshot returns an empty snapshot for the given run parameters.
+func newSnapshot(parameters SnapshotParameters) *Snapshot {
+	return &Snapshot{
+		Version:    Version,
+		CreatedAt:  time.Now().UTC().Format(time.RFC3339),
+		Parameters: parameters,
+	}
+}
+
+// readSnapshot reads a snapshot from disk. Files ending in `.gz` are
+// expected to be gzip compressed.
+func readSnapshot(path string) (*Snapshot, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read snapshot %s: %w", path, err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	var snapshot Snapshot
+	if err := json.NewDecoder(r).Decode(&snapshot); err != nil {
+		return nil, fmt.Errorf("unable to read snapshot %s: %w", path, err)
+	}
+
+	return &snapshot, nil
+}
+
+// Write writes the snapshot to disk. Files ending in `.gz` are gzip
+// compressed.
+func (s *Snapshot) Write(path string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if strings.HasSuffix(path, ".gz") {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		if _, err := gz.Write(data); err != nil {
+			return err
+		}
+		if err := gz.Close(); err != nil {
+			return err
+		}
+		data = buf.Bytes()
+	}
+
+	return os.WriteFile(path, data, 0o644)
+}
+
+// recorder returns a RoundTripper that records every GraphQL request and
+// response passing through base in the snapshot.
+func (s *Snapshot) recorder(base http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		requestBody, err := readRequestBody(req)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := base.RoundTrip(req)
+		if err != nil || resp.StatusCode != http.StatusOK {
+			return resp, err
+		}
+
+		responseBody, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(responseBody))
+
+		s.mu.Lock()
+		s.Responses = append(s.Responses, SnapshotResponse{
+			Request:  compactJSON(requestBody),
+			Response: compactJSON(responseBody),
+		})
+		s.mu.Unlock()
+
+		return resp, nil
+	})
+}
+
+// replayer returns a RoundTripper that answers GraphQL requests from the
+// snapshot, without network access. Requests that were not recorded
+// result in an error.
+func (s *Snapshot) replayer() http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		requestBody, err := readRequestBody(req)
+		if err != nil {
+			return nil, err
+		}
+		request := compactJSON(requestBody)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		for _, recorded := range s.Responses {
+			if bytes.Equal(compactJSON(recorded.Request), request) {
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Status:     "200 OK",
+					Header:     http.Header{"Content-Type": []string{"application/json"}},
+					Body:       io.NopCloser(bytes.NewReader(recorded.Response)),
+					Request:    req,
+				}, nil
+			}
+		}
+
+		return nil, fmt.Errorf("request not found in snapshot: %s", request)
+	})
+}
+
+// roundTripperFunc adapts a function to the http.RoundTripper interface.
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// readRequestBody returns the body of a request, restoring it so that it
+// can still be sent.
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	return body, nil
+}
+
+// compactJSON returns data with insignificant whitespace removed, or data
+// unchanged if it is not valid JSON.
+func compactJSON(data []byte) json.RawMessage {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, data); err != nil {
+		return data
+	}
+
+	return buf.Bytes()
+}
@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nbio/st"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func Test_Snapshot_WriteAndRead(t *testing.T) {
+	for _, name := range []string{"snapshot.json", "snapshot.json.gz"} {
+		path := filepath.Join(t.TempDir(), name)
+
+		snapshot := newSnapshot(SnapshotParameters{Owner: Owner, Repository: Repository, OnlyWeekdays: true})
+		snapshot.Responses = append(snapshot.Responses, SnapshotResponse{
+			Request:  []byte(`{"query":"query"}`),
+			Response: []byte(`{"data":{}}`),
+		})
+		st.Assert(t, snapshot.Write(path), nil)
+
+		read, err := readSnapshot(path)
+		st.Assert(t, err, nil)
+		st.Assert(t, read.Version, Version)
+		st.Assert(t, read.Parameters, snapshot.Parameters)
+		st.Assert(t, string(compactJSON(read.Responses[0].Response)), `{"data":{}}`)
+	}
+}
+
+func Test_readSnapshot_Invalid(t *testing.T) {
+	_, err := readSnapshot(filepath.Join(t.TempDir(), "missing.json"))
+	st.Assert(t, err != nil, true)
+}
+
+func Test_Snapshot_Replayer(t *testing.T) {
+	snapshot := newSnapshot(SnapshotParameters{})
+	snapshot.Responses = append(snapshot.Responses, SnapshotResponse{
+		Request:  []byte(`{"query":"query"}`),
+		Response: []byte(`{"data":{}}`),
+	})
+
+	req, _ := http.NewRequest("POST", "https://api.github.com/graphql", bytes.NewBufferString(`{ "query": "query" }`))
+	resp, err := snapshot.replayer().RoundTrip(req)
+	st.Assert(t, err, nil)
+	st.Assert(t, resp.StatusCode, http.StatusOK)
+
+	req, _ = http.NewRequest("POST", "https://api.github.com/graphql", bytes.NewBufferString(`{"query":"other"}`))
+	_, err = snapshot.replayer().RoundTrip(req)
+	st.Assert(t, strings.Contains(err.Error(), "request not found in snapshot"), true)
+}
+
+func Test_RootCmd_SaveAndReplaySnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshot.json.gz")
+
+	gock.New("https://api.github.com/graphql").
+		Post("/").
+		MatchType("json").
+		AddMatcher(gqlSearchQueryMatcher(Owner, Repository, StartDate, EndDate)).
+		Reply(200).
+		BodyString(ResponseJSON)
+
+	recorded := execute(t, fmt.Sprintf("--repo=%s/%s --start=%s --end=%s --csv --save-snapshot=%s", Owner, Repository, StartDate, EndDate, path))
+	gock.Off()
+
+	st.Assert(t, strings.Contains(recorded, "5339,1,6,3,1"), true)
+
+	snapshot, err := readSnapshot(path)
+	st.Assert(t, err, nil)
+	st.Assert(t, snapshot.Parameters.Owner, Owner)
+	st.Assert(t, snapshot.Parameters.StartDate, StartDate)
+	st.Assert(t, len(snapshot.Responses), 1)
+
+	replayed := execute(t, fmt.Sprintf("--csv --from-snapshot=%s", path))
+	st.Assert(t, replayed, recorded)
+}
+
+func Test_RootCmd_ReplaySnapshotOtherReport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+
+	gock.New("https://api.github.com/graphql").
+		Post("/").
+		MatchType("json").
+		AddMatcher(gqlSearchQueryMatcher(Owner, Repository, "2022-03-18", "2022-03-23")).
+		Reply(200).
+		BodyString(ResponseJSON)
+
+	gock.New("https://api.github.com/graphql").
+		Post("/").
+		MatchType("json").
+		AddMatcher(gqlSearchQueryMatcher(Owner, Repository, "2022-03-24", "2022-03-28")).
+		Reply(200).
+		BodyString(strings.ReplaceAll(ResponseJSON, `"number": 53`, `"number": 63`))
+
+	execute(t, fmt.Sprintf("--repo=%s/%s --start=%s --end=%s --window-days=6 --save-snapshot=%s", Owner, Repository, StartDate, EndDate, path))
+	gock.Off()
+
+	snapshot, err := readSnapshot(path)
+	st.Assert(t, err, nil)
+	st.Assert(t, snapshot.Parameters.WindowDays, 6)
+	st.Assert(t, len(snapshot.Responses), 2)
+
+	replayed := execute(t, fmt.Sprintf("--csv --columns=number,changed-files,time-in-merge-queue --sort=number --from-snapshot=%s", path))
+	st.Assert(t, strings.Contains(replayed, "request not found"), false)
+	st.Assert(t, strings.Contains(replayed, "6340,"), true)
+
+	replayed = execute(t, fmt.Sprintf("--group-by=label --csv --from-snapshot=%s", path))
+	st.Assert(t, strings.Contains(replayed, "request not found"), false)
+
+	replayed = execute(t, fmt.Sprintf("--owner-latency --from-snapshot=%s", path))
+	st.Assert(t, strings.Contains(replayed, "was not taken with code owners"), true)
+
+	replayed = execute(t, fmt.Sprintf("--review-requests --from-snapshot=%s", path))
+	st.Assert(t, strings.Contains(replayed, "was not taken with review requests"), true)
+}
+
+func Test_RootCmd_SnapshotWithOffline(t *testing.T) {
+	actual := execute(t, "--repo=cli/cli --offline --from-snapshot=snapshot.json")
+	expected := "none of the others can be"
+
+	st.Assert(t, strings.Contains(actual, expected), true)
+}
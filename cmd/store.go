@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// StoreVersion is the version of the shape of the stored pull requests.
+// It must be bumped whenever the fields of PullRequest change, so that
+// stores synced before are resynced rather than reported on with missing
+// data.
+const StoreVersion = 4
+
+// Store persists the pull requests fetched for a repository on disk, so
+// that reports can be generated without refetching merged pull requests.
+type Store struct {
+	Version            int
+	Host               string
+	Owner              string
+	Repository         string
+	LastSyncedMergedAt string
+	PullRequests       map[int]PullRequest
+
+	path string
+}
+
+// storePath returns the location of the store for a given repository,
+// within the user's cache directory.
+func storePath(host, owner, repo string) (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(cacheDir, "gh-metrics", host, owner, repo+".json"), nil
+}
+
+// loadStore returns the store for a given repository. If nothing has been
+// stored yet, an empty store is returned.
+func loadStore(host, owner, repo string) (*Store, error) {
+	path, err := storePath(host, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	store := &Store{
+		Host:         host,
+		Owner:        owner,
+		Repository:   repo,
+		PullRequests: make(map[int]PullRequest),
+		path:         path,
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, store); err != nil {
+		return nil, fmt.Errorf("unable to read store %s: %w", path, err)
+	}
+
+	return store, nil
+}
+
+// Outdated returns true if the store was synced with pull requests of an
+// older shape, which lack data some reports need.
+func (s *Store) Outdated() bool {
+	return len(s.PullRequests) > 0 && s.Version < StoreVersion
+}
+
+// Reset removes every stored pull request, and returns the earliest date
+// one of them was merged on, so that they can all be synced again.
+func (s *Store) Reset() string {
+	earliest := ""
+	for _, pr := range s.PullRequests {
+		if len(pr.MergedAt) >= len(DefaultDateFormat) && (earliest == "" || pr.MergedAt < earliest) {
+			earliest = pr.MergedAt
+		}
+	}
+
+	s.PullRequests = make(map[int]PullRequest)
+	s.LastSyncedMergedAt = ""
+
+	if earliest == "" {
+		return ""
+	}
+	return earliest[:len(DefaultDateFormat)]
+}
+
+// Path returns the location of the store on disk.
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Save writes the store to disk, replacing any previous version.
+func (s *Store) Save() error {
+	s.Version = StoreVersion
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, s.path)
+}
+
+// Upsert adds the given pull requests to the store, replacing those with
+// the same number, and advances the last synced merge date.
+func (s *Store) Upsert(pullRequests []PullRequest) {
+	for _, pr := range pullRequests {
+		s.PullRequests[pr.Number] = pr
+
+		if pr.MergedAt > s.LastSyncedMergedAt {
+			s.LastSyncedMergedAt = pr.MergedAt
+		}
+	}
+}
+
+// PullRequestsMergedBetween returns the stored pull requests merged within
+// the given date range, inclusive, ordered from most to least recent.
+func (s *Store) PullRequestsMergedBetween(startDate, endDate string) []PullRequest {
+	var pullRequests []PullRequest
+	for _, pr := range s.PullRequests {
+		if len(pr.MergedAt) < len(DefaultDateFormat) {
+			continue
+		}
+
+		mergedOn := pr.MergedAt[:len(DefaultDateFormat)]
+		if mergedOn >= startDate && mergedOn <= endDate {
+			pullRequests = append(pullRequests, pr)
+		}
+	}
+
+	sort.Slice(pullRequests, func(i, j int) bool {
+		return pullRequests[i].Number > pullRequests[j].Number
+	})
+
+	return pullRequests
+}
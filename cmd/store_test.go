@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nbio/st"
+)
+
+func Test_loadStore_Empty(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	store, err := loadStore("github.com", Owner, Repository)
+
+	st.Assert(t, err, nil)
+	st.Assert(t, len(store.PullRequests), 0)
+	st.Assert(t, store.LastSyncedMergedAt, "")
+	st.Assert(t, filepath.Base(store.Path()), Repository+".json")
+}
+
+func Test_loadStore_Invalid(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	path, _ := storePath("github.com", Owner, Repository)
+	os.MkdirAll(filepath.Dir(path), 0o755)
+	os.WriteFile(path, []byte("{"), 0o644)
+
+	_, err := loadStore("github.com", Owner, Repository)
+	st.Assert(t, err != nil, true)
+}
+
+func Test_Store_SaveAndLoad(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	store, _ := loadStore("github.com", Owner, Repository)
+	store.Upsert([]PullRequest{
+		{Number: 1, MergedAt: "2022-03-21T16:22:05Z"},
+		{Number: 2, MergedAt: "2022-03-22T16:22:05Z"},
+	})
+	st.Assert(t, store.Save(), nil)
+
+	loaded, err := loadStore("github.com", Owner, Repository)
+	st.Assert(t, err, nil)
+	st.Assert(t, len(loaded.PullRequests), 2)
+	st.Assert(t, loaded.PullRequests[2].MergedAt, "2022-03-22T16:22:05Z")
+	st.Assert(t, loaded.LastSyncedMergedAt, "2022-03-22T16:22:05Z")
+}
+
+func Test_Store_Upsert(t *testing.T) {
+	store := &Store{PullRequests: make(map[int]PullRequest)}
+
+	store.Upsert([]PullRequest{{Number: 1, Additions: 1, MergedAt: "2022-03-22T16:22:05Z"}})
+	store.Upsert([]PullRequest{{Number: 1, Additions: 2, MergedAt: "2022-03-22T16:22:05Z"}})
+	store.Upsert([]PullRequest{{Number: 0, MergedAt: "2022-03-20T16:22:05Z"}})
+
+	st.Assert(t, len(store.PullRequests), 2)
+	st.Assert(t, store.PullRequests[1].Additions, 2)
+	st.Assert(t, store.LastSyncedMergedAt, "2022-03-22T16:22:05Z")
+}
+
+func Test_Store_PullRequestsMergedBetween(t *testing.T) {
+	store := &Store{PullRequests: map[int]PullRequest{
+		1: {Number: 1, MergedAt: "2022-03-17T23:59:59Z"},
+		2: {Number: 2, MergedAt: "2022-03-18T00:00:00Z"},
+		3: {Number: 3, MergedAt: "2022-03-28T23:59:59Z"},
+		4: {Number: 4, MergedAt: "2022-03-29T00:00:00Z"},
+		5: {Number: 5, MergedAt: ""},
+	}}
+
+	pullRequests := store.PullRequestsMergedBetween(StartDate, EndDate)
+
+	st.Assert(t, len(pullRequests), 2)
+	st.Assert(t, pullRequests[0].Number, 3)
+	st.Assert(t, pullRequests[1].Number, 2)
+}
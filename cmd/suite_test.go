@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -26,6 +27,8 @@ const (
                     "additions": 6,
                     "deletions": 3,
                     "number": 5339,
+                    "title": "Fix the Batmobile",
+                    "url": "https://github.com/testOwner/testRepo/pull/5339",
                     "createdAt": "2022-03-21T15:11:09Z",
                     "changedFiles": 1,
                     "isDraft": false,
@@ -36,6 +39,50 @@ const (
                     "comments": {
                         "totalCount": 0
                     },
+                    "reviewThreads": {
+                        "nodes": [
+                            {
+                                "comments": {
+                                    "nodes": [
+                                        {
+                                            "author": {
+                                                "login": "dependabot",
+                                                "__typename": "Bot"
+                                            },
+                                            "createdAt": "2022-03-21T15:11:30Z"
+                                        }
+                                    ]
+                                }
+                            },
+                            {
+                                "comments": {
+                                    "nodes": [
+                                        {
+                                            "author": {
+                                                "login": "Robin",
+                                                "__typename": "User"
+                                            },
+                                            "createdAt": "2022-03-21T15:12:00Z"
+                                        }
+                                    ]
+                                }
+                            }
+                        ]
+                    },
+                    "labels": {
+                        "nodes": [
+                            {
+                                "name": "bug"
+                            }
+                        ]
+                    },
+                    "files": {
+                        "nodes": [
+                            {
+                                "path": "src/batmobile/tyres.go"
+                            }
+                        ]
+                    },
                     "reviews": {
                         "nodes": [
                             {
@@ -43,7 +90,10 @@ const (
                                     "login": "Joker"
                                 },
                                 "createdAt": "2022-03-21T15:12:52Z",
-                                "state": "COMMENTED"
+                                "state": "COMMENTED",
+                                "comments": {
+                                    "totalCount": 2
+                                }
                             },
                             {
                                 "author": {
@@ -80,16 +130,58 @@ const (
                     "additions": 12,
                     "deletions": 6,
                     "number": 5340,
+                    "title": "Add a grappling hook",
+                    "url": "https://github.com/testOwner/testRepo/pull/5340",
                     "createdAt": "2022-03-22T15:11:09Z",
                     "changedFiles": 2,
                     "isDraft": false,
                     "mergedAt": "2022-03-22T16:22:05Z",
+                    "mergedBy": {
+                        "login": "Joker"
+                    },
                     "participants": {
                         "totalCount": 3
                     },
                     "comments": {
                         "totalCount": 0
                     },
+                    "reviewThreads": {
+                        "nodes": [
+                            {
+                                "comments": {
+                                    "nodes": [
+                                        {
+                                            "author": {
+                                                "login": "Robin",
+                                                "__typename": "User"
+                                            },
+                                            "createdAt": "2022-03-22T15:12:00Z"
+                                        }
+                                    ]
+                                }
+                            }
+                        ]
+                    },
+                    "labels": {
+                        "nodes": [
+                            {
+                                "name": "bug"
+                            },
+                            {
+                                "name": "enhancement"
+                            }
+                        ]
+                    },
+                    "files": {
+                        "nodes": [
+                            {
+                                "path": "src/gadgets/grapple.go"
+                            },
+                            {
+                                "path": "docs/gadgets.md"
+                            }
+                        ]
+                    },
                     "reviews": {
                         "nodes": [
                             {
@@ -144,6 +236,7 @@ func gqlSearchQueryMatcher(owner, repo, start, end string) func(req *http.Reques
 		var gqlRequest GQLRequest
 
 		var body, err = io.ReadAll(req.Body)
+		req.Body = io.NopCloser(bytes.NewReader(body))
 		err = json.Unmarshal(body, &gqlRequest)
 
 		return gqlRequest.Variables.Query == fmt.Sprintf("repo:%s/%s type:pr merged:%s..%s",
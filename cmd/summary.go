@@ -0,0 +1,249 @@
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// DurationMetric describes a duration metric computed for every pull
+// request. Key identifies the metric in flags and machine readable output,
+// Span returns the events the metric of a PR is measured between, as
+// configured by a UI, and Fields are the optional connections of pull
+// requests it is measured from.
+type DurationMetric struct {
+	Name   string
+	Key    string
+	Value  func(PullRequestMetrics) NullDuration
+	Span   func(*UI, PullRequest) (Span, error)
+	Fields PullRequestFields
+}
+
+// DurationMetrics lists the duration metrics computed for every pull
+// request, in the order they are displayed.
+var DurationMetrics = []DurationMetric{
+	{
+		Name:  "Time to First Review",
+		Key:   "time-to-first-review",
+		Value: func(m PullRequestMetrics) NullDuration { return m.TimeToFirstReview },
+		Span: func(_ *UI, pr PullRequest) (Span, error) {
+			return timeToFirstReviewSpan(pr.Author.Login, pr.CreatedAt, pr.IsDraft, pr.TimelineItems, pr.Reviews)
+		},
+	},
+	{
+		Name:  "Time to First Response",
+		Key:   "time-to-first-response",
+		Value: func(m PullRequestMetrics) NullDuration { return m.TimeToFirstResponse },
+		Span: func(_ *UI, pr PullRequest) (Span, error) {
+			return timeToFirstResponseSpan(pr)
+		},
+		Fields: PullRequestFields{Responses: true},
+	},
+	{
+		Name:  "Feature Lead Time",
+		Key:   "feature-lead-time",
+		Value: func(m PullRequestMetrics) NullDuration { return m.FeatureLeadTime },
+		Span: func(_ *UI, pr PullRequest) (Span, error) {
+			return featureLeadTimeSpan(pr.MergedAt, pr.Commits)
+		},
+	},
+	{
+		Name:  "First to Last Review",
+		Key:   "first-to-last-review",
+		Value: func(m PullRequestMetrics) NullDuration { return m.FirstReviewToLastReview },
+		Span: func(_ *UI, pr PullRequest) (Span, error) {
+			return firstReviewToLastReviewSpan(pr.Author.Login, pr.Reviews)
+		},
+	},
+	{
+		Name:  "First Approval to Merge",
+		Key:   "first-approval-to-merge",
+		Value: func(m PullRequestMetrics) NullDuration { return m.FirstApprovalToMerge },
+		Span: func(_ *UI, pr PullRequest) (Span, error) {
+			return firstApprovalToMergeSpan(pr.Author.Login, pr.MergedAt, pr.Reviews)
+		},
+	},
+	{
+		Name:  "Issue Created to Merge",
+		Key:   "issue-created-to-merge",
+		Value: func(m PullRequestMetrics) NullDuration { return m.IssueCreatedToMerge },
+		Span: func(_ *UI, pr PullRequest) (Span, error) {
+			return issueCreatedSpan(pr)
+		},
+		Fields: PullRequestFields{LinkedIssues: true},
+	},
+	{
+		Name:  "Issue Started to Merge",
+		Key:   "issue-started-to-merge",
+		Value: func(m PullRequestMetrics) NullDuration { return m.IssueStartedToMerge },
+		Span: func(ui *UI, pr PullRequest) (Span, error) {
+			return issueStartedSpan(pr, ui.IssueStartedLabel)
+		},
+		Fields: PullRequestFields{LinkedIssues: true},
+	},
+	{
+		Name:  "Approval to Enqueue",
+		Key:   "approval-to-enqueue",
+		Value: func(m PullRequestMetrics) NullDuration { return m.ApprovalToEnqueue },
+		Span: func(_ *UI, pr PullRequest) (Span, error) {
+			return approvalToEnqueueSpan(pr)
+		},
+		Fields: PullRequestFields{MergeQueue: true},
+	},
+	{
+		Name:  "Enqueue to Merge",
+		Key:   "enqueue-to-merge",
+		Value: func(m PullRequestMetrics) NullDuration { return m.EnqueueToMerge },
+		Span: func(_ *UI, pr PullRequest) (Span, error) {
+			return enqueueToMergeSpan(pr)
+		},
+		Fields: PullRequestFields{MergeQueue: true},
+	},
+	{
+		Name:  "Time in Merge Queue",
+		Key:   "time-in-merge-queue",
+		Value: func(m PullRequestMetrics) NullDuration { return m.TimeInMergeQueue },
+		Span: func(_ *UI, pr PullRequest) (Span, error) {
+			return timeInMergeQueueSpan(pr)
+		},
+		Fields: PullRequestFields{MergeQueue: true},
+	},
+}
+
+// durationMetric returns the duration metric identified by a given key.
+func durationMetric(key string) (DurationMetric, error) {
+	var keys []string
+	for _, metric := range DurationMetrics {
+		if metric.Key == key {
+			return metric, nil
+		}
+		keys = append(keys, metric.Key)
+	}
+
+	return DurationMetric{}, fmt.Errorf("invalid metric %q, must be one of %v", key, keys)
+}
+
+// MetricSummary holds summary statistics of a duration metric across a set
+// of pull requests. Count is the number of pull requests the metric could
+// be determined for.
+type MetricSummary struct {
+	Name   string
+	Key    string
+	Count  int
+	Median NullDuration
+	Mean   NullDuration
+	P90    NullDuration
+}
+
+// summarize returns summary statistics for each of the given duration
+// metrics across a set of pull requests.
+func summarize(metrics []PullRequestMetrics, durationMetrics []DurationMetric) []MetricSummary {
+	summaries := make([]MetricSummary, 0, len(durationMetrics))
+	for _, metric := range durationMetrics {
+		var valid []time.Duration
+		for _, m := range metrics {
+			if d := metric.Value(m); d.Valid {
+				valid = append(valid, d.Duration)
+			}
+		}
+
+		summaries = append(summaries, MetricSummary{
+			Name:   metric.Name,
+			Key:    metric.Key,
+			Count:  len(valid),
+			Median: medianMetric(metrics, metric.Value),
+			Mean:   meanDuration(valid),
+			P90:    percentileDuration(valid, 90),
+		})
+	}
+
+	return summaries
+}
+
+// meanDuration returns the mean of the durations, or an invalid
+// NullDuration if there are none.
+func meanDuration(durations []time.Duration) NullDuration {
+	if len(durations) == 0 {
+		return NullDuration{}
+	}
+
+	var total time.Duration
+	for _, d := range durations {
+		total += d
+	}
+
+	return NullDuration{Duration: total / time.Duration(len(durations)), Valid: true}
+}
+
+// percentileDuration returns the pth percentile of the durations using the
+// nearest-rank method, or an invalid NullDuration if there are none.
+func percentileDuration(durations []time.Duration, p float64) NullDuration {
+	if len(durations) == 0 {
+		return NullDuration{}
+	}
+
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+
+	return NullDuration{Duration: sorted[rank-1], Valid: true}
+}
+
+// TrendPoint holds the median of each duration metric, in the order they
+// were given, across the pull requests merged in a week.
+type TrendPoint struct {
+	Week         string
+	PullRequests int
+	Medians      []NullDuration
+}
+
+// weekStart returns the Monday of the week a given time falls in.
+func weekStart(t time.Time) time.Time {
+	daysSinceMonday := (int(t.Weekday()) + 6) % 7
+	year, month, day := t.AddDate(0, 0, -daysSinceMonday).Date()
+
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+// weeklyTrend returns the median of each of the given duration metrics per
+// week, ordered from oldest to newest, based on when pull requests were
+// merged. Weeks are identified by the date of their Monday.
+func weeklyTrend(metrics []PullRequestMetrics, durationMetrics []DurationMetric) []TrendPoint {
+	byWeek := make(map[string][]PullRequestMetrics)
+	for _, m := range metrics {
+		mergedAt, err := time.Parse(time.RFC3339, m.PullRequest.MergedAt)
+		if err != nil {
+			continue
+		}
+
+		week := weekStart(mergedAt).Format(DefaultDateFormat)
+		byWeek[week] = append(byWeek[week], m)
+	}
+
+	weeks := make([]string, 0, len(byWeek))
+	for week := range byWeek {
+		weeks = append(weeks, week)
+	}
+	sort.Strings(weeks)
+
+	trend := make([]TrendPoint, 0, len(weeks))
+	for _, week := range weeks {
+		point := TrendPoint{
+			Week:         week,
+			PullRequests: len(byWeek[week]),
+		}
+		for _, metric := range durationMetrics {
+			point.Medians = append(point.Medians, medianMetric(byWeek[week], metric.Value))
+		}
+		trend = append(trend, point)
+	}
+
+	return trend
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nbio/st"
+)
+
+func Test_meanDuration(t *testing.T) {
+	st.Assert(t, meanDuration(nil), NullDuration{})
+	st.Assert(t, meanDuration([]time.Duration{time.Hour, 2 * time.Hour, 6 * time.Hour}), NullDuration{Duration: 3 * time.Hour, Valid: true})
+}
+
+func Test_percentileDuration(t *testing.T) {
+	durations := []time.Duration{
+		10 * time.Hour, 1 * time.Hour, 2 * time.Hour, 3 * time.Hour, 4 * time.Hour,
+		5 * time.Hour, 6 * time.Hour, 7 * time.Hour, 8 * time.Hour, 9 * time.Hour,
+	}
+
+	st.Assert(t, percentileDuration(nil, 90), NullDuration{})
+	st.Assert(t, percentileDuration(durations, 90), NullDuration{Duration: 9 * time.Hour, Valid: true})
+	st.Assert(t, percentileDuration(durations, 100), NullDuration{Duration: 10 * time.Hour, Valid: true})
+	st.Assert(t, percentileDuration(durations, 0), NullDuration{Duration: 1 * time.Hour, Valid: true})
+	st.Assert(t, durations[0], 10*time.Hour)
+}
+
+func Test_summarize(t *testing.T) {
+	metrics := []PullRequestMetrics{
+		{
+			TimeToFirstReview: NullDuration{Duration: time.Hour, Valid: true},
+			FeatureLeadTime:   NullDuration{Duration: 4 * time.Hour, Valid: true},
+		},
+		{
+			TimeToFirstReview: NullDuration{Duration: 3 * time.Hour, Valid: true},
+		},
+	}
+
+	summaries := summarize(metrics, DurationMetrics)
+
+	st.Assert(t, len(summaries), len(DurationMetrics))
+	st.Assert(t, summaries[0], MetricSummary{
+		Name:   "Time to First Review",
+		Key:    "time-to-first-review",
+		Count:  2,
+		Median: NullDuration{Duration: 2 * time.Hour, Valid: true},
+		Mean:   NullDuration{Duration: 2 * time.Hour, Valid: true},
+		P90:    NullDuration{Duration: 3 * time.Hour, Valid: true},
+	})
+	st.Assert(t, summaries[1], MetricSummary{Name: "Time to First Response", Key: "time-to-first-response"})
+	st.Assert(t, summaries[2].Count, 1)
+	st.Assert(t, summaries[3], MetricSummary{Name: "First to Last Review", Key: "first-to-last-review"})
+}
+
+func Test_weekStart(t *testing.T) {
+	monday := time.Date(2022, 3, 21, 0, 0, 0, 0, time.UTC)
+
+	st.Assert(t, weekStart(time.Date(2022, 3, 21, 16, 22, 5, 0, time.UTC)), monday)
+	st.Assert(t, weekStart(time.Date(2022, 3, 25, 9, 0, 0, 0, time.UTC)), monday)
+	st.Assert(t, weekStart(time.Date(2022, 3, 27, 23, 59, 59, 0, time.UTC)), monday)
+	st.Assert(t, weekStart(time.Date(2022, 3, 28, 0, 0, 0, 0, time.UTC)), monday.AddDate(0, 0, 7))
+}
+
+func Test_weeklyTrend(t *testing.T) {
+	metrics := []PullRequestMetrics{
+		{
+			PullRequest:       PullRequest{MergedAt: "2022-03-29T10:00:00Z"},
+			TimeToFirstReview: NullDuration{Duration: 5 * time.Hour, Valid: true},
+		},
+		{
+			PullRequest:       PullRequest{MergedAt: "2022-03-21T10:00:00Z"},
+			TimeToFirstReview: NullDuration{Duration: time.Hour, Valid: true},
+		},
+		{
+			PullRequest:       PullRequest{MergedAt: "2022-03-25T10:00:00Z"},
+			TimeToFirstReview: NullDuration{Duration: 3 * time.Hour, Valid: true},
+		},
+		{
+			PullRequest: PullRequest{MergedAt: "invalid"},
+		},
+	}
+
+	trend := weeklyTrend(metrics, DurationMetrics)
+
+	st.Assert(t, len(trend), 2)
+	st.Assert(t, trend[0].Week, "2022-03-21")
+	st.Assert(t, trend[0].PullRequests, 2)
+	st.Assert(t, trend[0].Medians[0], NullDuration{Duration: 2 * time.Hour, Valid: true})
+	st.Assert(t, trend[0].Medians[1], NullDuration{})
+	st.Assert(t, trend[1].Week, "2022-03-28")
+	st.Assert(t, trend[1].PullRequests, 1)
+	st.Assert(t, len(trend[1].Medians), len(DurationMetrics))
+}
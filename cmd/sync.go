@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var SyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Store merged pull requests locally for offline reporting",
+	Long: `Fetch pull requests merged since the last sync and add them to a local
+store, so that reports can be generated with --offline.
+
+The first sync for a repository starts at --start. Later syncs resume from
+the most recent merge date already stored, unless --start is given.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		repository, _ := cmd.Flags().GetString("repo")
+		startDate, _ := cmd.Flags().GetString("start")
+		endDate, _ := cmd.Flags().GetString("end")
+		windowDays, _ := cmd.Flags().GetInt("window-days")
+		concurrency, _ := cmd.Flags().GetInt("concurrency")
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+
+		repo, err := newGHRepo(repository)
+		if err != nil {
+			return err
+		}
+
+		store, err := loadStore(repo.Host, repo.Owner, repo.Name)
+		if err != nil {
+			return err
+		}
+
+		// Stores of an older version are synced again in full, so that
+		// every stored pull request has the same shape.
+		if store.Outdated() {
+			earliest := store.Reset()
+			if !cmd.Flags().Changed("start") && earliest != "" {
+				startDate = earliest
+			}
+			cmd.PrintErrf("Store %s was synced by an older version, syncing again from %s\n", store.Path(), startDate)
+		} else if !cmd.Flags().Changed("start") && len(store.LastSyncedMergedAt) >= len(DefaultDateFormat) {
+			startDate = store.LastSyncedMergedAt[:len(DefaultDateFormat)]
+		}
+
+		ui := &UI{
+			Owner:       repo.Owner,
+			Repository:  repo.Name,
+			Host:        repo.Host,
+			StartDate:   startDate,
+			EndDate:     endDate,
+			Fields:      &AllPullRequestFields,
+			WindowDays:  windowDays,
+			Concurrency: concurrency,
+			Timeout:     timeout,
+			Progress:    cmd.ErrOrStderr(),
+		}
+
+		pullRequests, err := ui.fetchPullRequests(DefaultResultCount)
+		if err != nil {
+			return err
+		}
+		store.Upsert(pullRequests)
+
+		if err := store.Save(); err != nil {
+			return err
+		}
+
+		cmd.Printf("Synced %d pull requests merged between %s and %s (%d stored in %s)\n",
+			len(pullRequests),
+			startDate,
+			endDate,
+			len(store.PullRequests),
+			store.Path())
+
+		return nil
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(SyncCmd)
+}
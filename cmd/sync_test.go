@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nbio/st"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func Test_SyncCmd(t *testing.T) {
+	defer gock.Off()
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	gock.New("https://api.github.com/graphql").
+		Post("/").
+		MatchType("json").
+		AddMatcher(gqlSearchQueryMatcher(Owner, Repository, StartDate, EndDate)).
+		Reply(200).
+		BodyString(ResponseJSON)
+
+	actual := execute(t, fmt.Sprintf("sync --repo=%s/%s --start=%s --end=%s", Owner, Repository, StartDate, EndDate))
+	st.Assert(t, strings.Contains(actual, "Synced 2 pull requests merged between 2022-03-18 and 2022-03-28"), true)
+
+	store, err := loadStore("github.com", Owner, Repository)
+	st.Assert(t, err, nil)
+	st.Assert(t, len(store.PullRequests), 2)
+	st.Assert(t, store.LastSyncedMergedAt, "2022-03-22T16:22:05Z")
+
+	// Subsequent syncs resume from the most recent merge date.
+	gock.New("https://api.github.com/graphql").
+		Post("/").
+		MatchType("json").
+		AddMatcher(gqlSearchQueryMatcher(Owner, Repository, "2022-03-22", EndDate)).
+		Reply(200).
+		BodyString(ResponseJSON)
+
+	actual = execute(t, fmt.Sprintf("sync --repo=%s/%s --end=%s", Owner, Repository, EndDate))
+	st.Assert(t, strings.Contains(actual, "merged between 2022-03-22 and 2022-03-28 (2 stored in"), true)
+}
+
+func Test_RootCmd_Offline(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	store, _ := loadStore("github.com", Owner, Repository)
+	store.Upsert([]PullRequest{
+		{Number: 5339, MergedAt: "2022-03-21T16:22:05Z"},
+		{Number: 5000, MergedAt: "2022-01-21T16:22:05Z"},
+	})
+	st.Assert(t, store.Save(), nil)
+
+	actual := execute(t, fmt.Sprintf("--repo=%s/%s --start=%s --end=%s --offline --csv", Owner, Repository, StartDate, EndDate))
+
+	st.Assert(t, strings.Contains(actual, "5339,0,0,0,0,--"), true)
+	st.Assert(t, strings.Contains(actual, "5000"), false)
+}
+
+func Test_RootCmd_OfflineWithQuery(t *testing.T) {
+	actual := execute(t, "--repo=cli/cli --offline --query=author:Batman")
+	expected := "--query is not supported with --offline"
+
+	st.Assert(t, strings.Contains(actual, expected), true)
+}
+
+func Test_RootCmd_OfflineWithoutCodeOwners(t *testing.T) {
+	actual := execute(t, "--repo=cli/cli --offline --owner-latency")
+	expected := "--codeowners is required to report on code owners with --offline"
+
+	st.Assert(t, strings.Contains(actual, expected), true)
+}
+
+// writeOutdatedStore writes a store of pull requests synced before stores
+// were versioned.
+func writeOutdatedStore(t *testing.T) {
+	t.Helper()
+
+	path, _ := storePath("github.com", Owner, Repository)
+	st.Assert(t, os.MkdirAll(filepath.Dir(path), 0o755), nil)
+	st.Assert(t, os.WriteFile(path, []byte(`{"PullRequests": {"5339": {"Number": 5339, "MergedAt": "2022-03-19T16:22:05Z"}}, "LastSyncedMergedAt": "2022-03-19T16:22:05Z"}`), 0o644), nil)
+}
+
+func Test_loadPullRequests_OutdatedStore(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	writeOutdatedStore(t)
+
+	ui := &UI{Host: "github.com", Owner: Owner, Repository: Repository, StartDate: StartDate, EndDate: EndDate, Offline: true}
+	_, err := ui.loadPullRequests(DefaultResultCount)
+
+	st.Assert(t, strings.Contains(err.Error(), "synced by an older version, please run `gh metrics sync` again"), true)
+}
+
+func Test_SyncCmd_OutdatedStore(t *testing.T) {
+	defer gock.Off()
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	writeOutdatedStore(t)
+
+	// The whole store is synced again from its earliest merge date.
+	gock.New("https://api.github.com/graphql").
+		Post("/").
+		MatchType("json").
+		AddMatcher(gqlSearchQueryMatcher(Owner, Repository, "2022-03-19", EndDate)).
+		Reply(200).
+		BodyString(ResponseJSON)
+
+	actual := execute(t, fmt.Sprintf("sync --repo=%s/%s --end=%s", Owner, Repository, EndDate))
+	st.Assert(t, strings.Contains(actual, "synced by an older version, syncing again from 2022-03-19"), true)
+
+	store, err := loadStore("github.com", Owner, Repository)
+	st.Assert(t, err, nil)
+	st.Assert(t, store.Version, StoreVersion)
+	st.Assert(t, store.Outdated(), false)
+	st.Assert(t, store.PullRequests[5339].Title, "Fix the Batmobile")
+}
@@ -0,0 +1,341 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	graphql "github.com/cli/shurcooL-graphql"
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+const (
+	// Group pull requests by the teams of their authors.
+	GroupByTeam = "team"
+	// Group name used for reviewers who do not belong to any selected team.
+	NoTeamGroup = "(none)"
+)
+
+// GHTeam represents a GitHub team.
+type GHTeam struct {
+	Org  string
+	Slug string
+}
+
+// newGHTeam returns a GHTeam configured via the 'ORG/TEAM-SLUG' string it's passed.
+func newGHTeam(name string) (*GHTeam, error) {
+	nameParts := strings.Split(name, "/")
+
+	if len(nameParts) != 2 || nameParts[0] == "" || nameParts[1] == "" {
+		return nil, errors.New("invalid team name")
+	}
+
+	return &GHTeam{
+		Org:  nameParts[0],
+		Slug: nameParts[1],
+	}, nil
+}
+
+// String returns the team in 'ORG/TEAM-SLUG' format.
+func (t GHTeam) String() string {
+	return t.Org + "/" + t.Slug
+}
+
+// teamMembers returns the logins of the members of a given team. Lookups
+// are cached, so each team is only fetched once per run, and safe to make
+// from concurrent requests of the serve command. Only lookups of the same
+// team wait for each other's fetch. Failed fetches aren't cached, so they
+// are retried by the next lookup.
+func (ui *UI) teamMembers(team GHTeam) (map[string]bool, error) {
+	members, lock := ui.cachedTeamMembers(team)
+	if members != nil {
+		return members, nil
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	// Another lookup may have fetched the team while this one waited.
+	if members, _ := ui.cachedTeamMembers(team); members != nil {
+		return members, nil
+	}
+
+	logins, err := ui.fetchTeamMembers(team, DefaultResultCount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch members of %s: %w", team, err)
+	}
+
+	members = make(map[string]bool)
+	for _, login := range logins {
+		members[login] = true
+	}
+
+	ui.teamMembersMu.Lock()
+	if ui.teamMembersCache == nil {
+		ui.teamMembersCache = make(map[string]map[string]bool)
+	}
+	ui.teamMembersCache[team.String()] = members
+	ui.teamMembersMu.Unlock()
+
+	return members, nil
+}
+
+// cachedTeamMembers returns the cached members of a given team, or nil if
+// they haven't been fetched yet, along with the lock serializing the
+// fetches of that team.
+func (ui *UI) cachedTeamMembers(team GHTeam) (map[string]bool, *sync.Mutex) {
+	ui.teamMembersMu.Lock()
+	defer ui.teamMembersMu.Unlock()
+
+	if members, ok := ui.teamMembersCache[team.String()]; ok {
+		return members, nil
+	}
+
+	if ui.teamFetchLocks == nil {
+		ui.teamFetchLocks = make(map[string]*sync.Mutex)
+	}
+	lock, ok := ui.teamFetchLocks[team.String()]
+	if !ok {
+		lock = &sync.Mutex{}
+		ui.teamFetchLocks[team.String()] = lock
+	}
+
+	return nil, lock
+}
+
+// isTeamMember returns true if a user is a member of a given team. Teams
+// looked up while computing metrics are loaded beforehand by
+// loadTeamMembers, which reports failures, so a team that can't be fetched
+// here has no members.
+func (ui *UI) isTeamMember(team GHTeam, login string) bool {
+	members, err := ui.teamMembers(team)
+	return err == nil && members[login]
+}
+
+// loadTeamMembers fetches the members of every team the metrics of a set of
+// PRs may look up: the selected teams, the teams owning their changed files
+// and the teams requested to review them.
+func (ui *UI) loadTeamMembers(pullRequests []PullRequest) error {
+	teams := append([]GHTeam(nil), ui.Teams...)
+	for _, pr := range pullRequests {
+		if ui.CodeOwners != nil {
+			for _, owner := range ui.CodeOwners.ownersOf(pr) {
+				if team, ok := ownerTeam(owner); ok {
+					teams = append(teams, team)
+				}
+			}
+		}
+		if ui.ReviewRequests || ui.ReviewResponses {
+			for _, node := range pr.ReviewRequestEvents.Nodes {
+				if team, err := newGHTeam(node.ReviewRequestedEvent.RequestedReviewer.Team.CombinedSlug); err == nil {
+					teams = append(teams, *team)
+				}
+			}
+		}
+	}
+
+	for _, team := range teams {
+		if _, err := ui.teamMembers(team); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// resetTeamMembers empties the cache of team members, so that they are
+// fetched again.
+func (ui *UI) resetTeamMembers() {
+	ui.teamMembersMu.Lock()
+	ui.teamMembersCache = nil
+	ui.teamMembersMu.Unlock()
+}
+
+// fetchTeamMembers returns the logins of the members of a given team,
+// following pagination until all members are retrieved.
+func (ui *UI) fetchTeamMembers(team GHTeam, defaultResultCount int) ([]string, error) {
+	client, err := ui.gqlClient()
+	if err != nil {
+		return nil, err
+	}
+
+	var gqlQuery TeamMembersGQLQuery
+	var gqlQueryVariables map[string]interface{} = map[string]interface{}{
+		"org":         graphql.String(team.Org),
+		"slug":        graphql.String(team.Slug),
+		"resultCount": graphql.Int(defaultResultCount),
+		"afterCursor": (*graphql.String)(nil),
+	}
+
+	var logins []string
+	for {
+		ui.rateLimit().wait()
+
+		err := client.Query("TeamMembers", &gqlQuery, gqlQueryVariables)
+		if err != nil {
+			return nil, err
+		}
+
+		ui.rateLimit().observe(gqlQuery.RateLimit)
+
+		for _, member := range gqlQuery.Organization.Team.Members.Nodes {
+			logins = append(logins, member.Login)
+		}
+
+		if !gqlQuery.Organization.Team.Members.PageInfo.HasNextPage {
+			break
+		}
+
+		gqlQueryVariables["afterCursor"] = graphql.String(gqlQuery.Organization.Team.Members.PageInfo.EndCursor)
+	}
+
+	return logins, nil
+}
+
+// teamsOf returns the selected teams a given user belongs to.
+func (ui *UI) teamsOf(login string) []string {
+	var teams []string
+	for _, team := range ui.Teams {
+		if ui.isTeamMember(team, login) {
+			teams = append(teams, team.String())
+		}
+	}
+
+	return teams
+}
+
+// filterByTeams returns the PRs authored by members of the selected teams.
+// If no teams are selected, all PRs are returned, and so are they when
+// grouping by team, so that PRs of non-members are reported under
+// NoTeamGroup.
+func (ui *UI) filterByTeams(pullRequests []PullRequest) ([]PullRequest, error) {
+	if len(ui.Teams) == 0 {
+		return pullRequests, nil
+	}
+
+	for _, team := range ui.Teams {
+		if _, err := ui.teamMembers(team); err != nil {
+			return nil, err
+		}
+	}
+
+	if ui.GroupBy == GroupByTeam {
+		return pullRequests, nil
+	}
+
+	var filtered []PullRequest
+	for _, pr := range pullRequests {
+		if len(ui.teamsOf(pr.Author.Login)) > 0 {
+			filtered = append(filtered, pr)
+		}
+	}
+
+	return filtered, nil
+}
+
+// teamGroups returns the groups a PR belongs to based on the teams of its
+// author.
+func (ui *UI) teamGroups(pr PullRequest) []string {
+	teams := ui.teamsOf(pr.Author.Login)
+	if len(teams) == 0 {
+		return []string{NoTeamGroup}
+	}
+
+	return teams
+}
+
+// CrossTeamReviewCount holds the number of reviews given by members of one
+// team on PRs authored by members of another.
+type CrossTeamReviewCount struct {
+	AuthorTeam   string
+	ReviewerTeam string
+	Reviews      int
+	PullRequests int
+}
+
+// crossTeamReviews returns the number of non-author reviews given by
+// reviewers outside of the PR author's team, per pair of author and
+// reviewer teams. Reviewers outside of every selected team are attributed
+// to NoTeamGroup.
+func (ui *UI) crossTeamReviews(pullRequests []PullRequest) []CrossTeamReviewCount {
+	byPair := make(map[[2]string]*CrossTeamReviewCount)
+	for _, pr := range pullRequests {
+		seen := make(map[[2]string]bool)
+		for _, authorTeam := range ui.teamsOf(pr.Author.Login) {
+			for _, review := range pr.Reviews.Nodes {
+				if review.Author.Login == pr.Author.Login {
+					continue
+				}
+
+				reviewerTeams := ui.teamsOf(review.Author.Login)
+				if len(reviewerTeams) == 0 {
+					reviewerTeams = []string{NoTeamGroup}
+				}
+
+				sameTeam := false
+				for _, reviewerTeam := range reviewerTeams {
+					if reviewerTeam == authorTeam {
+						sameTeam = true
+					}
+				}
+				if sameTeam {
+					continue
+				}
+
+				for _, reviewerTeam := range reviewerTeams {
+					pair := [2]string{authorTeam, reviewerTeam}
+					entry, ok := byPair[pair]
+					if !ok {
+						entry = &CrossTeamReviewCount{AuthorTeam: authorTeam, ReviewerTeam: reviewerTeam}
+						byPair[pair] = entry
+					}
+					entry.Reviews++
+					if !seen[pair] {
+						seen[pair] = true
+						entry.PullRequests++
+					}
+				}
+			}
+		}
+	}
+
+	reviews := make([]CrossTeamReviewCount, 0, len(byPair))
+	for _, entry := range byPair {
+		reviews = append(reviews, *entry)
+	}
+	sort.Slice(reviews, func(i, j int) bool {
+		if reviews[i].AuthorTeam != reviews[j].AuthorTeam {
+			return reviews[i].AuthorTeam < reviews[j].AuthorTeam
+		}
+		return reviews[i].ReviewerTeam < reviews[j].ReviewerTeam
+	})
+
+	return reviews
+}
+
+// crossTeamReviewsTable returns a table containing a row per pair of author
+// and reviewer teams.
+func crossTeamReviewsTable(reviews []CrossTeamReviewCount) table.Writer {
+	t := table.NewWriter()
+	t.SetStyle(table.StyleLight)
+
+	t.AppendHeader(table.Row{
+		"Author Team",
+		"Reviewer Team",
+		"Reviews",
+		"PRs",
+	})
+
+	for _, r := range reviews {
+		t.AppendRow(table.Row{
+			r.AuthorTeam,
+			r.ReviewerTeam,
+			r.Reviews,
+			r.PullRequests,
+		})
+	}
+
+	return t
+}
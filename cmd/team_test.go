@@ -0,0 +1,332 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/nbio/st"
+	"github.com/rickar/cal/v2"
+	"gopkg.in/h2non/gock.v1"
+)
+
+const (
+	TeamMembersJSON = `
+{
+    "data": {
+        "organization": {
+            "team": {
+                "members": {
+                    "pageInfo": {
+                        "hasNextPage": false,
+                        "endCursor": "Y3Vyc29yOjI="
+                    },
+                    "nodes": [
+                        {
+                            "login": "Batman"
+                        },
+                        {
+                            "login": "Robin"
+                        }
+                    ]
+                }
+            }
+        }
+    }
+}`
+)
+
+type TeamGQLRequest struct {
+	Variables struct {
+		Org  string
+		Slug string
+	}
+}
+
+func gqlTeamMembersQueryMatcher(org, slug string) func(req *http.Request, ereq *gock.Request) (bool, error) {
+	return func(req *http.Request, ereq *gock.Request) (bool, error) {
+		var gqlRequest TeamGQLRequest
+
+		var body, err = io.ReadAll(req.Body)
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		err = json.Unmarshal(body, &gqlRequest)
+
+		return gqlRequest.Variables.Org == org && gqlRequest.Variables.Slug == slug, err
+	}
+}
+
+func Test_NewGHTeam(t *testing.T) {
+	tests := []struct {
+		name     string
+		wantOrg  string
+		wantSlug string
+		wantErr  bool
+		errMsg   string
+	}{
+		{
+			name:     "gotham/justice-league",
+			wantOrg:  "gotham",
+			wantSlug: "justice-league",
+			wantErr:  false,
+		}, {
+			name:    "gotham",
+			wantErr: true,
+			errMsg:  "invalid team name",
+		}, {
+			name:    "gotham/",
+			wantErr: true,
+			errMsg:  "invalid team name",
+		}, {
+			name:    "github.com/gotham/justice-league",
+			wantErr: true,
+			errMsg:  "invalid team name",
+		}}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			team, err := newGHTeam(tt.name)
+
+			if tt.wantErr {
+				st.Assert(t, err.Error(), tt.errMsg)
+			} else {
+				st.Assert(t, err, nil)
+				st.Assert(t, team.Org, tt.wantOrg)
+				st.Assert(t, team.Slug, tt.wantSlug)
+				st.Assert(t, team.String(), tt.name)
+			}
+		})
+	}
+}
+
+func Test_teamMembers_IsCached(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.github.com/graphql").
+		Post("/").
+		MatchType("json").
+		AddMatcher(gqlTeamMembersQueryMatcher("gotham", "heroes")).
+		Reply(200).
+		BodyString(TeamMembersJSON)
+
+	ui := &UI{}
+	team := GHTeam{Org: "gotham", Slug: "heroes"}
+
+	members, err := ui.teamMembers(team)
+	st.Assert(t, err, nil)
+	st.Assert(t, members, map[string]bool{"Batman": true, "Robin": true})
+	st.Assert(t, ui.teamMembersCache["gotham/heroes"], map[string]bool{"Batman": true, "Robin": true})
+
+	ui.teamMembersCache["gotham/heroes"]["Alfred"] = true
+	st.Assert(t, ui.isTeamMember(team, "Alfred"), true)
+}
+
+func Test_teamMembers_Error(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.github.com/graphql").
+		Post("/").
+		MatchType("json").
+		AddMatcher(gqlTeamMembersQueryMatcher("gotham", "bats")).
+		Reply(502)
+
+	ui := &UI{}
+	team := GHTeam{Org: "gotham", Slug: "bats"}
+
+	_, err := ui.teamMembers(team)
+	st.Assert(t, strings.HasPrefix(err.Error(), "failed to fetch members of gotham/bats"), true)
+	st.Assert(t, len(ui.teamMembersCache), 0)
+	st.Assert(t, ui.isTeamMember(team, "Batman"), false)
+
+	ui.Teams = []GHTeam{team}
+	_, err = ui.filterByTeams([]PullRequest{{Number: 1, Author: Author{Login: "Batman"}}})
+	st.Assert(t, strings.HasPrefix(err.Error(), "failed to fetch members of gotham/bats"), true)
+}
+
+func Test_teamMembers_Concurrent(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.github.com/graphql").
+		Post("/").
+		MatchType("json").
+		AddMatcher(gqlTeamMembersQueryMatcher("gotham", "sidekicks")).
+		Reply(200).
+		BodyString(TeamMembersJSON)
+
+	ui := &UI{}
+	team := GHTeam{Org: "gotham", Slug: "sidekicks"}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ui.teamMembers(team)
+		}()
+	}
+	wg.Wait()
+
+	members, err := ui.teamMembers(team)
+	st.Assert(t, err, nil)
+	st.Assert(t, members, map[string]bool{"Batman": true, "Robin": true})
+	st.Assert(t, gock.IsDone(), true)
+}
+
+func Test_teamMembers_OtherTeamFetching(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.github.com/graphql").
+		Post("/").
+		MatchType("json").
+		AddMatcher(gqlTeamMembersQueryMatcher("gotham", "wards")).
+		Reply(200).
+		BodyString(TeamMembersJSON)
+
+	ui := &UI{}
+
+	// Simulate a fetch of another team in progress.
+	_, lock := ui.cachedTeamMembers(GHTeam{Org: "gotham", Slug: "rogues"})
+	lock.Lock()
+	defer lock.Unlock()
+
+	members, err := ui.teamMembers(GHTeam{Org: "gotham", Slug: "wards"})
+	st.Assert(t, err, nil)
+	st.Assert(t, members, map[string]bool{"Batman": true, "Robin": true})
+}
+
+func newTeamsUI() *UI {
+	return &UI{
+		Teams: []GHTeam{
+			{Org: "gotham", Slug: "heroes"},
+			{Org: "gotham", Slug: "villains"},
+		},
+		teamMembersCache: map[string]map[string]bool{
+			"gotham/heroes":   {"Batman": true, "Robin": true},
+			"gotham/villains": {"Joker": true, "Riddler": true},
+		},
+	}
+}
+
+func Test_teamsOf(t *testing.T) {
+	ui := newTeamsUI()
+
+	st.Assert(t, ui.teamsOf("Batman"), []string{"gotham/heroes"})
+	st.Assert(t, ui.teamsOf("Joker"), []string{"gotham/villains"})
+	st.Assert(t, len(ui.teamsOf("Alfred")), 0)
+}
+
+func Test_filterByTeams(t *testing.T) {
+	pullRequests := []PullRequest{
+		{Number: 1, Author: Author{Login: "Batman"}},
+		{Number: 2, Author: Author{Login: "Alfred"}},
+		{Number: 3, Author: Author{Login: "Joker"}},
+	}
+
+	filtered, err := newTeamsUI().filterByTeams(pullRequests)
+	st.Assert(t, err, nil)
+	st.Assert(t, len(filtered), 2)
+	st.Assert(t, filtered[0].Number, 1)
+	st.Assert(t, filtered[1].Number, 3)
+
+	filtered, err = (&UI{}).filterByTeams(pullRequests)
+	st.Assert(t, err, nil)
+	st.Assert(t, len(filtered), 3)
+
+	ui := newTeamsUI()
+	ui.GroupBy = GroupByTeam
+	filtered, err = ui.filterByTeams(pullRequests)
+	st.Assert(t, err, nil)
+	st.Assert(t, len(filtered), 3)
+	st.Assert(t, ui.teamGroups(filtered[1]), []string{NoTeamGroup})
+}
+
+func Test_crossTeamReviews(t *testing.T) {
+	pullRequests := []PullRequest{
+		{
+			Number: 1,
+			Author: Author{Login: "Batman"},
+			Reviews: Reviews{
+				Nodes: ReviewNodes{
+					{Author: Author{Login: "Batman"}, State: "COMMENTED"},
+					{Author: Author{Login: "Robin"}, State: "COMMENTED"},
+					{Author: Author{Login: "Joker"}, State: "COMMENTED"},
+					{Author: Author{Login: "Joker"}, State: "APPROVED"},
+					{Author: Author{Login: "Alfred"}, State: "APPROVED"},
+				},
+			},
+		},
+		{
+			Number: 2,
+			Author: Author{Login: "Riddler"},
+			Reviews: Reviews{
+				Nodes: ReviewNodes{
+					{Author: Author{Login: "Joker"}, State: "APPROVED"},
+				},
+			},
+		},
+	}
+
+	reviews := newTeamsUI().crossTeamReviews(pullRequests)
+
+	st.Assert(t, reviews, []CrossTeamReviewCount{
+		{AuthorTeam: "gotham/heroes", ReviewerTeam: NoTeamGroup, Reviews: 1, PullRequests: 1},
+		{AuthorTeam: "gotham/heroes", ReviewerTeam: "gotham/villains", Reviews: 2, PullRequests: 1},
+	})
+}
+
+func Test_SearchQuery_GroupByTeam(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.github.com/graphql").
+		Post("/").
+		MatchType("json").
+		AddMatcher(gqlSearchQueryMatcher(Owner, Repository, StartDate, EndDate)).
+		Reply(200).
+		BodyString(ResponseJSON)
+
+	ui := newTeamsUI()
+	ui.Owner = Owner
+	ui.Repository = Repository
+	ui.StartDate = StartDate
+	ui.EndDate = EndDate
+	ui.CSVFormat = true
+	ui.GroupBy = GroupByTeam
+	ui.Calendar = cal.NewBusinessCalendar()
+
+	have := ui.PrintMetrics()
+
+	st.Assert(t, strings.Contains(have, "Team,PRs,"), true)
+	st.Assert(t, strings.Contains(have, "gotham/heroes,2,18,9,38:13,38:12,01:12,08:00,06:51"), true)
+}
+
+func Test_SearchQuery_CrossTeamReviews(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.github.com/graphql").
+		Post("/").
+		MatchType("json").
+		AddMatcher(gqlSearchQueryMatcher(Owner, Repository, StartDate, EndDate)).
+		Reply(200).
+		BodyString(ResponseJSON)
+
+	ui := newTeamsUI()
+	ui.Owner = Owner
+	ui.Repository = Repository
+	ui.StartDate = StartDate
+	ui.EndDate = EndDate
+	ui.CSVFormat = true
+	ui.CrossTeamReviews = true
+	ui.Calendar = cal.NewBusinessCalendar()
+
+	have := ui.PrintMetrics()
+
+	st.Assert(t, strings.Contains(have, "Author Team,Reviewer Team,Reviews,PRs"), true)
+	st.Assert(t, strings.Contains(have, "gotham/heroes,gotham/villains,4,2"), true)
+}
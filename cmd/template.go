@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+//go:embed assets/templates/*.tmpl
+var builtinTemplates embed.FS
+
+// TemplateData is the data model templates are rendered with. Every PR
+// holds the fields fetched from the API in PullRequest, and its computed
+// metrics as NullDurations, e.g. `.FeatureLeadTime.Duration` and
+// `.FeatureLeadTime.Valid`. Summary holds the median, mean and 90th
+// percentile of every duration metric, and Trend their weekly medians.
+type TemplateData struct {
+	Host         string
+	Owner        string
+	Repository   string
+	StartDate    string
+	EndDate      string
+	Query        string
+	OnlyWeekdays bool
+	Version      string
+	PullRequests []PullRequestMetrics
+	Summary      []MetricSummary
+	Trend        []TrendPoint
+}
+
+// templateDuration formats a time.Duration or NullDuration in the
+// configured duration format.
+func (ui *UI) templateDuration(d interface{}) (string, error) {
+	switch d := d.(type) {
+	case NullDuration:
+		return ui.formatNullDuration(d), nil
+	case time.Duration:
+		return ui.formatDuration(d), nil
+	}
+
+	return "", fmt.Errorf("duration: unsupported type %T", d)
+}
+
+// templateHours returns a time.Duration or NullDuration in hours, or 0 if it
+// is not valid.
+func templateHours(d interface{}) (float64, error) {
+	switch d := d.(type) {
+	case NullDuration:
+		return d.Duration.Hours(), nil
+	case time.Duration:
+		return d.Hours(), nil
+	}
+
+	return 0, fmt.Errorf("hours: unsupported type %T", d)
+}
+
+// TemplateFuncs are the functions available to templates, in addition to
+// the text/template builtins. duration is replaced when rendering, to use
+// the configured duration format.
+var TemplateFuncs = template.FuncMap{
+	"duration": (&UI{}).templateDuration,
+	"hours":    templateHours,
+	"labels": func(pr PullRequest) []string {
+		return labelNames(pr.Labels)
+	},
+	"join": func(elems []string, sep string) string {
+		return strings.Join(elems, sep)
+	},
+}
+
+// builtinTemplateNames returns the names of the built-in templates.
+func builtinTemplateNames() []string {
+	matches, _ := fs.Glob(builtinTemplates, "assets/templates/*.tmpl")
+
+	names := make([]string, 0, len(matches))
+	for _, match := range matches {
+		names = append(names, strings.TrimSuffix(path.Base(match), ".tmpl"))
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// newTemplate parses a template.
+func newTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(TemplateFuncs).Parse(text)
+}
+
+// loadTemplate parses the template in a given file or, if there is no such
+// file, the built-in template of that name.
+func loadTemplate(name string) (*template.Template, error) {
+	text, err := os.ReadFile(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		builtin, builtinErr := builtinTemplates.ReadFile(fmt.Sprintf("assets/templates/%s.tmpl", name))
+		if builtinErr != nil {
+			return nil, fmt.Errorf("template %q is neither a file nor one of the built-in templates %v", name, builtinTemplateNames())
+		}
+		text, err = builtin, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return newTemplate(filepath.Base(name), string(text))
+}
+
+// renderTemplate returns the output of the configured template for a set of
+// PRs.
+func (ui *UI) renderTemplate(metrics []PullRequestMetrics) string {
+	data := TemplateData{
+		Host:         ui.Host,
+		Owner:        ui.Owner,
+		Repository:   ui.Repository,
+		StartDate:    ui.StartDate,
+		EndDate:      ui.EndDate,
+		Query:        ui.Query,
+		OnlyWeekdays: ui.OnlyWeekdays,
+		Version:      Version,
+		PullRequests: metrics,
+		Summary:      summarize(metrics, ui.durationMetrics()),
+		Trend:        weeklyTrend(metrics, ui.durationMetrics()),
+	}
+
+	var b bytes.Buffer
+	tmpl := ui.Template.Funcs(template.FuncMap{"duration": ui.templateDuration})
+	if err := tmpl.Execute(&b, data); err != nil {
+		log.Fatal(err)
+	}
+
+	return strings.TrimRight(b.String(), "\n")
+}
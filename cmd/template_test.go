@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nbio/st"
+	"github.com/rickar/cal/v2"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func Test_templateDuration(t *testing.T) {
+	ui := &UI{}
+	have, err := ui.templateDuration(NullDuration{Duration: 90 * time.Minute, Valid: true})
+	st.Assert(t, err, nil)
+	st.Assert(t, have, "1h30m")
+
+	have, err = ui.templateDuration(NullDuration{})
+	st.Assert(t, err, nil)
+	st.Assert(t, have, DefaultEmptyCell)
+
+	have, err = ui.templateDuration(2 * time.Minute)
+	st.Assert(t, err, nil)
+	st.Assert(t, have, "2m")
+
+	_, err = ui.templateDuration("1h")
+	st.Assert(t, err != nil, true)
+}
+
+func Test_templateHours(t *testing.T) {
+	have, err := templateHours(NullDuration{Duration: 90 * time.Minute, Valid: true})
+	st.Assert(t, err, nil)
+	st.Assert(t, have, 1.5)
+
+	have, err = templateHours(time.Hour)
+	st.Assert(t, err, nil)
+	st.Assert(t, have, 1.0)
+}
+
+func Test_loadTemplate(t *testing.T) {
+	st.Assert(t, builtinTemplateNames(), []string{"changelog", "slack"})
+
+	for _, name := range builtinTemplateNames() {
+		tmpl, err := loadTemplate(name)
+		st.Assert(t, err, nil)
+		st.Assert(t, tmpl.Name(), name)
+	}
+
+	path := filepath.Join(t.TempDir(), "custom.tmpl")
+	st.Assert(t, os.WriteFile(path, []byte("{{len .PullRequests}}"), 0o644), nil)
+	tmpl, err := loadTemplate(path)
+	st.Assert(t, err, nil)
+	st.Assert(t, tmpl.Name(), "custom.tmpl")
+
+	_, err = loadTemplate("missing")
+	st.Assert(t, strings.Contains(err.Error(), `template "missing" is neither a file nor one of the built-in templates`), true)
+
+	st.Assert(t, os.WriteFile(path, []byte("{{.PullRequests"), 0o644), nil)
+	_, err = loadTemplate(path)
+	st.Assert(t, err != nil, true)
+}
+
+func Test_SearchQuery_WithTemplate(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.github.com/graphql").
+		Post("/").
+		MatchType("json").
+		AddMatcher(gqlSearchQueryMatcher(Owner, Repository, StartDate, EndDate)).
+		Reply(200).
+		BodyString(ResponseJSON)
+
+	tmpl, err := newTemplate("test", `{{.Owner}}/{{.Repository}} {{.StartDate}}..{{.EndDate}}
+{{range .PullRequests}}#{{.PullRequest.Number}} {{duration .FeatureLeadTime}} {{printf "%.1f" (hours .TimeToFirstReview)}} {{join (labels .PullRequest) "+"}}
+{{end}}{{range .Summary}}{{.Key}}={{duration .Median}}
+{{end}}{{range .Trend}}{{.Week}}: {{.PullRequests}}{{end}}`)
+	st.Assert(t, err, nil)
+
+	ui := &UI{
+		Owner:      Owner,
+		Repository: Repository,
+		StartDate:  StartDate,
+		EndDate:    EndDate,
+		Template:   tmpl,
+		Calendar:   cal.NewBusinessCalendar(),
+	}
+
+	have := ui.PrintMetrics()
+	expected := `testOwner/testRepo 2022-03-18..2022-03-28
+#5339 1h12m 38.2 bug
+#5340 1h12m 38.2 bug+enhancement
+time-to-first-review=38h13m
+time-to-first-response=38h12m
+feature-lead-time=1h12m
+first-to-last-review=8h0m
+first-approval-to-merge=6h51m
+2022-03-21: 2`
+
+	st.Assert(t, have, expected)
+}
+
+func Test_RootCmd_BuiltinTemplate(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.github.com/graphql").
+		Post("/").
+		MatchType("json").
+		AddMatcher(gqlSearchQueryMatcher(Owner, Repository, StartDate, EndDate)).
+		Reply(200).
+		BodyString(ResponseJSON)
+
+	actual := execute(t, fmt.Sprintf("--repo=%s/%s --start=%s --end=%s --template=changelog", Owner, Repository, StartDate, EndDate))
+
+	st.Assert(t, strings.Contains(actual, "## testOwner/testRepo: changes merged between 2022-03-18 and 2022-03-28\n"), true)
+	st.Assert(t, strings.Contains(actual, "- Fix the Batmobile ([#5339](https://github.com/testOwner/testRepo/pull/5339)) by @Batman (bug)\n"), true)
+	st.Assert(t, strings.Contains(actual, "- Add a grappling hook ([#5340](https://github.com/testOwner/testRepo/pull/5340)) by @Batman (bug, enhancement)\n"), true)
+}
+
+func Test_RootCmd_TemplateWithFormat(t *testing.T) {
+	actual := execute(t, "--repo=cli/cli --template=slack --format=markdown")
+	expected := "--template can't be combined with --format or --csv"
+
+	st.Assert(t, strings.Contains(actual, expected), true)
+}
@@ -1,9 +1,14 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"net/http"
 	"strings"
+	"sync"
+	"text/template"
 	"time"
 
 	gh "github.com/cli/go-gh"
@@ -20,51 +25,138 @@ const (
 	DefaultResultCount = 100
 	// Pull request review approved state.
 	ReviewApprovedState = "APPROVED"
+	// Render output as a table.
+	FormatTable = "table"
+	// Render output as CSV.
+	FormatCSV = "csv"
+	// Render output as a GitHub-flavored Markdown table.
+	FormatMarkdown = "markdown"
+	// Render output as a self-contained HTML report.
+	FormatHTML = "html"
+	// Render output in the OpenMetrics text exposition format.
+	FormatOpenMetrics = "openmetrics"
+	// Render output as JSON.
+	FormatJSON = "json"
+	// Render output as an Excel workbook.
+	FormatXLSX = "xlsx"
 )
 
+// FormatOptions lists the supported values of the `--format` flag.
+var FormatOptions = []string{FormatTable, FormatCSV, FormatMarkdown, FormatHTML, FormatOpenMetrics, FormatJSON, FormatXLSX}
+
 type UI struct {
-	Host       string
-	Owner      string
-	Repository string
-	StartDate  string
-	EndDate    string
-	Query      string
-	CSVFormat  bool
-	Calendar   *cal.BusinessCalendar
+	Host              string
+	Owner             string
+	Repository        string
+	StartDate         string
+	EndDate           string
+	Query             string
+	CSVFormat         bool
+	Format            string
+	Header            bool
+	DurationFormat    string
+	Columns           []string
+	Sort              string
+	Template          *template.Template
+	OnlyWeekdays      bool
+	GroupBy           string
+	LabelMap          map[string]string
+	Teams             []GHTeam
+	CrossTeamReviews  bool
+	RubberStamps      bool
+	RubberStampLines  int
+	CodeOwners        *CodeOwners
+	OwnerLatency      bool
+	ReviewRequests    bool
+	ReviewResponses   bool
+	Outliers          *OutlierRule
+	OnlyOutliers      bool
+	IssueStartedLabel string
+	Project           *GHProject
+	Fields            *PullRequestFields
+	Offline           bool
+	Snapshot          *Snapshot
+	Actions           *GitHubActions
+	ReplaySnapshot    bool
+	WindowDays        int
+	Concurrency       int
+	Timeout           time.Duration
+	Progress          io.Writer
+	Calendar          *cal.BusinessCalendar
+
+	teamMembersMu    sync.Mutex
+	teamMembersCache map[string]map[string]bool
+	teamFetchLocks   map[string]*sync.Mutex
+	limiter          *rateLimiter
 }
 
-// subtractTime returns the duration t1 - t2, with respect to the
-// configured calendar.
-func (ui *UI) subtractTime(t1, t2 time.Time) time.Duration {
-	return ui.Calendar.WorkHoursInRange(t1, t2)
+// NullDuration represents a duration that may be absent, such as the time
+// to first review of a pull request that was never reviewed.
+type NullDuration struct {
+	Duration time.Duration
+	Valid    bool
 }
 
-// formatDuration formats a duration in hours and minutes, rounded
-// to the nearest minute.
-func formatDuration(d time.Duration, csvFormat bool) string {
-	roundedDuration := d.Round(time.Minute)
+// NullFloat represents a number that may be absent, such as a ratio with
+// a denominator of zero.
+type NullFloat struct {
+	Float64 float64
+	Valid   bool
+}
 
-	if csvFormat {
-		return excelCompatDuration(roundedDuration)
-	}
+// SpanEvent is an event of a pull request that a metric is measured from
+// or to.
+type SpanEvent struct {
+	Time        time.Time
+	Description string
+}
 
-	duration := strings.TrimSuffix(roundedDuration.String(), "0s")
+// Span holds the events a metric of a pull request is measured between.
+type Span struct {
+	From SpanEvent
+	To   SpanEvent
+}
 
-	if len(duration) == 0 {
-		return DefaultEmptyCell
-	}
+// PullRequestMetrics holds the metrics computed for a single pull request.
+type PullRequestMetrics struct {
+	PullRequest             PullRequest
+	TimeToFirstReview       NullDuration
+	TimeToFirstResponse     NullDuration
+	FeatureLeadTime         NullDuration
+	FirstReviewToLastReview NullDuration
+	FirstApprovalToMerge    NullDuration
+	TimeToFirstOwnerReview  NullDuration
+	OwnerApproved           string
+	ReviewRequests          []ReviewRequest
+	TimeToRequestedReview   NullDuration
+	IssueCreatedToMerge     NullDuration
+	IssueStartedToMerge     NullDuration
+	TimeInMergeQueue        NullDuration
+	ApprovalToEnqueue       NullDuration
+	EnqueueToMerge          NullDuration
+	Outliers                map[string]bool
+}
 
-	return duration
+// IsOutlier returns true if any duration metric of the PR is an outlier.
+func (m PullRequestMetrics) IsOutlier() bool {
+	return len(m.Outliers) > 0
 }
 
-// excelCompatDuration formats a duration in hours and minutes, for
-// Excel compatibility, rounded to the nearest minute.
-func excelCompatDuration(d time.Duration) string {
-	h := d / time.Hour
-	d -= h * time.Hour
-	m := d / time.Minute
+// subtractTime returns the duration t1 - t2, with respect to the
+// configured calendar.
+func (ui *UI) subtractTime(t1, t2 time.Time) time.Duration {
+	return ui.Calendar.WorkHoursInRange(t1, t2)
+}
+
+// spanDuration returns the duration of a span with respect to the
+// configured calendar, or an absent duration if the span couldn't be
+// determined.
+func (ui *UI) spanDuration(span Span, err error) NullDuration {
+	if err != nil {
+		return NullDuration{}
+	}
 
-	return fmt.Sprintf("%02d:%02d", h, m)
+	return NullDuration{Duration: ui.subtractTime(span.To.Time, span.From.Time), Valid: true}
 }
 
 // getReadyForReviewOrPrCreatedAt returns when the pull request was
@@ -85,45 +177,149 @@ func getReadyForReviewOrPrCreatedAt(prCreated string, timelineItems TimelineItem
 
 // getTimeToFirstReview returns the time to first review, in hours and
 // minutes, for a given PR.
+func (ui *UI) getTimeToFirstReview(author, prCreatedAt string, isDraft bool, timelineItems TimelineItems, reviews Reviews) string {
+	return ui.formatNullDuration(ui.timeToFirstReview(author, prCreatedAt, isDraft, timelineItems, reviews))
+}
+
+// timeToFirstReview returns the time to first review for a given PR.
+func (ui *UI) timeToFirstReview(author, prCreatedAt string, isDraft bool, timelineItems TimelineItems, reviews Reviews) NullDuration {
+	return ui.spanDuration(timeToFirstReviewSpan(author, prCreatedAt, isDraft, timelineItems, reviews))
+}
+
+// timeToFirstReviewSpan returns the events the time to first review of a
+// given PR is measured between.
 //
 //	timeToFirstReview = (readyForReviewAt || prCreatedAt) - firstReviewdAt
-func (ui *UI) getTimeToFirstReview(author, prCreatedAt string, isDraft bool, timelineItems TimelineItems, reviews Reviews) string {
+func timeToFirstReviewSpan(author, prCreatedAt string, isDraft bool, timelineItems TimelineItems, reviews Reviews) (Span, error) {
 	// The pull request is still in a draft state, because it has not
 	// yet been marked as ready for review.
 	if timelineItems.TotalCount == 0 && isDraft {
-		return DefaultEmptyCell
+		return Span{}, errors.New("the pull request was never marked as ready for review")
 	}
 
 	for _, review := range reviews.Nodes {
 		if review.Author.Login != author {
-			readyForReviewOrPrCreatedAt, err := time.Parse(time.RFC3339, getReadyForReviewOrPrCreatedAt(prCreatedAt, timelineItems))
+			readyAt := getReadyForReviewOrPrCreatedAt(prCreatedAt, timelineItems)
+			readyForReviewOrPrCreatedAt, err := time.Parse(time.RFC3339, readyAt)
 			if err != nil {
-				return DefaultEmptyCell
+				return Span{}, fmt.Errorf("invalid ready for review date: %w", err)
 			}
 			firstReviewedAt, err := time.Parse(time.RFC3339, review.CreatedAt)
 			if err != nil {
-				return DefaultEmptyCell
+				return Span{}, fmt.Errorf("invalid review date: %w", err)
 			}
 
-			return formatDuration(ui.subtractTime(firstReviewedAt, readyForReviewOrPrCreatedAt), ui.CSVFormat)
+			from := "opened"
+			if readyAt != prCreatedAt {
+				from = "marked as ready for review"
+			}
+
+			return Span{
+				From: SpanEvent{Time: readyForReviewOrPrCreatedAt, Description: from},
+				To:   SpanEvent{Time: firstReviewedAt, Description: fmt.Sprintf("first review by %s (%s)", review.Author.Login, review.State)},
+			}, nil
 		}
 	}
 
-	return DefaultEmptyCell
+	return Span{}, errors.New("not reviewed by anyone other than the author")
+}
+
+// isBot returns true if an author is a GitHub App or another bot account.
+func isBot(author Author) bool {
+	return author.Typename == "Bot" || strings.HasSuffix(author.Login, "[bot]")
+}
+
+// getTimeToFirstResponse returns the time to first response, in hours and
+// minutes, for a given PR.
+func (ui *UI) getTimeToFirstResponse(pr PullRequest) string {
+	return ui.formatNullDuration(ui.timeToFirstResponse(pr))
+}
+
+// timeToFirstResponse returns the time to first response for a given PR.
+func (ui *UI) timeToFirstResponse(pr PullRequest) NullDuration {
+	return ui.spanDuration(timeToFirstResponseSpan(pr))
+}
+
+// timeToFirstResponseSpan returns the events the time to first response of
+// a given PR is measured between. A response is a review, a comment or the
+// first comment of a review thread by anyone but the author and bots.
+//
+//	timeToFirstResponse = (readyForReviewAt || prCreatedAt) - firstRespondedAt
+func timeToFirstResponseSpan(pr PullRequest) (Span, error) {
+	if pr.TimelineItems.TotalCount == 0 && pr.IsDraft {
+		return Span{}, errors.New("the pull request was never marked as ready for review")
+	}
+
+	var first SpanEvent
+	respond := func(author Author, createdAt, description string) {
+		if author.Login == pr.Author.Login || isBot(author) {
+			return
+		}
+		respondedAt, err := time.Parse(time.RFC3339, createdAt)
+		if err != nil {
+			return
+		}
+		if first.Time.IsZero() || respondedAt.Before(first.Time) {
+			first = SpanEvent{Time: respondedAt, Description: description}
+		}
+	}
+
+	for _, review := range pr.Reviews.Nodes {
+		respond(review.Author, review.CreatedAt, fmt.Sprintf("first response: review by %s (%s)", review.Author.Login, review.State))
+	}
+	for _, comment := range pr.IssueComments.Nodes {
+		respond(comment.Author, comment.CreatedAt, fmt.Sprintf("first response: comment by %s", comment.Author.Login))
+	}
+	for _, thread := range pr.ReviewThreads.Nodes {
+		for _, comment := range thread.Comments.Nodes {
+			respond(comment.Author, comment.CreatedAt, fmt.Sprintf("first response: review comment by %s", comment.Author.Login))
+		}
+	}
+
+	if first.Time.IsZero() {
+		return Span{}, errors.New("no response from anyone other than the author or bots")
+	}
+
+	readyAt := getReadyForReviewOrPrCreatedAt(pr.CreatedAt, pr.TimelineItems)
+	readyForReviewOrPrCreatedAt, err := time.Parse(time.RFC3339, readyAt)
+	if err != nil {
+		return Span{}, fmt.Errorf("invalid ready for review date: %w", err)
+	}
+
+	from := "opened"
+	if readyAt != pr.CreatedAt {
+		from = "marked as ready for review"
+	}
+
+	return Span{
+		From: SpanEvent{Time: readyForReviewOrPrCreatedAt, Description: from},
+		To:   first,
+	}, nil
 }
 
 // getFeatureLeadTime returns the feature lead time, in hours and minutes,
 // for a given PR.
+func (ui *UI) getFeatureLeadTime(prMergedAtString string, commits Commits) string {
+	return ui.formatNullDuration(ui.featureLeadTime(prMergedAtString, commits))
+}
+
+// featureLeadTime returns the feature lead time for a given PR.
+func (ui *UI) featureLeadTime(prMergedAtString string, commits Commits) NullDuration {
+	return ui.spanDuration(featureLeadTimeSpan(prMergedAtString, commits))
+}
+
+// featureLeadTimeSpan returns the events the feature lead time of a given
+// PR is measured between.
 //
 //	featureLeadTime = prMergedAt - earliestCommitAt
-func (ui *UI) getFeatureLeadTime(prMergedAtString string, commits Commits) string {
+func featureLeadTimeSpan(prMergedAtString string, commits Commits) (Span, error) {
 	if len(commits.Nodes) == 0 {
-		return DefaultEmptyCell
+		return Span{}, errors.New("no commits")
 	}
 
 	prMergedAt, err := time.Parse(time.RFC3339, prMergedAtString)
 	if err != nil {
-		return DefaultEmptyCell
+		return Span{}, fmt.Errorf("invalid merge date: %w", err)
 	}
 
 	// Find the earliest commit by date (handles rebases and force pushes)
@@ -142,17 +338,32 @@ func (ui *UI) getFeatureLeadTime(prMergedAtString string, commits Commits) strin
 
 	// If no valid commit dates were found
 	if !foundValidCommit {
-		return DefaultEmptyCell
+		return Span{}, errors.New("no commits with a valid date")
 	}
 
-	return formatDuration(ui.subtractTime(prMergedAt, earliestCommitDate), ui.CSVFormat)
+	return Span{
+		From: SpanEvent{Time: earliestCommitDate, Description: "earliest commit"},
+		To:   SpanEvent{Time: prMergedAt, Description: "merged"},
+	}, nil
 }
 
 // getFirstReviewToLastReview returns the first review to last approving review time, in
 // hours and minutes, for a given PR.
+func (ui *UI) getFirstReviewToLastReview(login string, reviews Reviews) string {
+	return ui.formatNullDuration(ui.firstReviewToLastReview(login, reviews))
+}
+
+// firstReviewToLastReview returns the first review to last approving review
+// time for a given PR.
+func (ui *UI) firstReviewToLastReview(login string, reviews Reviews) NullDuration {
+	return ui.spanDuration(firstReviewToLastReviewSpan(login, reviews))
+}
+
+// firstReviewToLastReviewSpan returns the events the first review to last
+// approving review time of a given PR is measured between.
 //
 //	firstReviewToLastReview = lastReviewedAt - firstReviewedAt
-func (ui *UI) getFirstReviewToLastReview(login string, reviews Reviews) string {
+func firstReviewToLastReviewSpan(login string, reviews Reviews) (Span, error) {
 	var nonAuthorReviews ReviewNodes
 	for _, review := range reviews.Nodes {
 		if review.Author.Login != login {
@@ -161,12 +372,13 @@ func (ui *UI) getFirstReviewToLastReview(login string, reviews Reviews) string {
 	}
 
 	if len(nonAuthorReviews) == 0 {
-		return DefaultEmptyCell
+		return Span{}, errors.New("not reviewed by anyone other than the author")
 	}
 
-	firstReviewedAt, err := time.Parse(time.RFC3339, nonAuthorReviews[0].CreatedAt)
+	firstReview := nonAuthorReviews[0]
+	firstReviewedAt, err := time.Parse(time.RFC3339, firstReview.CreatedAt)
 	if err != nil {
-		return DefaultEmptyCell
+		return Span{}, fmt.Errorf("invalid review date: %w", err)
 	}
 
 	// Iterate in reverse order to get the last approving review
@@ -174,36 +386,112 @@ func (ui *UI) getFirstReviewToLastReview(login string, reviews Reviews) string {
 		if nonAuthorReviews[i].State == ReviewApprovedState {
 			lastReviewedAt, err := time.Parse(time.RFC3339, nonAuthorReviews[i].CreatedAt)
 			if err != nil {
-				return DefaultEmptyCell
+				return Span{}, fmt.Errorf("invalid review date: %w", err)
 			}
-			return formatDuration(ui.subtractTime(lastReviewedAt, firstReviewedAt), ui.CSVFormat)
+
+			return Span{
+				From: SpanEvent{Time: firstReviewedAt, Description: fmt.Sprintf("first review by %s (%s)", firstReview.Author.Login, firstReview.State)},
+				To:   SpanEvent{Time: lastReviewedAt, Description: fmt.Sprintf("last approval by %s", nonAuthorReviews[i].Author.Login)},
+			}, nil
 		}
 	}
 
-	return DefaultEmptyCell
+	return Span{}, errors.New("not approved by anyone other than the author")
 }
 
 // getFirstApprovalToMerge returns the first approval review to merge time, in
 // hours and minutes, for a given PR.
+func (ui *UI) getFirstApprovalToMerge(author, prMergedAtString string, reviews Reviews) string {
+	return ui.formatNullDuration(ui.firstApprovalToMerge(author, prMergedAtString, reviews))
+}
+
+// firstApprovalToMerge returns the first approval review to merge time for
+// a given PR.
+func (ui *UI) firstApprovalToMerge(author, prMergedAtString string, reviews Reviews) NullDuration {
+	return ui.spanDuration(firstApprovalToMergeSpan(author, prMergedAtString, reviews))
+}
+
+// firstApprovalToMergeSpan returns the events the first approval review to
+// merge time of a given PR is measured between.
 //
 //	firstApprovalToMerge = prMergedAt - firstApprovedAt
-func (ui *UI) getFirstApprovalToMerge(author, prMergedAtString string, reviews Reviews) string {
+func firstApprovalToMergeSpan(author, prMergedAtString string, reviews Reviews) (Span, error) {
 	for _, review := range reviews.Nodes {
 		if review.Author.Login != author && review.State == ReviewApprovedState {
 			prMergedAt, err := time.Parse(time.RFC3339, prMergedAtString)
 			if err != nil {
-				return DefaultEmptyCell
+				return Span{}, fmt.Errorf("invalid merge date: %w", err)
 			}
 			firstApprovedAt, err := time.Parse(time.RFC3339, review.CreatedAt)
 			if err != nil {
-				return DefaultEmptyCell
+				return Span{}, fmt.Errorf("invalid review date: %w", err)
 			}
 
-			return formatDuration(ui.subtractTime(prMergedAt, firstApprovedAt), ui.CSVFormat)
+			return Span{
+				From: SpanEvent{Time: firstApprovedAt, Description: fmt.Sprintf("first approval by %s", review.Author.Login)},
+				To:   SpanEvent{Time: prMergedAt, Description: "merged"},
+			}, nil
 		}
 	}
 
-	return DefaultEmptyCell
+	return Span{}, errors.New("not approved by anyone other than the author")
+}
+
+// computeMetrics returns the metrics for a given PR. Code owner metrics
+// are only computed when CODEOWNERS rules are loaded, and review request
+// metrics when asked for, as they may require fetching team members.
+func (ui *UI) computeMetrics(pr PullRequest) PullRequestMetrics {
+	metrics := PullRequestMetrics{
+		PullRequest: pr,
+		TimeToFirstReview: ui.timeToFirstReview(
+			pr.Author.Login,
+			pr.CreatedAt,
+			pr.IsDraft,
+			pr.TimelineItems,
+			pr.Reviews,
+		),
+		TimeToFirstResponse: ui.timeToFirstResponse(pr),
+		FeatureLeadTime: ui.featureLeadTime(
+			pr.MergedAt,
+			pr.Commits,
+		),
+		FirstReviewToLastReview: ui.firstReviewToLastReview(
+			pr.Author.Login,
+			pr.Reviews,
+		),
+		FirstApprovalToMerge: ui.firstApprovalToMerge(
+			pr.Author.Login,
+			pr.MergedAt,
+			pr.Reviews,
+		),
+		IssueCreatedToMerge: ui.spanDuration(issueCreatedSpan(pr)),
+		IssueStartedToMerge: ui.spanDuration(issueStartedSpan(pr, ui.IssueStartedLabel)),
+		TimeInMergeQueue:    ui.timeInMergeQueue(pr),
+		ApprovalToEnqueue:   ui.spanDuration(approvalToEnqueueSpan(pr)),
+		EnqueueToMerge:      ui.spanDuration(enqueueToMergeSpan(pr)),
+	}
+
+	if ui.CodeOwners != nil {
+		metrics.TimeToFirstOwnerReview = ui.timeToFirstOwnerReview(pr)
+		metrics.OwnerApproved = ui.ownerApproved(pr)
+	}
+
+	if ui.ReviewRequests || ui.ReviewResponses {
+		metrics.ReviewRequests = ui.reviewRequests(pr)
+		metrics.TimeToRequestedReview = ui.timeToRequestedReview(metrics.ReviewRequests)
+	}
+
+	return metrics
+}
+
+// labelNames returns the names of the labels applied to a given PR.
+func labelNames(labels Labels) []string {
+	names := make([]string, 0, len(labels.Nodes))
+	for _, label := range labels.Nodes {
+		names = append(names, label.Name)
+	}
+
+	return names
 }
 
 // PrintMetrics returns a string representation of the metrics summary for
@@ -213,103 +501,316 @@ func (ui *UI) PrintMetrics() string {
 	return ui.printMetricsImpl(DefaultResultCount)
 }
 
+// metricsOf returns the metrics computed for every PR in a set, once the
+// members of the teams they look up are loaded.
+func (ui *UI) metricsOf(pullRequests []PullRequest) ([]PullRequestMetrics, error) {
+	if err := ui.loadTeamMembers(pullRequests); err != nil {
+		return nil, err
+	}
+
+	var metrics []PullRequestMetrics
+	for _, pr := range pullRequests {
+		metrics = append(metrics, ui.computeMetrics(pr))
+	}
+
+	return metrics, nil
+}
+
 // printMetricsImpl returns a string representation of the metrics summary
 // for a set of pull requests determined by the supplied date range.
 func (ui *UI) printMetricsImpl(defaultResultCount int) string {
-	client, err := gh.GQLClient(
-		&api.ClientOptions{
-			Host:        ui.Host,
-			EnableCache: true,
-			CacheTTL:    15 * time.Minute,
-			Timeout:     5 * time.Second,
-		},
-	)
+	pullRequests, err := ui.loadPullRequests(defaultResultCount)
+	if err != nil {
+		log.Fatal(err)
+	}
+	pullRequests, err = ui.filterByTeams(pullRequests)
+	if err != nil {
+		log.Fatal(err)
+	}
+	metrics, err := ui.metricsOf(pullRequests)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if ui.Outliers != nil {
+		markOutliers(metrics, *ui.Outliers)
+		if ui.OnlyOutliers {
+			metrics = onlyOutliers(metrics)
+		}
+	}
+	ui.sortMetrics(metrics)
+
+	t, err := ui.reportTable(pullRequests, metrics, ui.format())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if ui.Actions != nil {
+		if err := ui.reportToGitHubActions(pullRequests, metrics); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// Only tables show captions, so the PRs without linked issues are
+	// counted on stderr in other formats.
+	if ui.Progress != nil && (ui.Template != nil || ui.format() != FormatTable) && usesAnyColumn(ui.Columns, ui.Sort, IssueColumns) {
+		if caption := unlinkedCaption(metrics); caption != "" {
+			fmt.Fprintln(ui.Progress, caption)
+		}
+	}
+
+	return ui.render(t, metrics)
+}
+
+// reportTable returns the table of the report selected, formatted for a
+// given output format: one of the aggregate reports, or a row of metrics
+// per PR.
+func (ui *UI) reportTable(pullRequests []PullRequest, metrics []PullRequestMetrics, format string) (table.Writer, error) {
+	switch {
+	case ui.CrossTeamReviews:
+		return crossTeamReviewsTable(ui.crossTeamReviews(pullRequests)), nil
+	case ui.RubberStamps:
+		return ui.rubberStampsTable(rubberStamps(pullRequests, ui.RubberStampLines), format), nil
+	case ui.OwnerLatency:
+		return ui.ownerLatencyTable(ui.ownerLatencies(pullRequests), format), nil
+	case ui.ReviewResponses:
+		return ui.reviewerResponsesTable(ui.reviewerResponses(metrics), format), nil
+	case ui.GroupBy == GroupByLabel:
+		return ui.groupTable("Label", groupMetrics(metrics, ui.labelGroups), format), nil
+	case ui.GroupBy == GroupByTeam:
+		return ui.groupTable("Team", groupMetrics(metrics, ui.teamGroups), format), nil
+	case strings.HasPrefix(ui.GroupBy, GroupByProjectFieldPrefix):
+		field, _ := projectField(ui.GroupBy)
+		groups, err := ui.projectFieldGroups(metrics, field)
+		if err != nil {
+			return nil, err
+		}
+		return ui.groupTable(field, groups, format), nil
+	default:
+		return ui.metricsTable(metrics, format), nil
+	}
+}
+
+// validateFormat returns an error if the given output format is not
+// supported.
+func validateFormat(format string) error {
+	for _, option := range FormatOptions {
+		if format == option {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("invalid format %q, must be one of %v", format, FormatOptions)
+}
+
+// format returns the output format, taking the legacy CSVFormat option
+// into account.
+func (ui *UI) format() string {
+	if ui.CSVFormat {
+		return FormatCSV
+	}
+	if ui.Format == "" {
+		return FormatTable
+	}
+
+	return ui.Format
+}
+
+// render returns the table in the configured output format. Markdown
+// output is optionally preceded by a header describing the report.
+func (ui *UI) render(t table.Writer, metrics []PullRequestMetrics) string {
+	if ui.Template != nil {
+		return ui.renderTemplate(metrics)
+	}
+
+	switch ui.format() {
+	case FormatCSV:
+		return t.RenderCSV()
+	case FormatMarkdown:
+		if ui.Header {
+			return ui.markdownHeader(metrics) + t.RenderMarkdown()
+		}
+		return t.RenderMarkdown()
+	case FormatHTML:
+		return ui.renderHTML(t, metrics)
+	case FormatOpenMetrics:
+		return renderOpenMetrics(ui.observations(metrics), ui.durationMetrics())
+	case FormatJSON:
+		return ui.renderJSON(metrics)
+	case FormatXLSX:
+		return ui.renderXLSX(metrics)
+	default:
+		return t.Render()
+	}
+}
+
+// formatNumber returns the number of a given PR, linked to the PR in
+// Markdown output.
+func (ui *UI) formatNumber(pr PullRequest, format string) interface{} {
+	if format == FormatMarkdown && pr.URL != "" {
+		return fmt.Sprintf("[%d](%s)", pr.Number, pr.URL)
+	}
+
+	return pr.Number
+}
+
+// loadPullRequests returns the pull requests to report on, either from the
+// API or, when offline, from the local store.
+func (ui *UI) loadPullRequests(defaultResultCount int) ([]PullRequest, error) {
+	if !ui.Offline {
+		return ui.fetchPullRequests(defaultResultCount)
+	}
+
+	store, err := loadStore(ui.Host, ui.Owner, ui.Repository)
 	if err != nil {
-		log.Fatal("To authenticate, please run `gh auth login`.")
+		return nil, err
+	}
+	if store.Outdated() {
+		return nil, fmt.Errorf("The pull requests stored for %s/%s were synced by an older version, please run `gh metrics sync` again.", ui.Owner, ui.Repository)
+	}
+	if len(store.PullRequests) == 0 {
+		return nil, fmt.Errorf("No pull requests stored for %s/%s, please run `gh metrics sync`.", ui.Owner, ui.Repository)
 	}
 
+	return store.PullRequestsMergedBetween(ui.StartDate, ui.EndDate), nil
+}
+
+// gqlClient returns a GraphQL client for the configured host. Requests are
+// retried after secondary rate limits and transient server errors. When a
+// snapshot is configured, responses are either recorded in it or replayed
+// from it.
+func (ui *UI) gqlClient() (api.GQLClient, error) {
+	timeout := ui.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	opts := &api.ClientOptions{
+		Host:        ui.Host,
+		EnableCache: true,
+		CacheTTL:    15 * time.Minute,
+	}
+
+	transport := http.DefaultTransport
+	if ui.Snapshot != nil {
+		// Cached responses would bypass the snapshot transport.
+		opts.EnableCache = false
+		opts.CacheTTL = 0
+
+		if ui.ReplaySnapshot {
+			opts.AuthToken = snapshotAuthToken
+			transport = ui.Snapshot.replayer()
+		} else {
+			transport = ui.Snapshot.recorder(transport)
+		}
+	}
+	if !ui.ReplaySnapshot {
+		transport = retryTransport(transport, timeout, ui.Progress)
+	}
+	opts.Transport = transport
+
+	client, err := gh.GQLClient(opts)
+	if err != nil {
+		return nil, errors.New("To authenticate, please run `gh auth login`.")
+	}
+
+	return client, nil
+}
+
+// rateLimit returns the rate limiter shared by the queries of this run.
+func (ui *UI) rateLimit() *rateLimiter {
+	if ui.limiter == nil {
+		ui.limiter = &rateLimiter{progress: ui.Progress}
+	}
+
+	return ui.limiter
+}
+
+// fetchPullRequests returns every pull request merged within the supplied
+// date range. The range is split into windows of WindowDays days, which
+// are searched concurrently.
+func (ui *UI) fetchPullRequests(defaultResultCount int) ([]PullRequest, error) {
+	client, err := ui.gqlClient()
+	if err != nil {
+		return nil, err
+	}
+	ui.rateLimit()
+
+	windows := searchWindows(ui.StartDate, ui.EndDate, ui.WindowDays)
+	results := make([][]PullRequest, len(windows))
+	errs := make([]error, len(windows))
+
+	var mu sync.Mutex
+	completed := 0
+	runConcurrently(len(windows), ui.Concurrency, func(i int) {
+		results[i], errs[i] = ui.fetchWindow(client, windows[i], defaultResultCount)
+		if errs[i] != nil {
+			return
+		}
+
+		if len(windows) > 1 && ui.Progress != nil {
+			mu.Lock()
+			completed++
+			fmt.Fprintf(ui.Progress, "Fetched %d pull requests merged between %s and %s (%d/%d)\n",
+				len(results[i]),
+				windows[i].StartDate,
+				windows[i].EndDate,
+				completed,
+				len(windows))
+			mu.Unlock()
+		}
+	})
+
+	var pullRequests []PullRequest
+	for i, result := range results {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		pullRequests = append(pullRequests, result...)
+	}
+
+	return pullRequests, nil
+}
+
+// fetchWindow returns every pull request merged within a search window,
+// following pagination until all results are retrieved.
+func (ui *UI) fetchWindow(client api.GQLClient, window SearchWindow, defaultResultCount int) ([]PullRequest, error) {
 	var gqlQuery MetricsGQLQuery
 	var gqlQueryVariables map[string]interface{} = map[string]interface{}{
 		"query": graphql.String(
 			strings.TrimSpace(fmt.Sprintf("repo:%s/%s type:pr merged:%s..%s %s",
 				ui.Owner,
 				ui.Repository,
-				ui.StartDate,
-				ui.EndDate,
+				window.StartDate,
+				window.EndDate,
 				ui.Query))),
 		"resultCount": graphql.Int(defaultResultCount),
 		"afterCursor": (*graphql.String)(nil),
 	}
-
-	err = client.Query("PullRequests", &gqlQuery, gqlQueryVariables)
-	if err != nil {
-		log.Fatal(err)
+	for name, value := range ui.fields().variables() {
+		gqlQueryVariables[name] = value
 	}
 
-	t := table.NewWriter()
-	t.SetStyle(table.StyleLight)
-
-	t.AppendHeader(table.Row{
-		"PR",
-		"Commits",
-		"Additions",
-		"Deletions",
-		"Changed Files",
-		"Time to First Review",
-		"Comments",
-		"Participants",
-		"Feature Lead Time",
-		"First to Last Review",
-		"First Approval to Merge",
-	})
-
+	var pullRequests []PullRequest
 	for {
+		ui.rateLimit().wait()
+
+		err := client.Query("PullRequests", &gqlQuery, gqlQueryVariables)
+		if err != nil {
+			return nil, err
+		}
+
+		ui.rateLimit().observe(gqlQuery.RateLimit)
+
 		for _, node := range gqlQuery.Search.Nodes {
-			t.AppendRow(table.Row{
-				node.PullRequest.Number,
-				node.PullRequest.Commits.TotalCount,
-				node.PullRequest.Additions,
-				node.PullRequest.Deletions,
-				node.PullRequest.ChangedFiles,
-				ui.getTimeToFirstReview(
-					node.PullRequest.Author.Login,
-					node.PullRequest.CreatedAt,
-					node.PullRequest.IsDraft,
-					node.PullRequest.TimelineItems,
-					node.PullRequest.Reviews,
-				),
-				node.PullRequest.Comments.TotalCount,
-				node.PullRequest.Participants.TotalCount,
-				ui.getFeatureLeadTime(
-					node.PullRequest.MergedAt,
-					node.PullRequest.Commits,
-				),
-				ui.getFirstReviewToLastReview(
-					node.PullRequest.Author.Login,
-					node.PullRequest.Reviews,
-				),
-				ui.getFirstApprovalToMerge(
-					node.PullRequest.Author.Login,
-					node.PullRequest.MergedAt,
-					node.PullRequest.Reviews,
-				),
-			})
+			pullRequests = append(pullRequests, node.PullRequest)
 		}
 
-		if gqlQuery.Search.PageInfo.HasNextPage {
-			gqlQueryVariables["afterCursor"] = graphql.String(gqlQuery.Search.PageInfo.EndCursor)
-			err = client.Query("PullRequests", &gqlQuery, gqlQueryVariables)
-			if err != nil {
-				log.Fatal(err)
-			}
-		} else {
+		if !gqlQuery.Search.PageInfo.HasNextPage {
 			break
 		}
-	}
 
-	if ui.CSVFormat {
-		return t.RenderCSV()
+		gqlQueryVariables["afterCursor"] = graphql.String(gqlQuery.Search.PageInfo.EndCursor)
 	}
 
-	return t.Render()
+	return pullRequests, nil
 }
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -26,6 +27,7 @@ func gqlSearchQueryWithFilterMatcher(req *http.Request, ereq *gock.Request) (boo
 	var gqlRequest GQLRequest
 
 	var body, err = io.ReadAll(req.Body)
+	req.Body = io.NopCloser(bytes.NewReader(body))
 	err = json.Unmarshal(body, &gqlRequest)
 
 	return gqlRequest.Variables.Query == fmt.Sprintf("repo:%s/%s type:pr merged:%s..%s %s",
@@ -185,22 +187,6 @@ func Test_subtractTime_SpanningWeekend(t *testing.T) {
 	st.Assert(t, uiWithoutWeekends.subtractTime(end, start).String(), "6h59m59s")
 }
 
-func Test_formatDuration_LessThanMinute(t *testing.T) {
-	st.Assert(t, formatDuration(time.Second*5, false), DefaultEmptyCell)
-}
-
-func Test_formatDuration_LessThanMinuteWithCSV(t *testing.T) {
-	st.Assert(t, formatDuration(time.Second*5, true), "00:00")
-}
-
-func Test_formatDuration_MoreThanMinute(t *testing.T) {
-	st.Assert(t, formatDuration(time.Minute*5, false), "5m")
-}
-
-func Test_formatDuration_MoreThanMinuteWithCSV(t *testing.T) {
-	st.Assert(t, formatDuration(time.Minute*5, true), "00:05")
-}
-
 func Test_getReadyForReviewOrPrCreatedAt_prCreatedAt(t *testing.T) {
 	st.Assert(t, getReadyForReviewOrPrCreatedAt("2022-03-21T15:11:09Z", TimelineItems{
 		TotalCount: 0,
@@ -346,6 +332,69 @@ func Test_getTimeToFirstReview_InvalidReviewDate(t *testing.T) {
 	st.Assert(t, ui.getTimeToFirstReview("Batman", "", false, timelineItems, reviews), "--")
 }
 
+func Test_isBot(t *testing.T) {
+	st.Assert(t, isBot(Author{Login: "github-actions", Typename: "Bot"}), true)
+	st.Assert(t, isBot(Author{Login: "renovate[bot]"}), true)
+	st.Assert(t, isBot(Author{Login: "Robin", Typename: "User"}), false)
+}
+
+func Test_getTimeToFirstResponse(t *testing.T) {
+	pr := PullRequest{
+		Author:    Author{Login: "Batman"},
+		CreatedAt: "2022-03-21T09:00:00Z",
+		Reviews: Reviews{
+			Nodes: ReviewNodes{
+				{
+					Author:    Author{Login: "Joker"},
+					CreatedAt: "2022-03-21T12:00:00Z",
+					State:     "COMMENTED",
+				},
+			},
+		},
+		IssueComments: IssueComments{
+			Nodes: CommentNodes{
+				{Author: Author{Login: "Batman"}, CreatedAt: "2022-03-21T09:05:00Z"},
+				{Author: Author{Login: "github-actions", Typename: "Bot"}, CreatedAt: "2022-03-21T09:10:00Z"},
+				{Author: Author{Login: "Robin"}, CreatedAt: "2022-03-21T11:00:00Z"},
+			},
+		},
+		ReviewThreads: ReviewThreads{
+			Nodes: ReviewThreadNodes{
+				{Comments: struct{ Nodes CommentNodes }{Nodes: CommentNodes{{Author: Author{Login: "Alfred"}, CreatedAt: "2022-03-21T10:30:00Z"}}}},
+			},
+		},
+	}
+
+	ui := &UI{
+		Calendar: &cal.BusinessCalendar{
+			WorkdayFunc:      WorkdayAllDays,
+			WorkdayStartFunc: WorkdayStart,
+			WorkdayEndFunc:   WorkdayEnd,
+		},
+	}
+	st.Assert(t, ui.getTimeToFirstResponse(pr), "1h30m")
+
+	span, err := timeToFirstResponseSpan(pr)
+	st.Assert(t, err, nil)
+	st.Assert(t, span.To.Description, "first response: review comment by Alfred")
+}
+
+func Test_getTimeToFirstResponse_OnlyAuthorAndBots(t *testing.T) {
+	pr := PullRequest{
+		Author:    Author{Login: "Batman"},
+		CreatedAt: "2022-03-21T09:00:00Z",
+		IssueComments: IssueComments{
+			Nodes: CommentNodes{
+				{Author: Author{Login: "Batman"}, CreatedAt: "2022-03-21T09:05:00Z"},
+				{Author: Author{Login: "dependabot[bot]"}, CreatedAt: "2022-03-21T09:10:00Z"},
+			},
+		},
+	}
+
+	ui := &UI{}
+	st.Assert(t, ui.getTimeToFirstResponse(pr), "--")
+}
+
 func Test_getFeatureLeadTime(t *testing.T) {
 	var commits = Commits{
 		TotalCount: 1,
@@ -0,0 +1,347 @@
+package cmd
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Styles of workbook cells, as indexes into the cellXfs of xlsxStyles.
+const (
+	xlsxStyleDefault = iota
+	xlsxStyleHeader
+	xlsxStyleDuration
+	xlsxStyleDateTime
+	xlsxStyleDate
+	xlsxStyleHyperlink
+)
+
+const xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>
+%s</Types>`
+
+const xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`
+
+const xlsxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<numFmts count="3">
+<numFmt numFmtId="164" formatCode="[h]:mm"/>
+<numFmt numFmtId="165" formatCode="yyyy-mm-dd hh:mm"/>
+<numFmt numFmtId="166" formatCode="yyyy-mm-dd"/>
+</numFmts>
+<fonts count="3">
+<font><sz val="11"/><name val="Calibri"/></font>
+<font><b/><sz val="11"/><name val="Calibri"/></font>
+<font><u/><sz val="11"/><color rgb="FF0563C1"/><name val="Calibri"/></font>
+</fonts>
+<fills count="2">
+<fill><patternFill patternType="none"/></fill>
+<fill><patternFill patternType="gray125"/></fill>
+</fills>
+<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>
+<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>
+<cellXfs count="6">
+<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>
+<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>
+<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>
+<xf numFmtId="165" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>
+<xf numFmtId="166" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>
+<xf numFmtId="0" fontId="2" fillId="0" borderId="0" xfId="0" applyFont="1"/>
+</cellXfs>
+</styleSheet>`
+
+// xlsxDateColumns lists the columns holding RFC 3339 timestamps, which are
+// written as Excel dates.
+var xlsxDateColumns = map[string]bool{
+	"created-at": true,
+	"merged-at":  true,
+}
+
+// xlsxCell is a cell of a worksheet. Value is an int, a float64, a string,
+// a time.Time or a NullDuration, and the cell is left blank if it is nil,
+// an empty string or an invalid NullDuration.
+type xlsxCell struct {
+	Value interface{}
+	Style int
+	Link  string
+}
+
+// xlsxSheet is a worksheet, whose first row is a header.
+type xlsxSheet struct {
+	Name string
+	Rows [][]xlsxCell
+}
+
+// xlsxColumnName returns the letters identifying the column at a given
+// zero-based index, e.g. "A" or "AB".
+func xlsxColumnName(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+
+	return name
+}
+
+// xlsxSerial returns the Excel serial number of a time, the number of days
+// since 1899-12-30.
+func xlsxSerial(t time.Time) float64 {
+	epoch := time.Date(1899, time.December, 30, 0, 0, 0, 0, time.UTC)
+	return float64(t.Sub(epoch)) / float64(24*time.Hour)
+}
+
+// xlsxEscape escapes text for inclusion in XML.
+func xlsxEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// writeXLSXCell writes a cell, returning false if it is blank.
+func writeXLSXCell(b *strings.Builder, ref string, cell xlsxCell) bool {
+	style := cell.Style
+	if style == xlsxStyleDefault && cell.Link != "" {
+		style = xlsxStyleHyperlink
+	}
+
+	var number float64
+	switch v := cell.Value.(type) {
+	case int:
+		number = float64(v)
+	case float64:
+		number = v
+	case time.Time:
+		number = xlsxSerial(v)
+		if style == xlsxStyleDefault {
+			style = xlsxStyleDateTime
+		}
+	case NullDuration:
+		if !v.Valid {
+			return false
+		}
+		number = float64(v.Duration) / float64(24*time.Hour)
+		style = xlsxStyleDuration
+	case NullFloat:
+		if !v.Valid {
+			return false
+		}
+		number = v.Float64
+	case string:
+		if v == "" {
+			return false
+		}
+		fmt.Fprintf(b, `<c r="%s" s="%d" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, style, xlsxEscape(v))
+		return true
+	default:
+		return false
+	}
+
+	fmt.Fprintf(b, `<c r="%s" s="%d"><v>%s</v></c>`, ref, style, strconv.FormatFloat(number, 'f', -1, 64))
+	return true
+}
+
+// worksheet returns the XML of a worksheet, and the XML of its
+// relationships to the targets of its hyperlinks, if any.
+func (sheet xlsxSheet) worksheet() (string, string) {
+	var b, hyperlinks, rels strings.Builder
+
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	b.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">`)
+	b.WriteString(`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`)
+	b.WriteString("<sheetData>")
+
+	links := 0
+	for i, row := range sheet.Rows {
+		fmt.Fprintf(&b, `<row r="%d">`, i+1)
+		for j, cell := range row {
+			ref := fmt.Sprintf("%s%d", xlsxColumnName(j), i+1)
+			if !writeXLSXCell(&b, ref, cell) || cell.Link == "" {
+				continue
+			}
+
+			links++
+			fmt.Fprintf(&hyperlinks, `<hyperlink ref="%s" r:id="rId%d"/>`, ref, links)
+			fmt.Fprintf(&rels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/hyperlink" Target="%s" TargetMode="External"/>`, links, xlsxEscape(cell.Link))
+		}
+		b.WriteString("</row>")
+	}
+	b.WriteString("</sheetData>")
+
+	if links == 0 {
+		b.WriteString("</worksheet>")
+		return b.String(), ""
+	}
+
+	b.WriteString("<hyperlinks>" + hyperlinks.String() + "</hyperlinks></worksheet>")
+
+	return b.String(), `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n" +
+		`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` + rels.String() + "</Relationships>"
+}
+
+// writeXLSX returns an Excel workbook containing the given worksheets.
+func writeXLSX(sheets []xlsxSheet) ([]byte, error) {
+	var overrides, workbookSheets, workbookRels strings.Builder
+	files := map[string]string{}
+	names := []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml"}
+
+	for i, sheet := range sheets {
+		n := i + 1
+		name := fmt.Sprintf("xl/worksheets/sheet%d.xml", n)
+		worksheet, rels := sheet.worksheet()
+
+		files[name] = worksheet
+		names = append(names, name)
+		if rels != "" {
+			relsName := fmt.Sprintf("xl/worksheets/_rels/sheet%d.xml.rels", n)
+			files[relsName] = rels
+			names = append(names, relsName)
+		}
+
+		fmt.Fprintf(&overrides, `<Override PartName="/%s" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`+"\n", name)
+		fmt.Fprintf(&workbookSheets, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, xlsxEscape(sheet.Name), n, n)
+		fmt.Fprintf(&workbookRels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, n, n)
+	}
+	fmt.Fprintf(&workbookRels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, len(sheets)+1)
+
+	files["[Content_Types].xml"] = fmt.Sprintf(xlsxContentTypes, overrides.String())
+	files["_rels/.rels"] = xlsxRootRels
+	files["xl/styles.xml"] = xlsxStyles
+	files["xl/workbook.xml"] = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n" +
+		`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>` +
+		workbookSheets.String() + "</sheets></workbook>"
+	files["xl/_rels/workbook.xml.rels"] = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n" +
+		`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` + workbookRels.String() + "</Relationships>"
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range names {
+		w, err := zw.Create(name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write([]byte(files[name])); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// xlsxHeader returns a header row.
+func xlsxHeader(names ...string) []xlsxCell {
+	row := make([]xlsxCell, 0, len(names))
+	for _, name := range names {
+		row = append(row, xlsxCell{Value: name, Style: xlsxStyleHeader})
+	}
+
+	return row
+}
+
+// xlsxPullRequestsSheet returns a worksheet with the selected columns of
+// every PR.
+func (ui *UI) xlsxPullRequestsSheet(metrics []PullRequestMetrics) xlsxSheet {
+	columns := ui.columns()
+
+	var headers []string
+	for _, c := range columns {
+		headers = append(headers, c.Header)
+	}
+	rows := [][]xlsxCell{xlsxHeader(headers...)}
+
+	for _, m := range metrics {
+		row := make([]xlsxCell, 0, len(columns))
+		for _, c := range columns {
+			cell := xlsxCell{Value: c.Value(m)}
+			switch v := cell.Value.(type) {
+			case []string:
+				cell.Value = strings.Join(v, ", ")
+			case string:
+				if !xlsxDateColumns[c.Key] {
+					break
+				}
+				if t, err := time.Parse(time.RFC3339, v); err == nil {
+					cell.Value = t
+				}
+			}
+			if c.Key == "number" {
+				cell.Link = m.PullRequest.URL
+			}
+			row = append(row, cell)
+		}
+		rows = append(rows, row)
+	}
+
+	return xlsxSheet{Name: "PRs", Rows: rows}
+}
+
+// xlsxSummarySheet returns a worksheet with summary statistics of the
+// given duration metrics.
+func xlsxSummarySheet(metrics []PullRequestMetrics, durationMetrics []DurationMetric) xlsxSheet {
+	rows := [][]xlsxCell{xlsxHeader("Metric", "PRs", "Median", "Mean", "90th Percentile")}
+	for _, summary := range summarize(metrics, durationMetrics) {
+		rows = append(rows, []xlsxCell{
+			{Value: summary.Name},
+			{Value: summary.Count},
+			{Value: summary.Median},
+			{Value: summary.Mean},
+			{Value: summary.P90},
+		})
+	}
+
+	return xlsxSheet{Name: "Summary", Rows: rows}
+}
+
+// xlsxTrendSheet returns a worksheet with the weekly median of the given
+// duration metrics.
+func xlsxTrendSheet(metrics []PullRequestMetrics, durationMetrics []DurationMetric) xlsxSheet {
+	headers := []string{"Week", "PRs"}
+	for _, metric := range durationMetrics {
+		headers = append(headers, metric.Name)
+	}
+	rows := [][]xlsxCell{xlsxHeader(headers...)}
+
+	for _, point := range weeklyTrend(metrics, durationMetrics) {
+		week, _ := time.Parse(DefaultDateFormat, point.Week)
+		row := []xlsxCell{
+			{Value: week, Style: xlsxStyleDate},
+			{Value: point.PullRequests},
+		}
+		for _, median := range point.Medians {
+			row = append(row, xlsxCell{Value: median})
+		}
+		rows = append(rows, row)
+	}
+
+	return xlsxSheet{Name: "Trend", Rows: rows}
+}
+
+// renderXLSX returns an Excel workbook with a sheet of PRs, a sheet of
+// summary statistics and a sheet of weekly trends. Durations are written as
+// Excel time values and dates as Excel dates.
+func (ui *UI) renderXLSX(metrics []PullRequestMetrics) string {
+	workbook, err := writeXLSX([]xlsxSheet{
+		ui.xlsxPullRequestsSheet(metrics),
+		xlsxSummarySheet(metrics, ui.durationMetrics()),
+		xlsxTrendSheet(metrics, ui.durationMetrics()),
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return string(workbook)
+}
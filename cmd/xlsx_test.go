@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nbio/st"
+	"gopkg.in/h2non/gock.v1"
+)
+
+// readXLSX returns the contents of every file in a workbook.
+func readXLSX(t *testing.T, workbook []byte) map[string]string {
+	t.Helper()
+
+	zr, err := zip.NewReader(bytes.NewReader(workbook), int64(len(workbook)))
+	st.Assert(t, err, nil)
+
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		st.Assert(t, err, nil)
+		content, err := io.ReadAll(rc)
+		st.Assert(t, err, nil)
+		rc.Close()
+		files[f.Name] = string(content)
+	}
+
+	return files
+}
+
+func Test_xlsxColumnName(t *testing.T) {
+	st.Assert(t, xlsxColumnName(0), "A")
+	st.Assert(t, xlsxColumnName(25), "Z")
+	st.Assert(t, xlsxColumnName(26), "AA")
+	st.Assert(t, xlsxColumnName(27), "AB")
+	st.Assert(t, xlsxColumnName(701), "ZZ")
+	st.Assert(t, xlsxColumnName(702), "AAA")
+}
+
+func Test_writeXLSX(t *testing.T) {
+	workbook, err := writeXLSX([]xlsxSheet{
+		{Name: "A & B", Rows: [][]xlsxCell{
+			xlsxHeader("Number", "Duration", "Name"),
+			{{Value: 1, Link: "https://example.com/?a=1&b=2"}, {Value: NullDuration{Duration: 36 * 60 * 60 * 1e9, Valid: true}}, {Value: "<none>"}},
+			{{Value: 2}, {Value: NullDuration{}}, {Value: ""}},
+		}},
+		{Name: "Empty", Rows: [][]xlsxCell{xlsxHeader("Nothing")}},
+	})
+	st.Assert(t, err, nil)
+
+	files := readXLSX(t, workbook)
+	st.Assert(t, len(files), 8)
+	st.Assert(t, strings.Contains(files["xl/workbook.xml"], `<sheet name="A &amp; B" sheetId="1" r:id="rId1"/><sheet name="Empty" sheetId="2" r:id="rId2"/>`), true)
+	st.Assert(t, strings.Contains(files["xl/_rels/workbook.xml.rels"], `<Relationship Id="rId3" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`), true)
+	st.Assert(t, strings.Contains(files["[Content_Types].xml"], `<Override PartName="/xl/worksheets/sheet2.xml"`), true)
+
+	sheet := files["xl/worksheets/sheet1.xml"]
+	st.Assert(t, strings.Contains(sheet, `<row r="2"><c r="A2" s="5"><v>1</v></c><c r="B2" s="2"><v>1.5</v></c><c r="C2" s="0" t="inlineStr"><is><t xml:space="preserve">&lt;none&gt;</t></is></c></row>`), true)
+	st.Assert(t, strings.Contains(sheet, `<row r="3"><c r="A3" s="0"><v>2</v></c></row>`), true)
+	st.Assert(t, strings.Contains(sheet, `<hyperlinks><hyperlink ref="A2" r:id="rId1"/></hyperlinks>`), true)
+	st.Assert(t, strings.Contains(files["xl/worksheets/_rels/sheet1.xml.rels"], `Target="https://example.com/?a=1&amp;b=2" TargetMode="External"`), true)
+
+	_, ok := files["xl/worksheets/_rels/sheet2.xml.rels"]
+	st.Assert(t, ok, false)
+}
+
+func Test_RootCmd_XLSX(t *testing.T) {
+	defer gock.Off()
+
+	gock.New("https://api.github.com/graphql").
+		Post("/").
+		MatchType("json").
+		AddMatcher(gqlSearchQueryMatcher(Owner, Repository, StartDate, EndDate)).
+		Reply(200).
+		BodyString(ResponseJSON)
+
+	output := filepath.Join(t.TempDir(), "report.xlsx")
+	execute(t, fmt.Sprintf("--repo=%s/%s --start=%s --end=%s --format=xlsx --output=%s --columns=number,merged-at,time-to-first-review,labels", Owner, Repository, StartDate, EndDate, output))
+
+	workbook, err := os.ReadFile(output)
+	st.Assert(t, err, nil)
+
+	files := readXLSX(t, workbook)
+	st.Assert(t, strings.Contains(files["xl/workbook.xml"], `<sheet name="PRs" sheetId="1" r:id="rId1"/><sheet name="Summary" sheetId="2" r:id="rId2"/><sheet name="Trend" sheetId="3" r:id="rId3"/>`), true)
+
+	prs := files["xl/worksheets/sheet1.xml"]
+	st.Assert(t, strings.Contains(prs, `<c r="A1" s="1" t="inlineStr"><is><t xml:space="preserve">PR</t></is></c>`), true)
+	st.Assert(t, strings.Contains(prs, `<c r="A2" s="5"><v>5339</v></c>`), true)
+	st.Assert(t, strings.Contains(prs, `<c r="C2" s="2"><v>6.476689814814815</v></c>`), true)
+	st.Assert(t, strings.Contains(prs, `<c r="D2" s="0" t="inlineStr"><is><t xml:space="preserve">bug</t></is></c>`), true)
+	st.Assert(t, strings.Contains(files["xl/worksheets/_rels/sheet1.xml.rels"], `Target="https://github.com/testOwner/testRepo/pull/5339"`), true)
+
+	st.Assert(t, strings.Contains(files["xl/worksheets/sheet2.xml"], `<c r="A2" s="0" t="inlineStr"><is><t xml:space="preserve">Time to First Review</t></is></c><c r="B2" s="0"><v>2</v></c>`), true)
+	st.Assert(t, strings.Contains(files["xl/worksheets/sheet3.xml"], `<c r="A2" s="4"><v>44641</v></c><c r="B2" s="0"><v>2</v></c>`), true)
+}
+
+func Test_RootCmd_XLSXWithoutOutput(t *testing.T) {
+	actual := execute(t, "--repo=cli/cli --format=xlsx")
+
+	st.Assert(t, strings.Contains(actual, "--format xlsx requires --output"), true)
+}